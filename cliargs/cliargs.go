@@ -32,12 +32,14 @@ import (
 	"github.com/vrmiguel/particeps/particeps"
 )
 
-const usage = "Usage: ./particeps [-h, --help] [-a, --anonfiles] [-F, --filebin] [-b, --bayfiles] -f, --filename path-to-file"
+const usage = "Usage: ./particeps [-h, --help] [-a, --anonfiles] [-F, --filebin] [-b, --bayfiles] [-i, --imgur] [-q, --qr] [--qr-png path] -f, --filename path-to-file"
 
 // CLIArgs stores the passed command-line options
 type CLIArgs struct {
 	Destination int
 	Filename    string
+	QRCode      bool   // Print a QR code of the link
+	QRCodeFile  string // Save a QR code of the link as a PNG image
 }
 
 func printHelp() {
@@ -45,8 +47,11 @@ func printHelp() {
 	fmt.Printf("%-16s\tUpload the file to anonfiles.com\n", "-a, --anonfiles")
 	fmt.Printf("%-16s\tUpload the file to bayfiles.com\n", "-b, --bayfiles")
 	fmt.Printf("%-16s\tUpload the file to filebin.net\n", "-F, --filebin")
+	fmt.Printf("%-16s\tUpload the image to imgur.com\n", "-i, --imgur")
 	fmt.Printf("%-16s\tUpload the image to imagebin.net\n", "-F, --imagebin")
 	fmt.Printf("%-16s\tIndicates the file to be uploaded.\n", "-f, --filename")
+	fmt.Printf("%-16s\tPrint a QR code of the link.\n", "-q, --qr")
+	fmt.Printf("%-16s\tSave a QR code of the link as a PNG image.\n", "--qr-png")
 	fmt.Println(usage)
 }
 
@@ -69,8 +74,20 @@ func ParseCLIArgs(args []string) CLIArgs {
 			cfg.Destination = particeps.BayFiles
 		} else if arg == "-F" || arg == "--filebin" {
 			cfg.Destination = particeps.Filebin
+		} else if arg == "-i" || arg == "--imgur" {
+			cfg.Destination = particeps.Imgur
 		} else if arg == "-I" || arg == "--imagebin" {
 			cfg.Destination = particeps.Imagebin
+		} else if arg == "-q" || arg == "--qr" {
+			cfg.QRCode = true
+		} else if arg == "--qr-png" {
+			if i+1 >= len(args) || args[i+1] == "" {
+				fmt.Println("error: missing value to --qr-png")
+				fmt.Println(usage)
+				os.Exit(1)
+			}
+			i++
+			cfg.QRCodeFile = args[i]
 		} else if arg == "-f" || arg == "--filename" {
 			if i+1 >= len(args) || args[i+1] == "" {
 				fmt.Println("error: missing value to -f, --filename")
@@ -84,10 +101,15 @@ func ParseCLIArgs(args []string) CLIArgs {
 			os.Exit(1)
 		}
 	}
+	return cfg
+}
+
+// RequireDestination exits with an error if no destination was given, either on the command line
+// or as the config file's default provider
+func (cfg CLIArgs) RequireDestination() {
 	if cfg.Destination == 0 {
 		fmt.Fprintln(os.Stderr, "error: destination not supplied")
 		fmt.Println(usage)
 		os.Exit(1)
 	}
-	return cfg
 }
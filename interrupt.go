@@ -0,0 +1,15 @@
+//go:build go1.16
+// +build go1.16
+
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+)
+
+// interruptContext returns a context cancelled by Ctrl-C. The returned function stops catching it.
+func interruptContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt)
+}
@@ -0,0 +1,93 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMainProcess runs the CLI when started by TestInterruptCancelsUpload, with the arguments in PARTICEPS_ARGS
+func TestMainProcess(t *testing.T) {
+	if os.Getenv("PARTICEPS_ARGS") == "" {
+		return
+	}
+	os.Args = append([]string{"particeps"}, strings.Fields(os.Getenv("PARTICEPS_ARGS"))...)
+	main()
+	os.Exit(0)
+}
+
+func TestInterruptCancelsUpload(t *testing.T) {
+	arrived, release := make(chan struct{}, 1), make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		select {
+		case arrived <- struct{}{}:
+		default:
+		}
+		<-release // never answers, as a stalled provider
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	dir, err := ioutil.TempDir("", "particeps-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(filepath.Join(dir, "particeps"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	config := `{"default_provider": "ttm.sh", "endpoints": {"ttm.sh": "` + srv.URL + `"}}`
+	if err := ioutil.WriteFile(filepath.Join(dir, "particeps", "config.json"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "notes.txt")
+	if err := ioutil.WriteFile(file, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestMainProcess$")
+	cmd.Env = append(os.Environ(), "PARTICEPS_ARGS=-f "+file, "XDG_CONFIG_HOME="+dir, "HOME="+dir)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-arrived:
+	case <-time.After(10 * time.Second):
+		cmd.Process.Kill()
+		t.Fatalf("the upload never reached the provider: %s", stderr.String())
+	}
+
+	interrupted := time.Now()
+	cmd.Process.Signal(os.Interrupt)
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err = <-done:
+	case <-time.After(5 * time.Second):
+		cmd.Process.Kill()
+		t.Fatal("the CLI kept waiting on the upload after Ctrl-C")
+	}
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok || exitErr.ExitCode() != 130 {
+		t.Fatalf("exited with %v, want status 130", err)
+	}
+	if !strings.Contains(stderr.String(), "upload cancelled") {
+		t.Fatalf("stderr = %q, want the cancellation reported", stderr.String())
+	}
+	if elapsed := time.Since(interrupted); elapsed > 2*time.Second {
+		t.Fatalf("took %s to exit after Ctrl-C", elapsed)
+	}
+}
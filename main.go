@@ -1,13 +1,16 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"image/png"
 	"log"
 	"os"
 
 	"github.com/vrmiguel/particeps/cliargs"
 
 	"github.com/vrmiguel/particeps/particeps"
+	"github.com/vrmiguel/particeps/qrcode"
 )
 
 func assertNonNil(err error) {
@@ -16,50 +19,113 @@ func assertNonNil(err error) {
 	}
 }
 
+// assertUploaded exits on a failed upload like assertNonNil, unless the upload was cancelled by Ctrl-C,
+// in which case it says so and exits with the status shells give to interrupted commands
+func assertUploaded(ctx context.Context, stop context.CancelFunc, err error) {
+	if err != nil && ctx.Err() != nil {
+		stop()
+		fmt.Fprintln(os.Stderr, "particeps: upload cancelled")
+		os.Exit(130)
+	}
+	assertNonNil(err)
+}
+
+// printQR shows and/or saves a QR code of the link, as requested on the command line
+func printQR(cfg cliargs.CLIArgs, url string) {
+	if !cfg.QRCode && cfg.QRCodeFile == "" {
+		return
+	}
+	code, err := qrcode.Encode(url)
+	assertNonNil(err)
+	if cfg.QRCode {
+		assertNonNil(code.WriteText(os.Stdout))
+	}
+	if cfg.QRCodeFile != "" {
+		f, err := os.Create(cfg.QRCodeFile)
+		assertNonNil(err)
+		defer f.Close()
+		assertNonNil(png.Encode(f, code.Image(8)))
+		fmt.Printf("particeps: QR code saved to %s\n", cfg.QRCodeFile)
+	}
+}
+
 // helper function for AnonFiles & BayFiles
 // anonfiles == true  => anonfiles
 // anonfiles == false => bayfiles
-func helperAnonFiles(cfg cliargs.CLIArgs, anonfiles bool) {
-	uploadFunction := particeps.AnonFilesUpload
+func helperAnonFiles(ctx context.Context, stop context.CancelFunc, cfg cliargs.CLIArgs, anonfiles bool) {
+	provider := particeps.AnonFiles
 	var website string
 	if anonfiles {
 		website = "https://anonfiles.com"
 	} else {
 		website = "https://bayfiles.com"
-		uploadFunction = particeps.BayFilesUpload
+		provider = particeps.BayFiles
 	}
 	fmt.Println(website)
-	res, err := uploadFunction(cfg.Filename)
-	assertNonNil(err)
+	res, err := particeps.UploadContext(ctx, provider, cfg.Filename)
+	assertUploaded(ctx, stop, err)
 	fmt.Printf("particeps: successfully uploaded \"%s\" to %s/\n", cfg.Filename, website)
 	fmt.Printf("particeps: full-length link: %s\n", res.FullURL)
 	fmt.Printf("particeps: short link: %s\n", res.ShortURL)
+	printQR(cfg, res.FullURL)
 }
 
 func main() {
 	cfg := cliargs.ParseCLIArgs(os.Args)
 	fmt.Printf("config folder: %s\n", particeps.GetPrefFolder())
+	if client, err := particeps.LoadConfig(""); err == nil {
+		particeps.DefaultClient = client
+	} else if !os.IsNotExist(err) {
+		log.Fatal(err)
+	}
+	// Like cp, the CLI uploads what a symlink points to; CheckFile below follows the same rule
+	particeps.DefaultClient.ResolveSymlinks = true
+	if cfg.Destination == 0 {
+		cfg.Destination = particeps.DefaultClient.DefaultProvider
+	}
+	cfg.RequireDestination()
+	// Ctrl-C cancels the upload in flight instead of leaving it to time out
+	ctx, stop := interruptContext()
+	defer stop()
 	fileSize, err := particeps.CheckFile(cfg.Filename)
 	assertNonNil(err)
 	fmt.Printf("particeps: file \"%s\" has size %s\n", cfg.Filename, fileSize)
 	fmt.Printf("particeps: uploading to ")
 	switch cfg.Destination {
 	case particeps.AnonFiles:
-		helperAnonFiles(cfg, true)
+		helperAnonFiles(ctx, stop, cfg, true)
 	case particeps.BayFiles:
-		helperAnonFiles(cfg, false)
+		helperAnonFiles(ctx, stop, cfg, false)
 	case particeps.Filebin:
 		fmt.Println("https://filebin.com")
-		res, err := particeps.FilebinUpload(cfg.Filename)
-		assertNonNil(err)
+		res, err := particeps.UploadContext(ctx, particeps.Filebin, cfg.Filename)
+		assertUploaded(ctx, stop, err)
 		fmt.Printf("particeps: successfully uploaded \"%s\" to https://filebin.com\n", cfg.Filename)
 		fmt.Printf("particeps: full-length link: %s\n", res.FullURL)
 		fmt.Println("particeps: bear in mind that Filebin only stores the files for a week.")
+		printQR(cfg, res.FullURL)
+	case particeps.Imgur:
+		fmt.Println("https://imgur.com")
+		res, err := particeps.UploadContext(ctx, particeps.Imgur, cfg.Filename)
+		assertUploaded(ctx, stop, err)
+		fmt.Printf("particeps: successfully uploaded \"%s\" to https://imgur.com\n", cfg.Filename)
+		fmt.Printf("particeps: full-length link: %s\n", res.FullURL)
+		printQR(cfg, res.FullURL)
 	case particeps.Imagebin:
 		fmt.Println("http://imagebin.ca")
 		fmt.Println("particeps: warning - Imagebin support is unstable and experimental")
-		res, err := particeps.ImagebinUpload(cfg.Filename)
+		res, err := particeps.UploadContext(ctx, particeps.Imagebin, cfg.Filename)
+		assertUploaded(ctx, stop, err)
+		fmt.Printf("particeps: full-length link: %s\n", res.FullURL)
+		printQR(cfg, res.FullURL)
+	default:
+		info, err := particeps.ProviderInfo(cfg.Destination)
 		assertNonNil(err)
+		fmt.Println(info.Name)
+		res, err := particeps.UploadContext(ctx, cfg.Destination, cfg.Filename)
+		assertUploaded(ctx, stop, err)
+		fmt.Printf("particeps: successfully uploaded \"%s\" to %s\n", cfg.Filename, info.Name)
 		fmt.Printf("particeps: full-length link: %s\n", res.FullURL)
+		printQR(cfg, res.FullURL)
 	}
 }
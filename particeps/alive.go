@@ -0,0 +1,52 @@
+package particeps
+
+import (
+	"context"
+	"net/http"
+)
+
+// IsAlive reports whether url, e.g. one returned by an earlier upload, still serves its file.
+// 200 and 206 mean alive and 404 and 410 mean gone; any other status is returned as a *StatusError.
+func IsAlive(ctx context.Context, url string) (bool, error) {
+	return DefaultClient.IsAlive(ctx, url)
+}
+
+// IsAlive reports whether url still serves its file. It sends a HEAD request, falling back on
+// a GET of the first byte for hosts that don't answer HEAD.
+func (c *Client) IsAlive(ctx context.Context, url string) (bool, error) {
+	resp, err := c.probe(ctx, "HEAD", url)
+	if err != nil {
+		return false, err
+	}
+	switch resp.StatusCode {
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented, http.StatusForbidden:
+		if resp, err = c.probe(ctx, "GET", url); err != nil {
+			return false, err
+		}
+	}
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusPartialContent:
+		return true, nil
+	case http.StatusNotFound, http.StatusGone:
+		return false, nil
+	}
+	return false, &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+}
+
+// probe sends a body-less request for url, asking GETs for the first byte only.
+// The returned response's body is already closed.
+func (c *Client) probe(ctx context.Context, method, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if method == "GET" {
+		req.Header.Set("Range", "bytes=0-0")
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp, nil
+}
@@ -0,0 +1,70 @@
+package particeps
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIsAlive(t *testing.T) {
+	var methods []string
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		ranges = append(ranges, r.Header.Get("Range"))
+		switch r.URL.Path {
+		case "/ok":
+			w.Write([]byte("hello"))
+		case "/gone":
+			w.WriteHeader(http.StatusGone)
+		case "/no-head":
+			if r.Method == "HEAD" {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.Header().Set("Content-Range", "bytes 0-0/5")
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write([]byte("h"))
+		case "/busy":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	c := &Client{}
+
+	tests := []struct {
+		path    string
+		alive   bool
+		status  int
+		methods []string
+	}{
+		{"/ok", true, 0, []string{"HEAD"}},
+		{"/missing", false, 0, []string{"HEAD"}},
+		{"/gone", false, 0, []string{"HEAD"}},
+		{"/no-head", true, 0, []string{"HEAD", "GET"}},
+		{"/busy", false, http.StatusServiceUnavailable, []string{"HEAD"}},
+	}
+	for _, tc := range tests {
+		methods, ranges = nil, nil
+		alive, err := c.IsAlive(context.Background(), srv.URL+tc.path)
+		var statusErr *StatusError
+		if tc.status != 0 {
+			if !errors.As(err, &statusErr) || statusErr.StatusCode != tc.status {
+				t.Errorf("%s: err = %v, want a StatusError for %d", tc.path, err, tc.status)
+			}
+		} else if err != nil || alive != tc.alive {
+			t.Errorf("%s: got %v, %v, want %v", tc.path, alive, err, tc.alive)
+		}
+		if strings.Join(methods, " ") != strings.Join(tc.methods, " ") {
+			t.Errorf("%s: sent %v, want %v", tc.path, methods, tc.methods)
+		}
+		if last := len(methods) - 1; methods[last] == "GET" && ranges[last] != "bytes=0-0" {
+			t.Errorf("%s: GET asked for range %q, want the first byte only", tc.path, ranges[last])
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package particeps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrNoEligibleProvider is returned by UploadAuto when no candidate provider takes the file's size and media type
+var ErrNoEligibleProvider = errors.New("particeps: no provider takes this file")
+
+// UploadAuto uploads the given file to the first of the Client's AutoProviders that takes its size and media type,
+// moving on to the next one as UploadFallback does
+func UploadAuto(filename string, opts ...Option) (ProviderResult, error) {
+	return DefaultClient.UploadAutoContext(context.Background(), filename, opts...)
+}
+
+// UploadAutoContext uploads the given file to the first eligible provider that accepts it.
+// WithPreferredProviders reorders the candidates for this call.
+func (c *Client) UploadAutoContext(ctx context.Context, filename string, opts ...Option) (ProviderResult, error) {
+	candidates, err := c.autoCandidates(filename, newUploadOptions(opts))
+	if err != nil {
+		return ProviderResult{Filename: filename, Err: err}, err
+	}
+	return c.UploadFallbackContext(ctx, candidates, filename, opts...)
+}
+
+// autoCandidates lists the providers UploadAuto tries for the given file, in order
+func (c *Client) autoCandidates(filename string, o uploadOptions) ([]int, error) {
+	f, name, err := c.openFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	size := readerSize(f)
+	contentType := o.contentType
+	if contentType == "" {
+		contentType, _, err = sniffContentType(f, name)
+	}
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	pool := c.AutoProviders
+	if pool == nil {
+		pool = ProvidersMatching(func(Capabilities) bool { return true })
+	}
+	var candidates []int
+	for _, provider := range pool {
+		def, err := lookupProvider(provider)
+		if err != nil {
+			return nil, err
+		}
+		if c.endpoint(provider, def, o) == "" {
+			continue // e.g. S3, whose endpoint is given per upload
+		}
+		if def.accepts(size, contentType) {
+			candidates = append(candidates, provider)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w: %s is %d bytes of %s", ErrNoEligibleProvider, filename, size, contentType)
+	}
+	preferProviders(candidates, o.preferredProviders)
+	return candidates, nil
+}
+
+// accepts reports whether the provider takes a file of the given size, or -1 if unknown, and media type
+func (def *providerDef) accepts(size int64, contentType string) bool {
+	if max := def.caps.maxSize(); size >= 0 && max > 0 && size > max {
+		return false
+	}
+	if len(def.mediaTypes) == 0 {
+		return true
+	}
+	for _, prefix := range def.mediaTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// preferProviders moves the preferred candidates to the front, in the order they are preferred,
+// keeping the others in their order
+func preferProviders(candidates, preferred []int) {
+	rank := make(map[int]int, len(preferred))
+	for i, provider := range preferred {
+		if _, seen := rank[provider]; !seen {
+			rank[provider] = i
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		ri, iPreferred := rank[candidates[i]]
+		rj, jPreferred := rank[candidates[j]]
+		if iPreferred && jPreferred {
+			return ri < rj
+		}
+		return iPreferred && !jPreferred
+	})
+}
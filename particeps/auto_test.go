@@ -0,0 +1,58 @@
+package particeps
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestUploadAutoPreferredProviders(t *testing.T) {
+	ttmSh := newTextServer(t, "https://ttm.sh/abc.txt")
+	transferSh := newTextServer(t, "https://transfer.sh/abc/notes.txt")
+	uguu := newTextServer(t, `{"success": true, "files": [{"url": "https://a.uguu.se/abc.txt"}]}`)
+	kekSh := newTextServer(t, `{"filename": "abc.png"}`)
+	c := &Client{
+		Endpoints:     map[int]string{TtmSh: ttmSh.URL, TransferSh: transferSh.URL, Uguu: uguu.URL, KekSh: kekSh.URL},
+		AutoProviders: []int{TtmSh, TransferSh, KekSh, Uguu},
+	}
+	path := writeTestFile(t, "notes.txt", []byte("hello"))
+
+	// kek.sh only takes images, so Uguu is the first eligible preferred provider
+	result, err := c.UploadAutoContext(context.Background(), path, WithPreferredProviders(KekSh, Uguu))
+	if err != nil || result.Provider != Uguu {
+		t.Fatalf("got provider %d, %v, want Uguu", result.Provider, err)
+	}
+	if len(uguu.received()) != 1 || len(ttmSh.received())+len(transferSh.received())+len(kekSh.received()) != 0 {
+		t.Fatal("a provider other than the preferred one was tried")
+	}
+
+	result, err = c.UploadAutoContext(context.Background(), path)
+	if err != nil || result.Provider != TtmSh {
+		t.Fatalf("without a preference: got provider %d, %v, want ttm.sh", result.Provider, err)
+	}
+	if want := []int{TtmSh, TransferSh, KekSh, Uguu}; !reflect.DeepEqual(c.AutoProviders, want) {
+		t.Fatalf("AutoProviders changed to %v", c.AutoProviders)
+	}
+
+	// A failing preferred provider falls over to the rest, in the Client's order
+	c.Endpoints[Uguu] = statusServer(t, http.StatusServiceUnavailable).URL
+	result, err = c.UploadAutoContext(context.Background(), path, WithPreferredProviders(Uguu))
+	if err != nil || result.Provider != TtmSh {
+		t.Fatalf("after Uguu failed: got provider %d, %v, want ttm.sh", result.Provider, err)
+	}
+
+	c.AutoProviders = []int{KekSh}
+	if _, err := c.UploadAutoContext(context.Background(), path); !errors.Is(err, ErrNoEligibleProvider) {
+		t.Fatalf("err = %v, want ErrNoEligibleProvider", err)
+	}
+}
+
+func TestPreferProviders(t *testing.T) {
+	candidates := []int{1, 2, 3, 4, 5}
+	preferProviders(candidates, []int{4, 9, 2, 4})
+	if want := []int{4, 2, 1, 3, 5}; !reflect.DeepEqual(candidates, want) {
+		t.Fatalf("got %v, want %v", candidates, want)
+	}
+}
@@ -0,0 +1,215 @@
+package particeps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FileErrors maps each file that could not be processed to the reason why
+type FileErrors map[string]error
+
+func (e FileErrors) Error() string {
+	filenames := make([]string, 0, len(e))
+	for filename := range e {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+	msgs := make([]string, len(filenames))
+	for i, filename := range filenames {
+		msgs[i] = filename + ": " + e[filename].Error()
+	}
+	return "particeps: " + strings.Join(msgs, "; ")
+}
+
+// EstimateBatchSize sums the sizes of the given files, skipping directories.
+// Files that can't be stat'ed are left out of the total and reported in a FileErrors.
+func EstimateBatchSize(filenames []string) (totalBytes int64, pretty string, err error) {
+	return estimateBatchSize(filenames, false)
+}
+
+// EstimateBatchSizeRecursive is like EstimateBatchSize, but sums the contents of directories as well
+func EstimateBatchSizeRecursive(filenames []string) (totalBytes int64, pretty string, err error) {
+	return estimateBatchSize(filenames, true)
+}
+
+func estimateBatchSize(filenames []string, recursive bool) (int64, string, error) {
+	var total int64
+	errs := FileErrors{}
+	for _, filename := range filenames {
+		info, err := DefaultClient.fileSystem().Stat(filename)
+		if err != nil {
+			errs[filename] = err
+			continue
+		}
+		if !info.IsDir() {
+			total += info.Size()
+			continue
+		}
+		if !recursive {
+			continue
+		}
+		err = DefaultClient.walk(filename, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				errs[path] = err
+				return nil
+			}
+			if info.Mode().IsRegular() {
+				total += info.Size()
+			}
+			return nil
+		})
+		if err != nil {
+			errs[filename] = err
+		}
+	}
+	if len(errs) > 0 {
+		return total, PrettySize(total), errs
+	}
+	return total, PrettySize(total), nil
+}
+
+// dirUploadWorkers is how many files UploadDir uploads at once
+const dirUploadWorkers = 4
+
+// UploadDir uploads every regular file under dir, recursively, to the given provider.
+// The results are keyed by each file's path.
+func UploadDir(provider int, dir string, opts ...Option) (map[string]ProviderResult, error) {
+	return DefaultClient.UploadDirContext(context.Background(), provider, dir, opts...)
+}
+
+// UploadDirContext uploads every regular file under dir, recursively, to the given provider.
+// Failed uploads are reported in their ProviderResult; the error is only set if dir couldn't be walked,
+// or with WithAbortOnFirstError, in which case it is the first failure.
+func (c *Client) UploadDirContext(ctx context.Context, provider int, dir string, opts ...Option) (map[string]ProviderResult, error) {
+	var filenames []string
+	err := c.walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			filenames = append(filenames, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var duplicates map[string][]string
+	if newUploadOptions(opts).dedupe {
+		if filenames, duplicates, err = c.dedupe(filenames); err != nil {
+			return nil, err
+		}
+	}
+	renames, err := resolveCollisions(filenames, newUploadOptions(opts).renameCollisions)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := newBatch(ctx, opts)
+	defer batch.cancel()
+	results := make(map[string]ProviderResult, len(filenames))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	jobs := make(chan string)
+	for i := 0; i < dirUploadWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for filename := range jobs {
+				fileOpts := opts
+				if renamed, ok := renames[filename]; ok {
+					fileOpts = append(opts[:len(opts):len(opts)], WithRemoteName(renamed))
+				}
+				res, err := c.UploadContext(batch.ctx, provider, filename, fileOpts...)
+				result := ProviderResult{Provider: provider, Filename: filename, RenamedTo: renames[filename], Response: res, Err: err}
+				mu.Lock()
+				results[filename] = result
+				mu.Unlock()
+				batch.done(result)
+			}
+		}()
+	}
+	for _, filename := range filenames {
+		jobs <- filename
+	}
+	close(jobs)
+	wg.Wait()
+	for original, copies := range duplicates {
+		for _, filename := range copies {
+			result := results[original]
+			result.Filename = filename
+			results[filename] = result
+		}
+	}
+	return results, batch.err()
+}
+
+// dedupe hashes the given files and keeps only the first file with each content.
+// The returned map holds, for each kept file, the files that are copies of it.
+func (c *Client) dedupe(filenames []string) (unique []string, duplicates map[string][]string, err error) {
+	first := make(map[string]string, len(filenames))
+	duplicates = map[string][]string{}
+	for _, filename := range filenames {
+		f, _, err := c.openFile(filename)
+		if err != nil {
+			return nil, nil, err
+		}
+		digest, err := hashFile(f)
+		f.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+		if original, seen := first[digest]; seen {
+			duplicates[original] = append(duplicates[original], filename)
+			continue
+		}
+		first[digest] = filename
+		unique = append(unique, filename)
+	}
+	return unique, duplicates, nil
+}
+
+// ErrNameCollision is returned by UploadDir when files in different directories share a name
+// and WithRenameCollisions wasn't used
+var ErrNameCollision = errors.New("particeps: several files share the same name")
+
+// resolveCollisions finds files whose base names clash with an earlier file's. If rename is false,
+// this is an error; otherwise each clashing file is given a new name with a numeric suffix, e.g. "notes-2.txt".
+// The returned map holds the new name of every renamed file.
+func resolveCollisions(filenames []string, rename bool) (map[string]string, error) {
+	taken := make(map[string]bool, len(filenames))
+	for _, filename := range filenames {
+		taken[remoteFilename(filename)] = true
+	}
+
+	seen := make(map[string]string, len(filenames))
+	renames := map[string]string{}
+	for _, filename := range filenames {
+		name := remoteFilename(filename)
+		first, clash := seen[name]
+		if !clash {
+			seen[name] = filename
+			continue
+		}
+		if !rename {
+			return nil, fmt.Errorf("%w: %q and %q", ErrNameCollision, first, filename)
+		}
+		ext := filepath.Ext(name)
+		stem := strings.TrimSuffix(name, ext)
+		for i := 2; ; i++ {
+			candidate := fmt.Sprintf("%s-%d%s", stem, i, ext)
+			if !taken[candidate] {
+				taken[candidate] = true
+				renames[filename] = candidate
+				break
+			}
+		}
+	}
+	return renames, nil
+}
@@ -0,0 +1,198 @@
+package particeps
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestEstimateBatchSize(t *testing.T) {
+	dir := tempDir(t)
+	write := func(name string, size int) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing.txt")
+	filenames := []string{write("a.bin", 1000), write("b.bin", 2048), filepath.Join(dir, "sub"), missing}
+	write("sub/c.bin", 1024)
+
+	total, pretty, err := EstimateBatchSize(filenames)
+	if total != 3048 || pretty != "2.98 KB" {
+		t.Fatalf("got %d bytes (%s), want 3048 bytes with the directory skipped", total, pretty)
+	}
+	var errs FileErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || !os.IsNotExist(errs[missing]) {
+		t.Fatalf("err = %v, want only %s reported as missing", err, missing)
+	}
+
+	total, _, _ = EstimateBatchSizeRecursive(filenames)
+	if total != 4072 {
+		t.Fatalf("recursive total = %d, want 4072", total)
+	}
+	if _, _, err := EstimateBatchSize(filenames[:2]); err != nil {
+		t.Fatalf("err = %v with no missing files", err)
+	}
+}
+
+// failFastServer fails uploads whose body is "fail" right away and holds the others until their request
+// is cancelled, or released
+func failFastServer(t *testing.T) (srv *httptest.Server, release chan struct{}) {
+	release = make(chan struct{})
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if string(body) == "fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		select {
+		case <-release:
+			w.Write([]byte("https://ttm.sh/abc.txt"))
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, release
+}
+
+func TestUploadDirAbortOnFirstError(t *testing.T) {
+	srv, _ := failFastServer(t)
+	c := &Client{Endpoints: map[int]string{TtmSh: srv.URL}}
+	dir := tempDir(t)
+	contents := map[string]string{"a.txt": "fail", "b.txt": "b", "c.txt": "c", "d.txt": "d", "e.txt": "e", "f.txt": "f"}
+	for name, data := range contents {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	results, err := c.UploadDirContext(context.Background(), TtmSh, dir, WithAbortOnFirstError())
+	var status *StatusError
+	if !errors.As(err, &status) || status.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("err = %v, want the failure of a.txt", err)
+	}
+	for name, res := range results {
+		if !res.failed() && filepath.Base(name) != "a.txt" {
+			t.Errorf("%s was uploaded after the batch was aborted", name)
+		}
+	}
+}
+
+func TestUploadToManyAbortOnFirstError(t *testing.T) {
+	failing := statusServer(t, http.StatusRequestEntityTooLarge)
+	holding, release := failFastServer(t)
+	c := &Client{Endpoints: map[int]string{TransferSh: failing.URL, TtmSh: holding.URL}}
+	path := writeTestFile(t, "a.txt", []byte("hello"))
+
+	results, err := c.UploadToManyContext(context.Background(), []int{TransferSh, TtmSh}, path, WithAbortOnFirstError())
+	if err == nil || !errors.Is(results[1].Err, context.Canceled) {
+		t.Fatalf("err = %v, results = %+v, want the held upload cancelled by the first failure", err, results)
+	}
+
+	// Without the option, the other uploads run to completion
+	close(release)
+	results, err = c.UploadToManyContext(context.Background(), []int{TransferSh, TtmSh}, path)
+	if err != nil || results[0].Err == nil || results[1].Err != nil {
+		t.Fatalf("err = %v, results = %+v, want only the first upload failed", err, results)
+	}
+}
+
+func TestUploadDirNameCollisions(t *testing.T) {
+	srv := newTextServer(t, "https://transfer.sh/abc/notes.txt")
+	c := &Client{Endpoints: map[int]string{TransferSh: srv.URL}}
+	dir := tempDir(t)
+	for _, sub := range []string{"a", "b"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, sub, "notes.txt"), []byte(sub), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := c.UploadDirContext(context.Background(), TransferSh, dir); !errors.Is(err, ErrNameCollision) {
+		t.Fatalf("err = %v, want ErrNameCollision", err)
+	}
+	if n := len(srv.received()); n != 0 {
+		t.Fatalf("%d files uploaded despite the collision", n)
+	}
+
+	results, err := c.UploadDirContext(context.Background(), TransferSh, dir, WithRenameCollisions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	second := filepath.Join(dir, "b", "notes.txt")
+	if res := results[second]; res.RenamedTo != "notes-2.txt" {
+		t.Fatalf("%s renamed to %q, want notes-2.txt", second, res.RenamedTo)
+	}
+	if res := results[filepath.Join(dir, "a", "notes.txt")]; res.RenamedTo != "" {
+		t.Fatalf("the first file was renamed to %q", res.RenamedTo)
+	}
+	paths := map[string]string{}
+	for _, req := range srv.received() {
+		paths[req.Path] = string(req.Body)
+	}
+	if paths["/notes.txt"] != "a" || paths["/notes-2.txt"] != "b" {
+		t.Fatalf("uploaded %v, want a/notes.txt as notes.txt and b/notes.txt as notes-2.txt", paths)
+	}
+}
+
+func TestUploadDirDedupe(t *testing.T) {
+	srv := newTextServer(t, "https://transfer.sh/abc/a.txt")
+	c := &Client{Endpoints: map[int]string{TransferSh: srv.URL}}
+	dir := tempDir(t)
+	for name, data := range map[string]string{"a.txt": "same", "b.txt": "same", "c.txt": "different"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	results, err := c.UploadDirContext(context.Background(), TransferSh, dir, WithDedupe())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(srv.received()); n != 2 {
+		t.Fatalf("%d uploads, want 2", n)
+	}
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want one per file", len(results))
+	}
+	original, duplicate := results[filepath.Join(dir, "a.txt")], results[filepath.Join(dir, "b.txt")]
+	if duplicate.Err != nil || duplicate.Response.FullURL != original.Response.FullURL {
+		t.Fatalf("the copy got %q, %v, want the original's URL %q", duplicate.Response.FullURL, duplicate.Err, original.Response.FullURL)
+	}
+	if duplicate.Filename != filepath.Join(dir, "b.txt") {
+		t.Fatalf("the copy's result is for %s", duplicate.Filename)
+	}
+}
+
+func TestUploadMirrored(t *testing.T) {
+	transfer := newTextServer(t, "https://transfer.sh/abc/notes.txt")
+	ttm := newTextServer(t, "https://ttm.sh/aBc.txt")
+	failing := statusServer(t, http.StatusInternalServerError)
+	c := &Client{Endpoints: map[int]string{TransferSh: transfer.URL, KekSh: failing.URL, TtmSh: ttm.URL}}
+	path := writeTestFile(t, "notes.txt", []byte("hello"))
+
+	mirrored, err := c.UploadMirroredContext(context.Background(), []int{TtmSh, KekSh, TransferSh}, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ProviderURL{{TtmSh, "https://ttm.sh/aBc.txt"}, {TransferSh, "https://transfer.sh/abc/notes.txt"}}
+	if !reflect.DeepEqual(mirrored.Mirrors, want) {
+		t.Fatalf("Mirrors = %+v, want %+v", mirrored.Mirrors, want)
+	}
+	if len(mirrored.Results) != 3 || mirrored.Results[1].Provider != KekSh || !mirrored.Results[1].failed() {
+		t.Fatalf("Results = %+v, want the KekSh failure kept in place", mirrored.Results)
+	}
+}
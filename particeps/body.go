@@ -0,0 +1,290 @@
+package particeps
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrUnsupportedMediaType is returned when a provider doesn't accept the type of file being uploaded
+var ErrUnsupportedMediaType = errors.New("particeps: file type not accepted by provider")
+
+// smallUploadSize is the largest upload whose multipart body is built in a pooled buffer.
+// Anything bigger, or of unknown size, is streamed to the provider instead.
+const smallUploadSize = 4 << 20
+
+// bufferPool recycles the buffers used to build small multipart bodies
+var bufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// putBuffer returns buf to the pool, unless it grew too large to be worth keeping around
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > 2*smallUploadSize {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// pooledBody is a request body built in a pooled buffer. The buffer only goes back to the pool once its owner
+// has released it and every reader handed out for it has been closed, which the transport does when it is done
+// sending, possibly after the response has arrived. This includes the readers GetBody returns for redirects.
+type pooledBody struct {
+	buf  *bytes.Buffer
+	mu   sync.Mutex
+	refs int
+}
+
+func newPooledBody(buf *bytes.Buffer) *pooledBody {
+	return &pooledBody{buf: buf, refs: 1}
+}
+
+// reader returns a new reader over the body, which must be closed
+func (b *pooledBody) reader() *pooledReader {
+	b.mu.Lock()
+	b.refs++
+	b.mu.Unlock()
+	return &pooledReader{Reader: bytes.NewReader(b.buf.Bytes()), body: b}
+}
+
+// open is reader as a GetBody function
+func (b *pooledBody) open() (io.ReadCloser, error) {
+	return b.reader(), nil
+}
+
+// release drops the owner's reference
+func (b *pooledBody) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.refs--; b.refs == 0 {
+		putBuffer(b.buf)
+	}
+}
+
+type pooledReader struct {
+	*bytes.Reader
+	body *pooledBody
+	once sync.Once
+}
+
+// Close drops the reader's reference to the body
+func (r *pooledReader) Close() error {
+	r.once.Do(r.body.release)
+	return nil
+}
+
+// withPooledBody sets the Content-Length and GetBody that http.NewRequest sets for in-memory bodies,
+// if req's body was built in a pooled buffer
+func withPooledBody(req *http.Request) {
+	if r, ok := req.Body.(*pooledReader); ok {
+		req.ContentLength = r.Size()
+		req.GetBody = r.body.open
+	}
+}
+
+// sizedReader is a reader whose length is known, even though its type doesn't tell.
+// size is not updated as the reader is consumed.
+type sizedReader struct {
+	io.Reader
+	size int64
+}
+
+// withSize wraps r, which yields size bytes, so that readerSize can still tell its length.
+// Unknown sizes (negative) leave r as is.
+func withSize(r io.Reader, size int64) io.Reader {
+	if size < 0 {
+		return r
+	}
+	return &sizedReader{Reader: r, size: size}
+}
+
+// readerSize returns the number of bytes left in r, or -1 if it can't be known up front
+func readerSize(r io.Reader) int64 {
+	switch v := r.(type) {
+	case *sizedReader:
+		return v.size
+	case *bytes.Reader:
+		return int64(v.Len())
+	case *bytes.Buffer:
+		return int64(v.Len())
+	case *strings.Reader:
+		return int64(v.Len())
+	case File:
+		info, err := v.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return -1
+		}
+		offset, err := v.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		return info.Size() - offset
+	}
+	return -1
+}
+
+// copyPart copies r into a multipart part, failing if r errors out or, when size is known,
+// if fewer or more bytes than expected were read. This prevents truncated files from being uploaded.
+func copyPart(w io.Writer, r io.Reader, size int64, filename string) error {
+	n, err := io.Copy(w, r)
+	if err != nil {
+		return fmt.Errorf("particeps: could not read %q: %w", filename, err)
+	}
+	if size >= 0 && n != size {
+		return fmt.Errorf("particeps: read %d bytes from %q, expected %d", n, filename, size)
+	}
+	return nil
+}
+
+// newMultipartBody encodes the contents of r as the single file part of a multipart form.
+// The returned release function must be called once the request using body is done.
+func newMultipartBody(r io.Reader, field, filename string) (body io.Reader, contentType string, release func(), err error) {
+	return newMultipartFormBody(r, field, filename, nil)
+}
+
+// writeFields writes plain form fields, sorted by name, ahead of the file part
+func writeFields(mw *multipart.Writer, fields map[string]string) error {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := mw.WriteField(name, fields[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dispositionEscaper escapes a name for a quoted-string in a Content-Disposition header. Line breaks would end
+// the header early and let the rest of the name be read as headers of its own, so they are dropped.
+var dispositionEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\r", "", "\n", "")
+
+// createFormFile is like multipart.Writer.CreateFormFile, but also drops line breaks from the filename,
+// so that names holding quotes, semicolons or newlines still produce a well-formed header
+func createFormFile(mw *multipart.Writer, field, filename string) (io.Writer, error) {
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+		dispositionEscaper.Replace(field), dispositionEscaper.Replace(filename)))
+	h.Set("Content-Type", "application/octet-stream")
+	return mw.CreatePart(h)
+}
+
+// newMultipartFormBody is like newMultipartBody, but also sends the given form fields
+func newMultipartFormBody(r io.Reader, field, filename string, fields map[string]string) (body io.Reader, contentType string, release func(), err error) {
+	size := readerSize(r)
+	if size >= 0 && size <= smallUploadSize {
+		buf := getBuffer()
+		pooled := newPooledBody(buf)
+		mw := multipart.NewWriter(buf)
+		var partWriter io.Writer
+		err := writeFields(mw, fields)
+		if err == nil {
+			partWriter, err = createFormFile(mw, field, filename)
+		}
+		if err == nil {
+			err = copyPart(partWriter, r, size, filename)
+		}
+		if err != nil {
+			pooled.release()
+			return nil, "", nil, err
+		}
+		mw.Close()
+		return pooled.reader(), mw.FormDataContentType(), pooled.release, nil
+	}
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		var partWriter io.Writer
+		err := writeFields(mw, fields)
+		if err == nil {
+			partWriter, err = createFormFile(mw, field, filename)
+		}
+		if err == nil {
+			err = copyPart(partWriter, r, size, filename)
+		}
+		if err == nil {
+			err = mw.Close()
+		}
+		pw.CloseWithError(err) // aborts the request if the file couldn't be read in full
+	}()
+	return pr, mw.FormDataContentType(), func() { pr.Close() }, nil
+}
+
+// newMultipartFilesBody encodes several files as parts of a single multipart form, all under the same field.
+// The files are streamed as the request is sent rather than buffered.
+func newMultipartFilesBody(field string, files []namedReader) (body io.Reader, contentType string, release func(), err error) {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		var err error
+		for _, file := range files {
+			var partWriter io.Writer
+			if partWriter, err = createFormFile(mw, field, file.filename); err != nil {
+				break
+			}
+			if err = copyPart(partWriter, file.r, readerSize(file.r), file.filename); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			err = mw.Close()
+		}
+		pw.CloseWithError(err) // aborts the request if a file couldn't be read in full
+	}()
+	return pr, mw.FormDataContentType(), func() { pr.Close() }, nil
+}
+
+// sniffContentType detects the media type of the upload from its extension or, failing that, its first bytes.
+// The returned reader yields the full contents of r, including the sniffed bytes.
+func sniffContentType(r io.Reader, filename string) (string, io.Reader, error) {
+	if byExt := mime.TypeByExtension(filepath.Ext(filename)); byExt != "" {
+		return byExt, r, nil
+	}
+	size := readerSize(r)
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", nil, err
+	}
+	head = head[:n]
+	return http.DetectContentType(head), withSize(io.MultiReader(bytes.NewReader(head), r), size), nil
+}
+
+// requireMediaType fails with ErrUnsupportedMediaType unless the upload's media type starts with prefix, e.g. "video/"
+func requireMediaType(u *uploadRequest, prefix string) error {
+	r, err := checkMediaType(u.r, u.filename, prefix)
+	if err != nil {
+		return err
+	}
+	u.r = r
+	return nil
+}
+
+// checkMediaType is like requireMediaType for a bare reader. The returned reader replaces r.
+func checkMediaType(r io.Reader, filename, prefix string) (io.Reader, error) {
+	contentType, r, err := sniffContentType(r, filename)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(contentType, prefix) {
+		return nil, fmt.Errorf("%w: %s is %s", ErrUnsupportedMediaType, filename, contentType)
+	}
+	return r, nil
+}
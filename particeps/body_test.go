@@ -0,0 +1,181 @@
+package particeps
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"strings"
+	"testing"
+)
+
+func TestSmallMultipartBodyIsPooled(t *testing.T) {
+	body, contentType, release, err := newMultipartBody(strings.NewReader("hello"), "file", "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, ok := body.(*pooledReader)
+	if !ok {
+		t.Fatalf("small upload body is a %T, want a pooled buffer", body)
+	}
+	buf := r.body.buf
+	if !strings.HasPrefix(contentType, "multipart/form-data; boundary=") || !strings.Contains(buf.String(), "hello") {
+		t.Fatalf("unexpected body %q of type %q", buf.String(), contentType)
+	}
+
+	// The transport may still be sending the body, or replaying it through GetBody, after the response is in
+	replay, _ := r.body.open()
+	release()
+	r.Close()
+	if buf.Len() == 0 {
+		t.Fatal("the buffer was reset while a replayed body was still open")
+	}
+	if data, _ := ioutil.ReadAll(replay); !strings.Contains(string(data), "hello") {
+		t.Fatalf("the replayed body holds %q", data)
+	}
+	replay.Close()
+	replay.Close()
+	if buf.Len() != 0 {
+		t.Fatal("the buffer wasn't reset once every reader was closed")
+	}
+}
+
+func TestLargeMultipartBodyIsStreamed(t *testing.T) {
+	big := bytes.NewReader(make([]byte, smallUploadSize+1))
+	for name, r := range map[string]io.Reader{"large": big, "unknown size": ioutil.NopCloser(strings.NewReader("hello"))} {
+		body, _, release, err := newMultipartBody(r, "file", "a.bin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := body.(*pooledReader); ok {
+			t.Errorf("%s upload was buffered", name)
+		}
+		release()
+	}
+}
+
+// failingReader yields n bytes and then fails
+type failingReader struct{ n int }
+
+var errReadFailed = errors.New("read failed")
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.n == 0 {
+		return 0, errReadFailed
+	}
+	if len(p) > r.n {
+		p = p[:r.n]
+	}
+	for i := range p {
+		p[i] = 'x'
+	}
+	r.n -= len(p)
+	return len(p), nil
+}
+
+func TestPooledBodyFailedRead(t *testing.T) {
+	_, _, release, err := newMultipartBody(withSize(&failingReader{n: 10}, 100), "file", "a.txt")
+	if !errors.Is(err, errReadFailed) {
+		t.Fatalf("err = %v, want the read error", err)
+	}
+	if release != nil {
+		t.Fatal("a release function was returned along with an error, though the buffer is already back in the pool")
+	}
+}
+
+var benchmarkPayload = bytes.Repeat([]byte("particeps"), 64<<10/9)
+
+func BenchmarkMultipartBodyPooled(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		body, _, release, err := newMultipartBody(bytes.NewReader(benchmarkPayload), "file", "a.bin")
+		if err != nil {
+			b.Fatal(err)
+		}
+		io.Copy(ioutil.Discard, body)
+		body.(io.Closer).Close()
+		release()
+	}
+}
+
+// BenchmarkMultipartBodyFresh builds the same body in a new buffer every time, as uploads did before pooling
+func BenchmarkMultipartBodyFresh(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf := new(bytes.Buffer)
+		mw := multipart.NewWriter(buf)
+		w, err := createFormFile(mw, "file", "a.bin")
+		if err != nil {
+			b.Fatal(err)
+		}
+		io.Copy(w, bytes.NewReader(benchmarkPayload))
+		mw.Close()
+		io.Copy(ioutil.Discard, buf)
+	}
+}
+
+func TestUploadFailsOnPartialRead(t *testing.T) {
+	srv := newTextServer(t, "url: https://imagebin.ca/v/abc")
+	c := &Client{Endpoints: map[int]string{Imagebin: srv.URL, TtmSh: srv.URL}}
+	for name, r := range map[string]io.Reader{
+		"buffered":  withSize(&failingReader{n: 100}, 1000),
+		"streamed":  &failingReader{n: 100},
+		"raw":       withSize(&failingReader{n: 100}, 1000),
+		"truncated": withSize(strings.NewReader("only part of it"), 1000),
+	} {
+		provider := Imagebin
+		if name == "raw" {
+			provider = TtmSh
+		}
+		res, err := c.UploadReaderContext(context.Background(), provider, r, "a.txt")
+		if err == nil || res.Status {
+			t.Errorf("%s: got %+v, want the upload to fail", name, res)
+		}
+		if name != "truncated" && !errors.Is(err, errReadFailed) {
+			t.Errorf("%s: err = %v, want it to wrap the read error", name, err)
+		}
+	}
+	// A request cut short may still reach the provider, but never a whole body: no multipart form may be
+	// closed and no raw body may hold as many bytes as announced
+	for _, r := range srv.received() {
+		if bytes.HasSuffix(r.Body, []byte("--\r\n")) || len(r.Body) >= 1000 {
+			t.Errorf("provider received a complete %s request for a file that failed to read", r.Method)
+		}
+	}
+}
+
+func TestFilenameEscapedInContentDisposition(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     string
+	}{
+		{`say "hi"; name="evil".txt`, `say "hi"; name="evil".txt`},
+		{`back\slash.txt`, `back\slash.txt`},
+		{"a\r\nX-Injected: 1.txt", "aX-Injected: 1.txt"},
+	}
+	for _, tc := range tests {
+		body, contentType, release, err := newMultipartBody(strings.NewReader("hello"), "file", tc.filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := ioutil.ReadAll(body)
+		release()
+		_, params, _ := mime.ParseMediaType(contentType)
+		part, err := multipart.NewReader(bytes.NewReader(data), params["boundary"]).NextPart()
+		if err != nil {
+			t.Fatalf("%q: malformed part: %v", tc.filename, err)
+		}
+		if len(part.Header) != 2 || part.Header.Get("X-Injected") != "" {
+			t.Errorf("%q: part headers are %v", tc.filename, part.Header)
+		}
+		if part.FormName() != "file" || part.FileName() != tc.want {
+			t.Errorf("%q: got field %q and filename %q, want %q", tc.filename, part.FormName(), part.FileName(), tc.want)
+		}
+		if content, _ := ioutil.ReadAll(part); string(content) != "hello" {
+			t.Errorf("%q: part holds %q", tc.filename, content)
+		}
+	}
+}
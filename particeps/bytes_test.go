@@ -0,0 +1,25 @@
+package particeps
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestUploadBytesSetsContentLength(t *testing.T) {
+	data := []byte("a few bytes already in memory")
+	for _, provider := range []int{TtmSh, TransferSh} {
+		srv := newTextServer(t, "https://files.example/abc.txt")
+		c := &Client{Endpoints: map[int]string{provider: srv.URL}}
+		res, err := c.UploadBytes(provider, data, "notes.txt")
+		if err != nil || res.FullURL != "https://files.example/abc.txt" {
+			t.Fatalf("provider %d: %v %+v", provider, err, res)
+		}
+		got := srv.received()[0]
+		if got.ContentLength < 0 {
+			t.Fatalf("provider %d: the upload was sent chunked, without a Content-Length", provider)
+		}
+		if got.ContentLength != int64(len(got.Body)) || !bytes.Contains(got.Body, data) {
+			t.Fatalf("provider %d: Content-Length %d for a %d byte body", provider, got.ContentLength, len(got.Body))
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package particeps
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// cacheEntry is a previous upload as recorded in the Client's CacheDir
+type cacheEntry struct {
+	Provider   int       `json:"provider"`
+	SHA256     string    `json:"sha256"`
+	FullURL    string    `json:"full_url"`
+	ShortURL   string    `json:"short_url"`
+	UploadedAt time.Time `json:"uploaded_at"`
+}
+
+// hashFile returns the hex SHA-256 of f's contents and rewinds it
+func hashFile(f io.ReadSeeker) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (c *Client) cachePath(provider int, digest string) string {
+	return filepath.Join(c.CacheDir, digest+"-"+strconv.Itoa(provider)+".json")
+}
+
+// cachedUpload returns the recorded upload of the given content to provider, if there is one.
+// When VerifyCachedURL is set, entries whose URL is gone are dropped; if the check itself fails,
+// the entry is kept and the error returned, since the URL may well still be alive.
+func (c *Client) cachedUpload(ctx context.Context, provider int, digest string) (UniversalResponse, bool, error) {
+	path := c.cachePath(provider, digest)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return UniversalResponse{}, false, nil
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.FullURL == "" {
+		return UniversalResponse{}, false, nil
+	}
+	if c.VerifyCachedURL {
+		alive, err := c.IsAlive(ctx, entry.FullURL)
+		if err != nil {
+			return UniversalResponse{}, false, fmt.Errorf("particeps: checking the cached upload %s: %w", entry.FullURL, err)
+		}
+		if !alive {
+			os.Remove(path)
+			return UniversalResponse{}, false, nil
+		}
+	}
+	return UniversalResponse{Status: true, Provider: provider, FullURL: entry.FullURL, ShortURL: entry.ShortURL, Cached: true, SHA256: digest}, true, nil
+}
+
+// storeUpload records a successful upload in the Client's CacheDir
+func (c *Client) storeUpload(provider int, digest string, res UniversalResponse) error {
+	if err := os.MkdirAll(c.CacheDir, 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(cacheEntry{
+		Provider:   provider,
+		SHA256:     digest,
+		FullURL:    res.FullURL,
+		ShortURL:   res.ShortURL,
+		UploadedAt: c.clock().Now(),
+	})
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.cachePath(provider, digest), data, 0600)
+}
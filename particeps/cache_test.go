@@ -0,0 +1,133 @@
+package particeps
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// fileHost answers POST and PUT uploads with a link to /abc.txt, and serves that link for as long as it is alive
+type fileHost struct {
+	*httptest.Server
+	mu      sync.Mutex
+	uploads int
+	dead    bool
+	failing bool // answers 503 instead of serving the link
+}
+
+func newFileHost(t *testing.T) *fileHost {
+	h := &fileHost{}
+	h.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if r.Method == "POST" || r.Method == "PUT" {
+			ioutil.ReadAll(r.Body)
+			h.uploads++
+			w.Write([]byte(h.URL + "/abc.txt"))
+			return
+		}
+		if h.dead {
+			w.WriteHeader(http.StatusNotFound)
+		} else if h.failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(h.Close)
+	return h
+}
+
+func (h *fileHost) uploaded() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.uploads
+}
+
+func TestReuseIfUploaded(t *testing.T) {
+	host := newFileHost(t)
+	c := &Client{Endpoints: map[int]string{TtmSh: host.URL}, CacheDir: tempDir(t), ReuseIfUploaded: true, VerifyCachedURL: true}
+	path := writeTestFile(t, "a.txt", []byte("hello"))
+
+	first, err := c.UploadContext(context.Background(), TtmSh, path)
+	if err != nil || first.Cached {
+		t.Fatalf("first upload: %+v, %v", first, err)
+	}
+	// Hit: same content, even under another name
+	again, err := c.UploadContext(context.Background(), TtmSh, writeTestFile(t, "b.txt", []byte("hello")))
+	if err != nil || !again.Cached || again.FullURL != first.FullURL || host.uploaded() != 1 {
+		t.Fatalf("cache hit: %+v, %v, %d uploads", again, err, host.uploaded())
+	}
+	// Miss: other content
+	other, err := c.UploadContext(context.Background(), TtmSh, writeTestFile(t, "c.txt", []byte("other")))
+	if err != nil || other.Cached || host.uploaded() != 2 {
+		t.Fatalf("cache miss: %+v, %v, %d uploads", other, err, host.uploaded())
+	}
+	// Stale: the cached link is gone, so the file is uploaded again
+	host.mu.Lock()
+	host.dead = true
+	host.mu.Unlock()
+	stale, err := c.UploadContext(context.Background(), TtmSh, path)
+	if err != nil || stale.Cached || host.uploaded() != 3 {
+		t.Fatalf("stale entry: %+v, %v, %d uploads", stale, err, host.uploaded())
+	}
+}
+
+func TestCacheKeptWhenVerifyFails(t *testing.T) {
+	host := newFileHost(t)
+	c := &Client{Endpoints: map[int]string{TtmSh: host.URL}, CacheDir: tempDir(t), ReuseIfUploaded: true, VerifyCachedURL: true}
+	path := writeTestFile(t, "a.txt", []byte("hello"))
+	first, err := c.UploadContext(context.Background(), TtmSh, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	host.mu.Lock()
+	host.failing = true
+	host.mu.Unlock()
+	_, err = c.UploadContext(context.Background(), TtmSh, path)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("err = %v, want the failed check reported", err)
+	}
+	if host.uploaded() != 1 {
+		t.Fatalf("%d uploads, want none while the check fails", host.uploaded())
+	}
+
+	// The entry survived the failed check
+	host.mu.Lock()
+	host.failing = false
+	host.mu.Unlock()
+	again, err := c.UploadContext(context.Background(), TtmSh, path)
+	if err != nil || !again.Cached || again.FullURL != first.FullURL || host.uploaded() != 1 {
+		t.Fatalf("after the host recovered: %+v, %v, %d uploads", again, err, host.uploaded())
+	}
+}
+
+func TestCacheKeyedByProvider(t *testing.T) {
+	host := newFileHost(t)
+	c := &Client{Endpoints: map[int]string{TtmSh: host.URL, TransferSh: host.URL}, CacheDir: tempDir(t), ReuseIfUploaded: true}
+	path := writeTestFile(t, "a.txt", []byte("hello"))
+	if _, err := c.UploadContext(context.Background(), TtmSh, path); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := c.UploadContext(context.Background(), TransferSh, path); err != nil || res.Cached {
+		t.Fatal("an upload to one provider was reused for another")
+	}
+}
+
+func TestCacheOffWithoutReuse(t *testing.T) {
+	host := newFileHost(t)
+	c := &Client{Endpoints: map[int]string{TtmSh: host.URL}, CacheDir: tempDir(t)}
+	path := writeTestFile(t, "a.txt", []byte("hello"))
+	for i := 0; i < 2; i++ {
+		if res, err := c.UploadContext(context.Background(), TtmSh, path); err != nil || res.Cached {
+			t.Fatalf("upload %d: %+v, %v", i, res, err)
+		}
+	}
+	if host.uploaded() != 2 {
+		t.Fatalf("%d uploads, want every upload sent without ReuseIfUploaded", host.uploaded())
+	}
+}
@@ -0,0 +1,161 @@
+package particeps
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultChunkSize is the size of each part of a chunked upload
+	DefaultChunkSize = 8 << 20
+	// DefaultMinChunkSize and DefaultMaxChunkSize bound adaptive chunk sizing
+	DefaultMinChunkSize = 1 << 20
+	DefaultMaxChunkSize = 64 << 20
+	// chunkTarget is how long an adaptive chunk should take to send: long enough to amortize
+	// per-request overhead, short enough that retrying a failed chunk stays cheap
+	chunkTarget = 10 * time.Second
+
+	// DefaultChunkParallelism is how many chunks of a file are sent at once by default
+	DefaultChunkParallelism = 4
+	// MaxChunkParallelism caps ChunkParallelism, as every chunk in flight is held in memory
+	MaxChunkParallelism = 16
+)
+
+// chunkSender sends one chunk of a chunked upload: the index-th one, starting at offset
+type chunkSender func(ctx context.Context, index int, offset int64, chunk []byte) error
+
+// chunkSizer picks the size of the next chunk from the throughput of the previous one
+type chunkSizer struct {
+	size, min, max int64
+	adaptive       bool
+}
+
+// chunkSizer returns the Client's chunk sizing configuration
+func (c *Client) chunkSizer() *chunkSizer {
+	s := &chunkSizer{size: c.ChunkSize, min: c.MinChunkSize, max: c.MaxChunkSize, adaptive: c.AdaptiveChunks}
+	if s.size <= 0 {
+		s.size = DefaultChunkSize
+	}
+	if s.min <= 0 {
+		s.min = DefaultMinChunkSize
+	}
+	if s.max <= 0 {
+		s.max = DefaultMaxChunkSize
+	}
+	if s.max < s.min {
+		s.max = s.min
+	}
+	s.size = s.clamp(s.size)
+	return s
+}
+
+// clamp keeps size within the configured bounds
+func (s *chunkSizer) clamp(size int64) int64 {
+	if size < s.min {
+		return s.min
+	}
+	if size > s.max {
+		return s.max
+	}
+	return size
+}
+
+// observe records that n bytes took elapsed to send and adapts the next chunk size.
+// The size changes by at most a factor of two per chunk so a single slow or fast chunk doesn't swing it wildly.
+func (s *chunkSizer) observe(n int64, elapsed time.Duration) {
+	if !s.adaptive || n <= 0 || elapsed <= 0 {
+		return
+	}
+	next := int64(float64(n) / elapsed.Seconds() * chunkTarget.Seconds())
+	if next > 2*s.size {
+		next = 2 * s.size
+	}
+	if next < s.size/2 {
+		next = s.size / 2
+	}
+	s.size = s.clamp(next)
+}
+
+// chunkParallelism returns how many chunks may be sent at once
+func (c *Client) chunkParallelism() int {
+	switch n := c.ChunkParallelism; {
+	case n <= 0:
+		return DefaultChunkParallelism
+	case n > MaxChunkParallelism:
+		return MaxChunkParallelism
+	default:
+		return n
+	}
+}
+
+// sendChunksParallel reads r in chunks of the Client's ChunkSize and passes them to send from up to
+// ChunkParallelism goroutines at once. Chunks are read in order, so at most that many are held in memory,
+// and the first error cancels the chunks still in flight. With AdaptiveChunks, each chunk is sized from
+// the throughput of the chunks sent so far. It returns the number of chunks and bytes read.
+func (c *Client) sendChunksParallel(ctx context.Context, r io.Reader, send chunkSender) (int, int64, error) {
+	sizer, clock := c.chunkSizer(), c.clock()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	fail := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+		mu.Unlock()
+	}
+	slots := make(chan struct{}, c.chunkParallelism())
+	var index int
+	var offset int64
+	for ctx.Err() == nil {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			continue
+		}
+		mu.Lock()
+		size := sizer.size
+		mu.Unlock()
+		chunk := make([]byte, size)
+		n, err := io.ReadFull(r, chunk)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			fail(err)
+			break
+		}
+		wg.Add(1)
+		go func(index int, offset int64, chunk []byte) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			start := clock.Now()
+			if err := send(ctx, index, offset, chunk); err != nil {
+				fail(err)
+				return
+			}
+			mu.Lock()
+			sizer.observe(int64(len(chunk)), clock.Now().Sub(start))
+			mu.Unlock()
+		}(index, offset, chunk[:n])
+		index++
+		offset += int64(n)
+		if n < len(chunk) {
+			break
+		}
+	}
+	wg.Wait()
+	if firstErr == nil {
+		firstErr = ctx.Err()
+	}
+	return index, offset, firstErr
+}
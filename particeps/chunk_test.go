@@ -0,0 +1,100 @@
+package particeps
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// chunkedServer mocks a ChunkedProvider host, recording the size of each part it receives, by index
+func chunkedServer(t *testing.T) (*httptest.Server, func() []int) {
+	var mu sync.Mutex
+	parts := map[int]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/":
+			w.Write([]byte(`{"id": "s1"}`))
+		case strings.HasPrefix(r.URL.Path, "/s1/parts/"):
+			body, _ := ioutil.ReadAll(r.Body)
+			var index int
+			for _, d := range strings.TrimPrefix(r.URL.Path, "/s1/parts/") {
+				index = index*10 + int(d-'0')
+			}
+			mu.Lock()
+			parts[index] = len(body)
+			mu.Unlock()
+		case r.URL.Path == "/s1/complete":
+			w.Write([]byte(`{"url": "https://files.example/f"}`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	sizes := func() []int {
+		mu.Lock()
+		defer mu.Unlock()
+		out := make([]int, len(parts))
+		for index, size := range parts {
+			out[index] = size
+		}
+		return out
+	}
+	return srv, sizes
+}
+
+func TestAdaptiveChunksGrowOnFastConnection(t *testing.T) {
+	srv, sizes := chunkedServer(t)
+	defer srv.Close()
+	provider := ChunkedProvider{Name: "chunked-adaptive", URL: srv.URL + "/", SessionPath: "id",
+		PartPath: "s1/parts/{index}", CompletePath: "s1/complete", JSONPath: "url"}.Register()
+	c := &Client{ChunkSize: 1024, MinChunkSize: 512, MaxChunkSize: 8192, AdaptiveChunks: true, ChunkParallelism: 1}
+
+	data := bytes.Repeat([]byte("x"), 40000)
+	res, err := c.UploadReaderContext(context.Background(), provider, bytes.NewReader(data), "big.bin")
+	if err != nil || !res.Status {
+		t.Fatalf("upload failed: %v %+v", err, res)
+	}
+	want := []int{1024, 2048, 4096, 8192, 8192}
+	got := sizes()
+	for i, size := range want {
+		if got[i] != size {
+			t.Fatalf("chunk sizes = %v, want them to start with %v", got, want)
+		}
+	}
+}
+
+func TestFixedChunksWithoutAdaptiveChunks(t *testing.T) {
+	srv, sizes := chunkedServer(t)
+	defer srv.Close()
+	provider := ChunkedProvider{Name: "chunked-fixed", URL: srv.URL + "/", SessionPath: "id",
+		PartPath: "s1/parts/{index}", CompletePath: "s1/complete", JSONPath: "url"}.Register()
+	c := &Client{ChunkSize: 1024, MinChunkSize: 512, ChunkParallelism: 1}
+
+	if _, err := c.UploadReaderContext(context.Background(), provider, bytes.NewReader(bytes.Repeat([]byte("x"), 5000)), "f.bin"); err != nil {
+		t.Fatal(err)
+	}
+	got := sizes()
+	for i, size := range got[:len(got)-1] {
+		if size != 1024 {
+			t.Fatalf("chunk %d is %d bytes, want 1024 (sizes %v)", i, size, got)
+		}
+	}
+}
+
+func TestChunkSizerShrinksOnSlowChunk(t *testing.T) {
+	s := &chunkSizer{size: 8 << 20, min: 1 << 20, max: 64 << 20, adaptive: true}
+	s.observe(8<<20, 80*time.Second) // 100 KiB/s: 10s worth is 1 MiB, but shrinking is capped at half
+	if s.size != 4<<20 {
+		t.Fatalf("size = %d, want %d", s.size, 4<<20)
+	}
+	s.observe(4<<20, 400*time.Second)
+	s.observe(2<<20, 400*time.Second)
+	if s.size != 1<<20 {
+		t.Fatalf("size = %d, want it clamped to the minimum %d", s.size, 1<<20)
+	}
+}
@@ -0,0 +1,181 @@
+package particeps
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ChunkedProvider describes a host with a three-step upload API for large files. A session is opened with
+// a JSON POST of {"filename", "size"} to the endpoint, the file's parts are PUT to the session, several at
+// once and in any order, and the session is then completed with a JSON POST listing the parts, e.g.
+// {"size": 20971520, "parts": [{"index": 0, "offset": 0, "size": 8388608, "etag": "..."}, ...]},
+// which the host answers with the assembled file's URL.
+type ChunkedProvider struct {
+	Name        string
+	URL         string // Endpoint sessions are opened at
+	SessionPath string // Dot-separated path to the session id in the response opening it, e.g. "id"
+	// PartPath is appended to the endpoint to get the URL each part is PUT to.
+	// "{session}", "{index}" and "{offset}" are replaced, e.g. "/{session}/parts/{index}".
+	PartPath string
+	// CompletePath is appended to the endpoint to get the URL that completes the session, e.g. "/{session}/complete"
+	CompletePath string
+	JSONPath     string // Dot-separated path to the URL in the response completing the session
+}
+
+// chunkedPart is one part of a chunked upload, as listed when completing the session
+type chunkedPart struct {
+	Index  int    `json:"index"`
+	Offset int64  `json:"offset"`
+	Size   int    `json:"size"`
+	ETag   string `json:"etag,omitempty"`
+}
+
+// Register registers p and returns the constant to upload to it with
+func (p ChunkedProvider) Register() int {
+	return registerProvider(&providerDef{
+		name:     p.Name,
+		endpoint: p.URL,
+		caps:     Capabilities{Chunked: true},
+		upload:   p.upload,
+		success:  urlSuccess,
+	})
+}
+
+func (p ChunkedProvider) upload(c *Client, u *uploadRequest) (UniversalResponse, error) {
+	var returnValue UniversalResponse
+	returnValue.Status = false
+	size := readerSize(u.r)
+
+	var session string
+	open := map[string]interface{}{"filename": u.filename, "size": size}
+	if err := p.postJSON(c, u, u.endpoint, open, p.SessionPath, &session); err != nil {
+		return returnValue, err
+	}
+	if session == "" {
+		return returnValue, fmt.Errorf("%w: no session id in response", ErrUnexpectedResponse)
+	}
+
+	var mu sync.Mutex
+	var parts []chunkedPart
+	count, total, err := c.sendChunksParallel(u.ctx, u.r, func(ctx context.Context, index int, offset int64, chunk []byte) error {
+		part := &uploadRequest{provider: u.provider, ctx: ctx, endpoint: u.endpoint + p.expand(p.PartPath, session, index, offset), opts: u.opts.forRequest("part-" + strconv.Itoa(index))}
+		etag, err := p.putPart(c, part, chunk, offset, size)
+		if err != nil {
+			return fmt.Errorf("part %d: %w", index, err)
+		}
+		mu.Lock()
+		parts = append(parts, chunkedPart{Index: index, Offset: offset, Size: len(chunk), ETag: etag})
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return returnValue, err
+	}
+	if size >= 0 && total != size {
+		return returnValue, fmt.Errorf("particeps: read %d bytes of a %d byte file", total, size)
+	}
+	// Parts finish in any order; the host assembles them by index
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Index < parts[j].Index })
+	if len(parts) != count {
+		return returnValue, fmt.Errorf("particeps: sent %d of %d parts", len(parts), count)
+	}
+
+	complete := map[string]interface{}{"size": total, "parts": parts}
+	done := *u
+	done.opts = u.opts.forRequest("complete")
+	err = p.postJSON(c, &done, u.endpoint+p.expand(p.CompletePath, session, 0, 0), complete, p.JSONPath, &returnValue.FullURL)
+	u.resp = done.resp
+	if err != nil {
+		return returnValue, err
+	}
+	u.parsed = returnValue.FullURL
+	return returnValue, nil
+}
+
+// forRequest derives the idempotency key, if any, of one of the several requests making up an upload from the
+// upload's own key, since hosts honoring the key would answer every request with the first one's response
+func (o uploadOptions) forRequest(name string) uploadOptions {
+	if o.idempotencyKey != "" {
+		o.idempotencyKey += "-" + name
+	}
+	return o
+}
+
+// expand fills in a PartPath or CompletePath
+func (p ChunkedProvider) expand(path, session string, index int, offset int64) string {
+	return strings.NewReplacer(
+		"{session}", url.PathEscape(session),
+		"{index}", strconv.Itoa(index),
+		"{offset}", strconv.FormatInt(offset, 10),
+	).Replace(path)
+}
+
+// putPart sends one part and returns the ETag the host gave it, if any
+func (p ChunkedProvider) putPart(c *Client, u *uploadRequest, chunk []byte, offset, size int64) (string, error) {
+	req, err := http.NewRequestWithContext(u.ctx, "PUT", u.endpoint, bytes.NewReader(chunk))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	total := "*"
+	if size >= 0 {
+		total = strconv.FormatInt(size, 10)
+	}
+	req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%s", offset, offset+int64(len(chunk))-1, total))
+	resp, err := c.send(u, req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if _, err := readBody(resp); err != nil {
+		return "", err
+	}
+	if ok, err := httpSuccess(resp, nil); !ok {
+		return "", err
+	}
+	return resp.Header.Get("ETag"), nil
+}
+
+// postJSON posts payload to endpoint and stores the string found at path in the JSON response in value
+func (p ChunkedProvider) postJSON(c *Client, u *uploadRequest, endpoint string, payload interface{}, path string, value *string) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(u.ctx, "POST", endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.send(u, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := readBody(resp)
+	if err != nil {
+		return err
+	}
+	if ok, err := httpSuccess(resp, nil); !ok {
+		return err
+	}
+	var parsed interface{}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return err
+	}
+	switch v := lookupJSONPath(parsed, path).(type) {
+	case string:
+		*value = v
+	case float64:
+		*value = strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return nil
+}
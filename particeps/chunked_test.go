@@ -0,0 +1,155 @@
+package particeps
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// assemblingServer mocks a ChunkedProvider host that stores parts by their Content-Range and reassembles
+// them, in the order the completing request lists them, into the file it returns
+func assemblingServer(t *testing.T) (srv *httptest.Server, assembled func() []byte, maxInFlight func() int) {
+	var mu sync.Mutex
+	stored := map[int64][]byte{}
+	var file []byte
+	inFlight, most := 0, 0
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte(`{"id": "s1"}`))
+		case "/s1/complete":
+			var complete struct {
+				Size  int64         `json:"size"`
+				Parts []chunkedPart `json:"parts"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&complete); err != nil {
+				t.Errorf("completing request: %v", err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			var buf []byte
+			for i, part := range complete.Parts {
+				if part.Index != i || part.Offset != int64(len(buf)) || len(stored[part.Offset]) != part.Size {
+					t.Errorf("part %d listed as %+v", i, part)
+				}
+				buf = append(buf, stored[part.Offset]...)
+			}
+			if int64(len(buf)) != complete.Size {
+				t.Errorf("assembled %d bytes, completing request says %d", len(buf), complete.Size)
+			}
+			file = buf
+			w.Write([]byte(`{"url": "https://files.example/f"}`))
+		default:
+			var first, last, total int64
+			if _, err := fmt.Sscanf(r.Header.Get("Content-Range"), "bytes %d-%d/%d", &first, &last, &total); err != nil {
+				t.Errorf("part %s: bad Content-Range %q", r.URL.Path, r.Header.Get("Content-Range"))
+			}
+			mu.Lock()
+			if inFlight++; inFlight > most {
+				most = inFlight
+			}
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			body, _ := ioutil.ReadAll(r.Body)
+			mu.Lock()
+			inFlight--
+			if int64(len(body)) != last-first+1 {
+				t.Errorf("part at %d: got %d bytes for range %d-%d", first, len(body), first, last)
+			}
+			stored[first] = body
+			mu.Unlock()
+			w.Header().Set("ETag", fmt.Sprintf(`"%d"`, first))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []byte {
+			mu.Lock()
+			defer mu.Unlock()
+			return file
+		}, func() int {
+			mu.Lock()
+			defer mu.Unlock()
+			return most
+		}
+}
+
+func TestChunkedUploadReassembles(t *testing.T) {
+	srv, assembled, maxInFlight := assemblingServer(t)
+	provider := ChunkedProvider{Name: "chunked-assembling", URL: srv.URL + "/", SessionPath: "id",
+		PartPath: "s1/parts/{index}", CompletePath: "s1/complete", JSONPath: "url"}.Register()
+	c := &Client{ChunkSize: 1000, MinChunkSize: 1000, ChunkParallelism: 3}
+
+	data := make([]byte, 10500)
+	for i := range data {
+		data[i] = byte(i * 7 % 251)
+	}
+	res, err := c.UploadReaderContext(context.Background(), provider, bytes.NewReader(data), "big.bin")
+	if err != nil || !res.Status || res.FullURL != "https://files.example/f" {
+		t.Fatalf("got %+v, %v", res, err)
+	}
+	if !bytes.Equal(assembled(), data) {
+		t.Fatalf("the reassembled file doesn't match the original (%d of %d bytes)", len(assembled()), len(data))
+	}
+	if n := maxInFlight(); n < 2 || n > 3 {
+		t.Fatalf("%d parts in flight at once, want 2 to 3", n)
+	}
+}
+
+func TestChunkParallelismBounded(t *testing.T) {
+	for _, tc := range []struct{ configured, want int }{
+		{0, DefaultChunkParallelism},
+		{-1, DefaultChunkParallelism},
+		{2, 2},
+		{1000, MaxChunkParallelism},
+	} {
+		if got := (&Client{ChunkParallelism: tc.configured}).chunkParallelism(); got != tc.want {
+			t.Errorf("ChunkParallelism %d: got %d, want %d", tc.configured, got, tc.want)
+		}
+	}
+}
+
+func TestChunkedUploadIdempotencyKeys(t *testing.T) {
+	var mu sync.Mutex
+	keys := map[string]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		mu.Lock()
+		keys[r.URL.Path] = r.Header.Get("Idempotency-Key")
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte(`{"id": "s1"}`))
+		case "/s1/complete":
+			w.Write([]byte(`{"url": "https://files.example/f"}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	provider := ChunkedProvider{Name: "chunked-idempotent", URL: srv.URL + "/", SessionPath: "id",
+		PartPath: "s1/parts/{index}", CompletePath: "s1/complete", JSONPath: "url"}.Register()
+	c := &Client{ChunkSize: 1000, MinChunkSize: 1000}
+
+	res, err := c.UploadReaderContext(context.Background(), provider, bytes.NewReader(make([]byte, 2500)), "big.bin", WithIdempotencyKey("k1"))
+	if err != nil || !res.Status || res.IdempotencyKey != "k1" {
+		t.Fatalf("got %+v, %v", res, err)
+	}
+	want := map[string]string{
+		"/":            "k1",
+		"/s1/parts/0":  "k1-part-0",
+		"/s1/parts/1":  "k1-part-1",
+		"/s1/parts/2":  "k1-part-2",
+		"/s1/complete": "k1-complete",
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if fmt.Sprint(keys) != fmt.Sprint(want) {
+		t.Fatalf("Idempotency-Key by request: %v, want %v", keys, want)
+	}
+}
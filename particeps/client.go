@@ -0,0 +1,492 @@
+package particeps
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Client holds the HTTP configuration used for uploads.
+// The zero value is ready to use and behaves like DefaultClient.
+type Client struct {
+	// HTTPClient sends the requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+	// UserAgent, if set, is sent in the User-Agent header of every request
+	UserAgent string
+	// DefaultProvider is the provider to use when none is specified, e.g. by the command-line tool
+	DefaultProvider int
+	// MaxIdleConnsPerHost is how many idle connections are kept open to each provider for reuse,
+	// which helps when uploading many small files. Zero keeps net/http's default of 2.
+	MaxIdleConnsPerHost int
+	// ForceHTTP2 attempts HTTP/2 even when the transport is customized, so uploads to the same provider
+	// can share one connection.
+	// Like MaxIdleConnsPerHost, it only applies when HTTPClient doesn't set its own Transport.
+	ForceHTTP2 bool
+	// MaxRedirects is the number of redirects followed before giving up. Zero keeps net/http's default of 10.
+	MaxRedirects int
+	// CaptureRedirects stops at the first redirect and reports its Location in the response
+	// instead of following it, for providers whose "success" is a redirect to the uploaded file.
+	CaptureRedirects bool
+	// FS is where files are read from. Defaults to OSFileSystem.
+	FS FileSystem
+	// ResolveSymlinks uploads the target of symlinks, under the target's name. Otherwise uploading a symlink
+	// fails with ErrNotRegularFile.
+	ResolveSymlinks bool
+	// TempDir is where data that has to be read more than once is spilled to disk. Defaults to os.TempDir().
+	TempDir string
+	// MaxMemoryBuffer is the most data kept in memory when an upload has to be buffered, e.g. to send a reader
+	// to several providers. Larger data is spilled to TempDir, trading memory for disk I/O latency.
+	// Defaults to DefaultMaxMemoryBuffer.
+	MaxMemoryBuffer int64
+	// Endpoints overrides the upload URL of the given providers, e.g. to use a self-hosted instance
+	Endpoints map[int]string
+	// EndpointHeaders adds static headers to the requests sent to the given providers, e.g. an API gateway key
+	// for a self-hosted instance behind an authenticating proxy. Headers the upload sets itself aren't overridden.
+	EndpointHeaders map[int]http.Header
+	// CacheDir, if set, is where successful uploads are recorded, keyed by the file's SHA-256 and the provider
+	CacheDir string
+	// ReuseIfUploaded returns the recorded upload from CacheDir instead of uploading the same content again
+	ReuseIfUploaded bool
+	// VerifyCachedURL checks with IsAlive that a recorded URL is still alive before reusing it. URLs that are gone
+	// are uploaded again; if the check itself fails, e.g. on a 503, the upload fails with its error.
+	VerifyCachedURL bool
+	// SpotCheckVerify samples the uploaded file with range requests after each upload from disk and fails with
+	// ErrVerifyFailed if it doesn't match the local file. See SpotCheck. It's skipped when StripMetadata is set.
+	SpotCheckVerify bool
+	// MinFileSize is the size, in bytes, below which uploads fail with ErrFileTooSmall. It defaults to 1, so that
+	// empty files aren't shared by accident; a negative value allows them.
+	MinFileSize int64
+	// MaxFilenameLength is the longest filename, in bytes, sent to providers. Longer names are shortened,
+	// keeping their extension. Defaults to DefaultMaxFilenameLength.
+	MaxFilenameLength int
+	// FilenameTemplates maps providers to the template their remote filenames are derived from, so uploads get
+	// consistent names. Templates may use {name}, {slug}, {ext}, {date} and {hash}; e.g. "{date}-{slug}{ext}"
+	// sends "My Photo.JPG" as "20240102-150405-my-photo.JPG". WithFilenameTemplate overrides it per upload.
+	FilenameTemplates map[int]string
+	// StripMetadata removes EXIF, XMP and other metadata, such as GPS coordinates, from JPEG and PNG files before
+	// uploading them. The image data itself is not re-encoded.
+	StripMetadata bool
+	// URLTransform, if set, rewrites the FullURL and ShortURL of successful uploads, e.g. to turn a view page's URL
+	// into a direct download link. The original FullURL is kept in UniversalResponse.RawURL.
+	URLTransform func(provider int, url string) (string, error)
+	// ShortenWith, if set, is called with the URL of each successful upload, and its result is stored in
+	// UniversalResponse.ShortenedURL. ShortenIsGd is a ready-made shortener.
+	ShortenWith func(ctx context.Context, url string) (string, error)
+	// Logger receives warnings, e.g. about provider responses drifting from their expected schema.
+	// If nil, the standard logger is used.
+	Logger *log.Logger
+	// FailoverPredicate decides when UploadFallback moves on to the next provider. Defaults to DefaultFailoverPredicate.
+	FailoverPredicate FailoverPredicate
+	// Credentials holds the API keys, tokens and accounts used by each provider.
+	// Unset credentials fall back to provider-specific environment variables, such as IMGUR_CLIENT_ID.
+	Credentials map[int]ProviderCredentials
+	// SendContentMD5 sends the MD5 digest of each upload's body in a Content-MD5 header, so providers that support
+	// it can reject corrupted uploads. Bodies that are streamed are buffered first, as for MaxMemoryBuffer.
+	SendContentMD5 bool
+	// Signers signs the upload requests of the given providers, for hosts that require signed requests
+	Signers map[int]RequestSigner
+	// PollInterval is how long to wait before checking again on uploads that providers process asynchronously,
+	// such as Streamable videos. The wait grows from there. Defaults to DefaultPollInterval.
+	PollInterval time.Duration
+	// PollTimeout is how long to wait for asynchronous processing to finish. Defaults to DefaultPollTimeout.
+	PollTimeout time.Duration
+	// ChunkSize is the size of each part for providers that upload in chunks. Defaults to DefaultChunkSize.
+	ChunkSize int64
+	// AdaptiveChunks resizes chunks after each one is sent, based on the measured throughput,
+	// staying between MinChunkSize and MaxChunkSize. Only chunked uploads are affected.
+	AdaptiveChunks bool
+	// MinChunkSize and MaxChunkSize bound chunk sizes. They default to DefaultMinChunkSize and DefaultMaxChunkSize.
+	MinChunkSize int64
+	MaxChunkSize int64
+	// ChunkParallelism is how many chunks of a file providers with a parallel chunked API receive at once.
+	// Defaults to DefaultChunkParallelism and is capped at MaxChunkParallelism.
+	ChunkParallelism int
+	// StatusURLs maps providers to status pages ProviderAvailable queries: 2xx answers mean the provider is up
+	// and server errors that it is down. Providers without one are pinged instead.
+	StatusURLs map[int]string
+	// MaxResponseBody is the largest provider response body read, in bytes, so that a misbehaving provider
+	// can't exhaust memory. Larger bodies fail with ErrResponseTooLarge. Defaults to DefaultMaxResponseBody;
+	// a negative value removes the limit.
+	MaxResponseBody int64
+	// AutoProviders are the providers UploadAuto picks from, in order of preference. Those that don't take a
+	// file's size or media type, or that have no endpoint, are skipped. Defaults to every registered provider.
+	AutoProviders []int
+	// SkipUnavailable makes UploadFallback check ProviderAvailable first and skip providers that are down
+	SkipUnavailable bool
+	// Resolver resolves the providers' host names instead of the system resolver, e.g. DNSServerResolver.
+	// FallbackResolver, if set, is tried whenever Resolver fails, for networks with flaky DNS. Failures to
+	// resolve a host are reported as ErrDNS. Like MaxIdleConnsPerHost, they only apply when HTTPClient doesn't
+	// set its own Transport.
+	Resolver         HostResolver
+	FallbackResolver HostResolver
+
+	// Clock is the time source for expiries, backoff, rate limits and cache timestamps. Defaults to the real clock.
+	Clock Clock
+
+	lifecycle *clientState // In-flight uploads, see Close, and the Client's own transport
+}
+
+// DefaultClient is the Client used by the package-level upload functions
+var DefaultClient = &Client{}
+
+// transportSettings are the connection settings a Client's own transport is built for
+type transportSettings struct {
+	maxIdleConnsPerHost int
+	forceHTTP2          bool
+	resolving           bool // dials through the Client's Resolver and FallbackResolver
+}
+
+func (c *Client) transportSettings() transportSettings {
+	return transportSettings{c.MaxIdleConnsPerHost, c.ForceHTTP2, c.Resolver != nil || c.FallbackResolver != nil}
+}
+
+// tuned reports whether the settings differ from http.DefaultTransport
+func (s transportSettings) tuned() bool {
+	return s.maxIdleConnsPerHost > 0 || s.forceHTTP2 || s.resolving
+}
+
+// ownTransport returns the transport c built for the given settings, building it from http.DefaultTransport
+// if c has none yet or its settings changed since. Each Client owns its transport, so that Reset only ever
+// drops its own connections.
+func (c *Client) ownTransport(settings transportSettings) http.RoundTripper {
+	s := c.state()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.transport != nil && s.transportSettings == settings {
+		return s.transport
+	}
+	base, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return http.DefaultTransport
+	}
+	t := base.Clone()
+	if settings.maxIdleConnsPerHost > 0 {
+		t.MaxIdleConnsPerHost = settings.maxIdleConnsPerHost
+		if t.MaxIdleConns != 0 && t.MaxIdleConns < settings.maxIdleConnsPerHost {
+			t.MaxIdleConns = settings.maxIdleConnsPerHost
+		}
+	}
+	t.ForceAttemptHTTP2 = t.ForceAttemptHTTP2 || settings.forceHTTP2
+	if settings.resolving {
+		// The resolvers are read on every dial, so changing them doesn't need a new transport
+		t.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return newResolvingDialer(c.Resolver, c.FallbackResolver).DialContext(ctx, network, addr)
+		}
+	}
+	if s.transport != nil {
+		s.transport.CloseIdleConnections()
+	}
+	s.transport, s.transportSettings = t, settings
+	return t
+}
+
+// Clone returns a copy of c that can be modified without affecting c, e.g. to give each tenant
+// of a service its own credentials or endpoints. The HTTPClient's connections and the Logger are shared.
+func (c *Client) Clone() *Client {
+	clientStateMu.Lock()
+	clone := *c
+	clientStateMu.Unlock()
+	clone.lifecycle = nil // the clone's uploads and transport are its own, and it starts open
+	if c.HTTPClient != nil {
+		hc := *c.HTTPClient
+		clone.HTTPClient = &hc
+	}
+	if c.Endpoints != nil {
+		clone.Endpoints = make(map[int]string, len(c.Endpoints))
+		for provider, endpoint := range c.Endpoints {
+			clone.Endpoints[provider] = endpoint
+		}
+	}
+	if c.EndpointHeaders != nil {
+		clone.EndpointHeaders = make(map[int]http.Header, len(c.EndpointHeaders))
+		for provider, header := range c.EndpointHeaders {
+			clone.EndpointHeaders[provider] = header.Clone()
+		}
+	}
+	if c.Credentials != nil {
+		clone.Credentials = make(map[int]ProviderCredentials, len(c.Credentials))
+		for provider, creds := range c.Credentials {
+			clone.Credentials[provider] = creds
+		}
+	}
+	if c.FilenameTemplates != nil {
+		clone.FilenameTemplates = make(map[int]string, len(c.FilenameTemplates))
+		for provider, tmpl := range c.FilenameTemplates {
+			clone.FilenameTemplates[provider] = tmpl
+		}
+	}
+	if c.StatusURLs != nil {
+		clone.StatusURLs = make(map[int]string, len(c.StatusURLs))
+		for provider, url := range c.StatusURLs {
+			clone.StatusURLs[provider] = url
+		}
+	}
+	if c.AutoProviders != nil {
+		clone.AutoProviders = append([]int(nil), c.AutoProviders...)
+	}
+	if c.Signers != nil {
+		clone.Signers = make(map[int]RequestSigner, len(c.Signers))
+		for provider, signer := range c.Signers {
+			clone.Signers[provider] = signer
+		}
+	}
+	return &clone
+}
+
+// Reset drops the state c accumulates between uploads, such as idle keep-alive connections and cached
+// ProviderAvailable outcomes, so the next upload starts fresh. Its configuration is left untouched. Only the connections of c's HTTPClient,
+// if it sets its own Transport, or of the transport c built for its connection settings are closed:
+// those of http.DefaultClient belong to the whole process.
+func (c *Client) Reset() {
+	if c.HTTPClient != nil && c.HTTPClient.Transport != nil {
+		c.HTTPClient.CloseIdleConnections()
+	}
+	s := c.state()
+	s.mu.Lock()
+	transport := s.transport
+	s.statuses = nil
+	s.mu.Unlock()
+	if transport != nil {
+		transport.CloseIdleConnections()
+	}
+}
+
+// httpClient returns the http.Client to use, with the redirect policy applied
+func (c *Client) httpClient() *http.Client {
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	if settings := c.transportSettings(); hc.Transport == nil && settings.tuned() {
+		tuned := *hc
+		tuned.Transport = c.ownTransport(settings)
+		hc = &tuned
+	}
+	if !c.CaptureRedirects && c.MaxRedirects == 0 {
+		return hc
+	}
+	withPolicy := *hc
+	withPolicy.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if c.CaptureRedirects {
+			return http.ErrUseLastResponse
+		}
+		if len(via) >= c.MaxRedirects {
+			return fmt.Errorf("particeps: stopped after %d redirects", c.MaxRedirects)
+		}
+		return nil
+	}
+	return &withPolicy
+}
+
+// do sends req using the Client's configuration
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	c.setUserAgent(req)
+	resp, err := c.httpClient().Do(req)
+	return resp, classifyDNS(err)
+}
+
+// setUserAgent sets the Client's UserAgent on req, unless the request set its own
+func (c *Client) setUserAgent(req *http.Request) {
+	if c.UserAgent != "" && req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+}
+
+// send sends a request made for u, keeping track of the response
+func (c *Client) send(u *uploadRequest, req *http.Request) (*http.Response, error) {
+	c.setUserAgent(req)
+	if u.opts.idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", u.opts.idempotencyKey)
+	}
+	if u.opts.expectContinue && req.Body != nil && req.Body != http.NoBody {
+		req.Header.Set("Expect", "100-continue")
+	}
+	for key, values := range c.EndpointHeaders[u.provider] {
+		if _, set := req.Header[http.CanonicalHeaderKey(key)]; set {
+			continue // the provider's own headers, such as Content-Type, take precedence
+		}
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if c.SendContentMD5 {
+		release, err := c.setContentMD5(req)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
+	var unsigned map[string]bool // the headers set before signing, to tell which ones the signer added
+	if u.opts.debug != nil {
+		unsigned = make(map[string]bool, len(req.Header))
+		for key := range req.Header {
+			unsigned[key] = true
+		}
+	}
+	if err := c.sign(u.provider, req); err != nil {
+		return nil, err
+	}
+
+	hc := c.httpClient()
+	if u.opts.debug != nil || u.opts.hasDeadline() {
+		perCall := *hc
+		if u.opts.debug != nil {
+			// The provider's configured headers and the signature may carry credentials as well
+			debug := &DebugRoundTripper{Next: hc.Transport, Out: u.opts.debug}
+			for key := range c.EndpointHeaders[u.provider] {
+				debug.Redact = append(debug.Redact, key)
+			}
+			for key := range req.Header {
+				if !unsigned[key] {
+					debug.Redact = append(debug.Redact, key)
+				}
+			}
+			perCall.Transport = debug
+		}
+		if u.opts.hasDeadline() {
+			perCall.Timeout = 0 // the upload's context carries the per-call deadline instead
+		}
+		hc = &perCall
+	}
+	resp, err := hc.Do(req)
+	if resp != nil {
+		resp.Body = c.limitBody(resp.Body)
+		u.resp = resp
+	}
+	return resp, classifyDNS(err)
+}
+
+// DefaultMaxResponseBody is the largest provider response body read by default, in bytes
+const DefaultMaxResponseBody = 8 << 20
+
+// ErrResponseTooLarge is returned when a provider's response body exceeds the Client's MaxResponseBody
+var ErrResponseTooLarge = errors.New("particeps: provider response is too large")
+
+// limitedBody fails reads with ErrResponseTooLarge once more than limit bytes of a response body were read
+type limitedBody struct {
+	io.ReadCloser
+	limit, remaining int64
+}
+
+// limitBody bounds a provider response body to the Client's MaxResponseBody
+func (c *Client) limitBody(body io.ReadCloser) io.ReadCloser {
+	limit := c.MaxResponseBody
+	if limit == 0 {
+		limit = DefaultMaxResponseBody
+	}
+	if limit < 0 {
+		return body
+	}
+	return &limitedBody{ReadCloser: body, limit: limit, remaining: limit}
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining == 0 {
+		// Only fail if there really is more, so that a body of exactly limit bytes is fine
+		var probe [1]byte
+		n, err := b.ReadCloser.Read(probe[:])
+		for n == 0 && err == nil {
+			n, err = b.ReadCloser.Read(probe[:])
+		}
+		if n > 0 {
+			return 0, fmt.Errorf("%w: more than %d bytes", ErrResponseTooLarge, b.limit)
+		}
+		return 0, err
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
+
+// capturedRedirect turns a redirect response into a successful UniversalResponse when CaptureRedirects is set
+func (c *Client) capturedRedirect(resp *http.Response) (UniversalResponse, bool) {
+	if !c.CaptureRedirects || resp.StatusCode < 300 || resp.StatusCode >= 400 {
+		return UniversalResponse{}, false
+	}
+	location, err := resp.Location()
+	if err != nil {
+		return UniversalResponse{}, false
+	}
+	return UniversalResponse{Status: true, FullURL: location.String(), Location: location.String()}, true
+}
+
+// tempFile creates a temporary file in the Client's TempDir.
+// The returned cleanup function closes and removes it, and is safe to call on every exit path.
+func (c *Client) tempFile() (*os.File, func(), error) {
+	dir := c.TempDir
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	f, err := ioutil.TempFile(dir, "particeps-*")
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, func() {
+		f.Close()
+		os.Remove(f.Name())
+	}, nil
+}
+
+// ErrUnexpectedResponse is returned when a provider answers with something other than what its API documents,
+// typically an HTML error or maintenance page served with a 200 status
+var ErrUnexpectedResponse = errors.New("particeps: unexpected response from provider")
+
+// ErrTruncatedResponse is returned when the connection closed before a provider's whole response arrived.
+// The upload itself may well have succeeded; retrying it is usually enough.
+var ErrTruncatedResponse = errors.New("particeps: response cut short by the connection closing, try again")
+
+// readBody reads a response body that is expected to be JSON or a plain-text URL.
+// Gzip-encoded bodies that the transport left compressed, e.g. because the request set its own
+// Accept-Encoding or a custom transport is in use, are decompressed.
+func readBody(resp *http.Response) ([]byte, error) {
+	var r io.Reader = resp.Body
+	if !resp.Uncompressed && strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		zr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid gzip body: %v", ErrUnexpectedResponse, err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+	body, err := ioutil.ReadAll(r)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("%w (HTTP %d, got %d bytes)", ErrTruncatedResponse, resp.StatusCode, len(body))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if isHTML(resp.Header.Get("Content-Type"), body) {
+		snippet := bytes.TrimSpace(body)
+		if len(snippet) > 200 {
+			snippet = snippet[:200]
+		}
+		return body, fmt.Errorf("%w (HTTP %d): %q", ErrUnexpectedResponse, resp.StatusCode, snippet)
+	}
+	return body, nil
+}
+
+// isHTML reports whether a response looks like an HTML page
+func isHTML(contentType string, body []byte) bool {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil && mediaType == "text/html" {
+		return true
+	}
+	start := bytes.ToLower(bytes.TrimSpace(body))
+	if len(start) > 16 {
+		start = start[:16]
+	}
+	return bytes.HasPrefix(start, []byte("<!doctype")) || bytes.HasPrefix(start, []byte("<html"))
+}
@@ -0,0 +1,245 @@
+package particeps
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var maintenancePage = `<!DOCTYPE html>
+<html><head><title>Down for maintenance</title></head>
+<body><h1>We'll be back soon</h1>` + "<p>" + strings.Repeat("Sorry! ", 100) + `</p></body></html>`
+
+func TestHTMLErrorPageDetected(t *testing.T) {
+	for _, contentType := range []string{"text/html; charset=utf-8", "application/json"} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", contentType)
+			w.Write([]byte(maintenancePage))
+		}))
+		c := &Client{Endpoints: map[int]string{AnonFiles: srv.URL, Filebin: srv.URL, Uguu: srv.URL, TtmSh: srv.URL}}
+		for _, provider := range []int{AnonFiles, Filebin, Uguu, TtmSh} {
+			res, err := c.UploadReaderContext(context.Background(), provider, strings.NewReader("hello"), "a.txt")
+			if !errors.Is(err, ErrUnexpectedResponse) || res.Status {
+				t.Errorf("%s, provider %d: got %+v, %v, want ErrUnexpectedResponse", contentType, provider, res, err)
+				continue
+			}
+			if msg := err.Error(); !strings.Contains(msg, "Down for maintenance") || len(msg) > 300 {
+				t.Errorf("%s, provider %d: want a short snippet of the page in %q", contentType, provider, msg)
+			}
+		}
+		srv.Close()
+	}
+}
+
+func TestIsHTML(t *testing.T) {
+	for body, want := range map[string]bool{
+		"  <!doctype html><p>hi": true,
+		"<HTML><body>":           true,
+		`{"url": "<html>"}`:      false,
+		"https://ttm.sh/abc.txt": false,
+	} {
+		if got := isHTML("text/plain", []byte(body)); got != want {
+			t.Errorf("isHTML(%q) = %v, want %v", body, got, want)
+		}
+	}
+}
+
+func TestCloneIsIndependent(t *testing.T) {
+	c := &Client{
+		HTTPClient:        &http.Client{Timeout: time.Minute},
+		Endpoints:         map[int]string{TtmSh: "https://ttm.example"},
+		EndpointHeaders:   map[int]http.Header{TtmSh: {"X-Key": {"a"}}},
+		Credentials:       map[int]ProviderCredentials{Imgur: {APIKey: "a"}},
+		FilenameTemplates: map[int]string{TtmSh: "{name}"},
+		StatusURLs:        map[int]string{TtmSh: "https://status.example"},
+		AutoProviders:     []int{TtmSh, Uguu},
+	}
+	clone := c.Clone()
+	clone.HTTPClient.Timeout = time.Second
+	clone.Endpoints[TtmSh] = "https://other.example"
+	clone.EndpointHeaders[TtmSh].Set("X-Key", "b")
+	clone.SetCredentials(Imgur, ProviderCredentials{APIKey: "b"})
+	clone.FilenameTemplates[TtmSh] = "{hash}"
+	clone.StatusURLs[Uguu] = "https://status.example"
+	clone.AutoProviders[0] = Filebin
+
+	if c.HTTPClient.Timeout != time.Minute || c.Endpoints[TtmSh] != "https://ttm.example" ||
+		c.EndpointHeaders[TtmSh].Get("X-Key") != "a" || c.Credentials[Imgur].APIKey != "a" ||
+		c.FilenameTemplates[TtmSh] != "{name}" || len(c.StatusURLs) != 1 || c.AutoProviders[0] != TtmSh {
+		t.Fatalf("modifying the clone changed the original: %+v", c)
+	}
+
+	// A clone of a closed Client is open
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	srv := newTextServer(t, "https://ttm.sh/abc.txt")
+	clone = c.Clone()
+	clone.Endpoints[TtmSh] = srv.URL
+	path := writeTestFile(t, "notes.txt", []byte("hello"))
+	if _, err := clone.UploadContext(context.Background(), TtmSh, path); err != nil {
+		t.Fatalf("err = %v uploading with the clone of a closed Client", err)
+	}
+}
+
+func TestResetClosesIdleConnections(t *testing.T) {
+	var closed int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("https://ttm.sh/abc.txt"))
+	}))
+	srv.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			atomic.AddInt32(&closed, 1)
+		}
+	}
+	srv.Start()
+	t.Cleanup(srv.Close)
+	c := &Client{HTTPClient: &http.Client{Transport: &http.Transport{}}, Endpoints: map[int]string{TtmSh: srv.URL}}
+	path := writeTestFile(t, "notes.txt", []byte("hello"))
+	if _, err := c.UploadContext(context.Background(), TtmSh, path); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(&closed) != 0 {
+		t.Fatal("the connection wasn't kept alive")
+	}
+
+	c.Reset()
+	for deadline := time.Now().Add(time.Second); atomic.LoadInt32(&closed) == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("the idle connection is still open after Reset")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestGzipEncodedResponse(t *testing.T) {
+	valid := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		if !valid {
+			w.Write([]byte(`{"success": true}`))
+			return
+		}
+		zw := gzip.NewWriter(w)
+		zw.Write([]byte(`{"success": true, "files": [{"url": "https://a.uguu.se/abc.txt"}]}`))
+		zw.Close()
+	}))
+	t.Cleanup(srv.Close)
+	path := writeTestFile(t, "notes.txt", []byte("hello"))
+
+	clients := map[string]*Client{
+		"default transport":     {},
+		"compression disabled":  {HTTPClient: &http.Client{Transport: &http.Transport{DisableCompression: true}}},
+		"Accept-Encoding given": {EndpointHeaders: map[int]http.Header{Uguu: {"Accept-Encoding": {"gzip"}}}},
+	}
+	for name, c := range clients {
+		c.Endpoints = map[int]string{Uguu: srv.URL}
+		res, err := c.UploadContext(context.Background(), Uguu, path)
+		if err != nil || res.FullURL != "https://a.uguu.se/abc.txt" {
+			t.Fatalf("%s: got %+v, %v", name, res, err)
+		}
+	}
+
+	valid = false
+	c := clients["compression disabled"]
+	if _, err := c.UploadContext(context.Background(), Uguu, path); !errors.Is(err, ErrUnexpectedResponse) {
+		t.Fatalf("err = %v for a body that isn't gzip, want ErrUnexpectedResponse", err)
+	}
+}
+
+func TestEndpointHeaders(t *testing.T) {
+	uguu := newTextServer(t, `{"success": true, "files": [{"url": "https://a.uguu.se/abc.txt"}]}`)
+	ttm := newTextServer(t, "https://ttm.sh/abc.txt")
+	c := &Client{
+		Endpoints: map[int]string{Uguu: uguu.URL, TtmSh: ttm.URL},
+		EndpointHeaders: map[int]http.Header{Uguu: {
+			"X-Gateway-Key": {"key"},
+			"x-tenant-id":   {"a", "b"},
+			"Content-Type":  {"text/plain"},
+		}},
+	}
+	path := writeTestFile(t, "notes.txt", []byte("hello"))
+	for _, provider := range []int{Uguu, TtmSh} {
+		if _, err := c.UploadContext(context.Background(), provider, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	header := uguu.received()[0].Header
+	if header.Get("X-Gateway-Key") != "key" || strings.Join(header["X-Tenant-Id"], ",") != "a,b" {
+		t.Fatalf("Uguu got headers %v, want the gateway key and both tenant ids", header)
+	}
+	if !strings.HasPrefix(header.Get("Content-Type"), "multipart/form-data; boundary=") || len(header["Content-Type"]) != 1 {
+		t.Fatalf("Content-Type is %q, want only the multipart one", header["Content-Type"])
+	}
+	if header := ttm.received()[0].Header; header.Get("X-Gateway-Key") != "" || header.Get("X-Tenant-Id") != "" {
+		t.Fatalf("ttm.sh got Uguu's headers: %v", header)
+	}
+}
+
+func TestTruncatedResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "200")
+		w.Write([]byte(`{"files": [{"url": "https://a.uguu.se/ab`))
+		w.(http.Flusher).Flush()
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		conn.Close()
+	}))
+	defer srv.Close()
+	c := &Client{Endpoints: map[int]string{Uguu: srv.URL}}
+
+	_, err := c.UploadReaderContext(context.Background(), Uguu, strings.NewReader("hello"), "a.txt")
+	if !errors.Is(err, ErrTruncatedResponse) {
+		t.Fatalf("err = %v, want ErrTruncatedResponse", err)
+	}
+}
+
+func TestMalformedResponseNotTruncated(t *testing.T) {
+	srv := newTextServer(t, `{"files": [{"url": "https://a.uguu.se/ab`)
+	c := &Client{Endpoints: map[int]string{Uguu: srv.URL}}
+
+	_, err := c.UploadReaderContext(context.Background(), Uguu, strings.NewReader("hello"), "a.txt")
+	if err == nil || errors.Is(err, ErrTruncatedResponse) {
+		t.Fatalf("err = %v, want a parse error", err)
+	}
+}
+
+func TestMaxResponseBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/exact" {
+			w.Write([]byte("https://ttm.sh/" + strings.Repeat("a", 1024-len("https://ttm.sh/"))))
+			return
+		}
+		// Stream far more than the limit, stopping once the client hangs up
+		chunk := []byte(strings.Repeat("x", 32<<10))
+		for i := 0; i < 1<<12; i++ {
+			if _, err := w.Write(chunk); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	c := &Client{Endpoints: map[int]string{TtmSh: srv.URL + "/endless"}, MaxResponseBody: 1024}
+	_, err := c.UploadReaderContext(context.Background(), TtmSh, strings.NewReader("hello"), "a.txt")
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("err = %v, want ErrResponseTooLarge", err)
+	}
+
+	c.Endpoints[TtmSh] = srv.URL + "/exact"
+	if res, err := c.UploadReaderContext(context.Background(), TtmSh, strings.NewReader("hello"), "a.txt"); err != nil || len(res.FullURL) != 1024 {
+		t.Fatalf("a body of exactly MaxResponseBody bytes: got %d byte URL, %v", len(res.FullURL), err)
+	}
+}
@@ -0,0 +1,26 @@
+package particeps
+
+import "time"
+
+// Clock tells the time and waits for the Client, so that time-dependent behavior, such as expiries, backoff
+// and rate limits, can be driven deterministically by a fake clock
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time // Sends the time once d has elapsed
+	Sleep(d time.Duration)
+}
+
+// realClock is the Clock backed by the time package
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+
+// clock returns the Client's Clock, or the real one
+func (c *Client) clock() Clock {
+	if c.Clock == nil {
+		return realClock{}
+	}
+	return c.Clock
+}
@@ -0,0 +1,106 @@
+package particeps
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock that only moves when told to, or by step on every call to Now.
+// It records how long it was asked to sleep.
+type fakeClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	step  time.Duration
+	slept []time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now
+	c.now = c.now.Add(c.step)
+	return now
+}
+
+// After advances the clock by d and fires right away
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.advance(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.Now()
+	return ch
+}
+
+// Sleep advances the clock by d and returns right away
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.mu.Lock()
+	c.slept = append(c.slept, d)
+	c.mu.Unlock()
+	c.advance(d)
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+func TestProgressUsesClock(t *testing.T) {
+	clock := newFakeClock()
+	var reports []Progress
+	p := newProgressReader(strings.NewReader(strings.Repeat("x", 100)), 100, func(pr Progress) {
+		reports = append(reports, pr)
+	}, time.Second, clock)
+
+	buf := make([]byte, 10)
+	for i := 0; i < 4; i++ {
+		p.Read(buf) // no time passes, so nothing is reported until the interval elapses
+	}
+	if len(reports) != 0 {
+		t.Fatalf("got %d reports before the interval elapsed", len(reports))
+	}
+	clock.advance(2 * time.Second)
+	p.Read(buf)
+	if len(reports) != 1 || reports[0].Sent != 50 || reports[0].BytesPerSecond != 25 {
+		t.Fatalf("reports = %+v, want one at 50 bytes sent, 25 B/s", reports)
+	}
+	if _, err := io.Copy(ioutil.Discard, p); err != nil {
+		t.Fatal(err)
+	}
+	if last := reports[len(reports)-1]; last.Sent != 100 {
+		t.Fatalf("last report at %d bytes, want the final one at 100", last.Sent)
+	}
+}
+
+func TestChunkTimingUsesClock(t *testing.T) {
+	srv, sizes := chunkedServer(t)
+	defer srv.Close()
+	provider := ChunkedProvider{Name: "chunked-clock", URL: srv.URL + "/", SessionPath: "id",
+		PartPath: "s1/parts/{index}", CompletePath: "s1/complete", JSONPath: "url"}.Register()
+	// Every chunk seems to take a minute, however fast the mock answers
+	clock := newFakeClock()
+	clock.step = 30 * time.Second
+	c := &Client{ChunkSize: 4096, MinChunkSize: 1024, MaxChunkSize: 8192, AdaptiveChunks: true,
+		ChunkParallelism: 1, Clock: clock}
+
+	data := bytes.Repeat([]byte("x"), 10000)
+	if _, err := c.UploadReaderContext(context.Background(), provider, bytes.NewReader(data), "f.bin"); err != nil {
+		t.Fatal(err)
+	}
+	want := []int{4096, 2048, 1024, 1024}
+	got := sizes()
+	for i, size := range want {
+		if got[i] != size {
+			t.Fatalf("chunk sizes = %v, want them to start with %v", got, want)
+		}
+	}
+}
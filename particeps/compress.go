@@ -0,0 +1,75 @@
+package particeps
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// compressSample is how much of a file is test-compressed to decide whether compressing it is worthwhile
+const compressSample = 8 << 10
+
+// maxCompressionRatio is the compressed-to-original size ratio above which compression isn't worth it
+const maxCompressionRatio = 0.9
+
+// incompressibleTypes are media types, or prefixes of them, whose data is already compressed
+var incompressibleTypes = []string{
+	"image/jpeg", "image/png", "image/gif", "image/webp", "video/", "audio/",
+	"application/zip", "application/gzip", "application/x-gzip", "application/x-bzip2", "application/x-xz",
+	"application/x-7z-compressed", "application/vnd.rar", "application/x-rar-compressed", "application/zstd",
+	"application/pdf",
+}
+
+// compressible reports whether data of the given type, starting with sample, is worth compressing
+func compressible(contentType string, sample []byte) bool {
+	for _, t := range incompressibleTypes {
+		if strings.HasPrefix(contentType, t) {
+			return false
+		}
+	}
+	if len(sample) == 0 {
+		return false
+	}
+	var out bytes.Buffer
+	zw, _ := gzip.NewWriterLevel(&out, gzip.BestSpeed)
+	zw.Write(sample)
+	zw.Close()
+	return float64(out.Len())/float64(len(sample)) < maxCompressionRatio
+}
+
+// gzipUpload replaces u's body with its gzip-compressed form, adding ".gz" to the filename,
+// unless the data looks incompressible. The returned Closer, if not nil, must be closed once the upload is done.
+func gzipUpload(u *uploadRequest) (io.Closer, error) {
+	size := readerSize(u.r)
+	head := make([]byte, compressSample)
+	n, err := io.ReadFull(u.r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	head = head[:n]
+	r := io.MultiReader(bytes.NewReader(head), u.r)
+	contentType := mime.TypeByExtension(filepath.Ext(u.filename))
+	if contentType == "" {
+		contentType = http.DetectContentType(head)
+	}
+	if !compressible(contentType, head) {
+		u.r = withSize(r, size)
+		return nil, nil
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		zw := gzip.NewWriter(pw)
+		_, err := io.Copy(zw, r)
+		if closeErr := zw.Close(); err == nil {
+			err = closeErr
+		}
+		pw.CloseWithError(err)
+	}()
+	u.r = pr
+	u.filename += ".gz"
+	return pr, nil
+}
@@ -0,0 +1,56 @@
+package particeps
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestWithGzipSkipsIncompressibleData(t *testing.T) {
+	text := []byte(strings.Repeat("GET /index.html 200\n", 2000))
+	jpg, _ := jpegWithEXIF(t)
+	random := make([]byte, 32<<10)
+	rand.New(rand.NewSource(1)).Read(random)
+	tests := []struct {
+		name       string
+		data       []byte
+		compressed bool
+	}{
+		{"access.log", text, true},
+		{"photo.jpg", jpg, false},
+		{"photo", jpg, false}, // detected from the content
+		{"random.bin", random, false},
+	}
+	for _, tc := range tests {
+		srv := newTextServer(t, "https://transfer.sh/abc/"+tc.name)
+		c := &Client{Endpoints: map[int]string{TransferSh: srv.URL}}
+		path := writeTestFile(t, tc.name, tc.data)
+
+		res, err := c.UploadContext(context.Background(), TransferSh, path, WithGzip())
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		req := srv.received()[0]
+		if res.Compressed != tc.compressed || res.Size != int64(len(tc.data)) {
+			t.Fatalf("%s: Compressed = %v, Size = %d, want %v and %d", tc.name, res.Compressed, res.Size, tc.compressed, len(tc.data))
+		}
+		if !tc.compressed {
+			if req.Path != "/"+tc.name || !bytes.Equal(req.Body, tc.data) {
+				t.Fatalf("%s: sent %s, want the file as is", tc.name, req.Path)
+			}
+			continue
+		}
+		zr, err := gzip.NewReader(bytes.NewReader(req.Body))
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		data, err := ioutil.ReadAll(zr)
+		if req.Path != "/"+tc.name+".gz" || err != nil || !bytes.Equal(data, tc.data) || len(req.Body) >= len(tc.data) {
+			t.Fatalf("%s: sent %s (%d bytes), want the file compressed as %s.gz", tc.name, req.Path, len(req.Body), tc.name)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package particeps
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config is the JSON configuration file read by LoadConfig. Providers are referred to by name, as in
+// ProviderDetails.Name, case-insensitively, or by their constant's value.
+//
+//	{
+//		"default_provider": "filebin",
+//		"timeout": "2m",
+//		"user_agent": "my-tool/1.0",
+//		"fallback_dns": "1.1.1.1:53",
+//		"endpoints": {"filebin": "https://filebin.example.com/"},
+//		"credentials": {"imgur": {"api_key": "0123456789abcde"}}
+//	}
+type Config struct {
+	DefaultProvider string                         `json:"default_provider"`
+	Timeout         string                         `json:"timeout"` // As accepted by time.ParseDuration
+	UserAgent       string                         `json:"user_agent"`
+	FallbackDNS     string                         `json:"fallback_dns"` // DNS server tried when the system's fails
+	Endpoints       map[string]string              `json:"endpoints"`
+	Credentials     map[string]ProviderCredentials `json:"credentials"`
+}
+
+// DefaultConfigPath is where LoadConfig looks when given an empty path, e.g. ~/.config/particeps/config.json
+func DefaultConfigPath() string {
+	return filepath.Join(GetPrefFolder(), "particeps", "config.json")
+}
+
+// LoadConfig reads the Config at path, or at DefaultConfigPath if path is empty, into a new Client
+func LoadConfig(path string) (*Client, error) {
+	if path == "" {
+		path = DefaultConfigPath()
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("particeps: invalid config %s: %w", path, err)
+	}
+	return cfg.Client()
+}
+
+// Client builds a Client configured as cfg describes
+func (cfg Config) Client() (*Client, error) {
+	c := &Client{UserAgent: cfg.UserAgent}
+	if cfg.Timeout != "" {
+		timeout, err := time.ParseDuration(cfg.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("particeps: invalid timeout %q: %w", cfg.Timeout, err)
+		}
+		c.HTTPClient = &http.Client{Timeout: timeout}
+	}
+	if cfg.FallbackDNS != "" {
+		c.FallbackResolver = DNSServerResolver(cfg.FallbackDNS)
+	}
+	if cfg.DefaultProvider != "" {
+		provider, err := ProviderByName(cfg.DefaultProvider)
+		if err != nil {
+			return nil, err
+		}
+		c.DefaultProvider = provider
+	}
+	for name, endpoint := range cfg.Endpoints {
+		provider, err := ProviderByName(name)
+		if err != nil {
+			return nil, err
+		}
+		if c.Endpoints == nil {
+			c.Endpoints = map[int]string{}
+		}
+		c.Endpoints[provider] = endpoint
+	}
+	for name, creds := range cfg.Credentials {
+		provider, err := ProviderByName(name)
+		if err != nil {
+			return nil, err
+		}
+		c.SetCredentials(provider, creds)
+	}
+	return c, nil
+}
+
+// ProviderByName returns the constant of the provider with the given name, compared case-insensitively.
+// The constant's value itself is accepted too.
+func ProviderByName(name string) (int, error) {
+	if provider, err := strconv.Atoi(name); err == nil {
+		if _, err := lookupProvider(provider); err != nil {
+			return 0, err
+		}
+		return provider, nil
+	}
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	for provider, def := range providers {
+		if strings.EqualFold(def.name, name) {
+			return provider, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %q", ErrUnknownProvider, name)
+}
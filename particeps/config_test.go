@@ -0,0 +1,85 @@
+package particeps
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+const sampleConfig = `{
+	"default_provider": "filebin",
+	"timeout": "2m",
+	"user_agent": "my-tool/1.0",
+	"fallback_dns": "1.1.1.1:53",
+	"endpoints": {"Filebin": "https://filebin.example.com/", "13": "https://ttm.example.com/"},
+	"credentials": {"imgur": {"api_key": "0123456789abcde"}, "streamable": {"user": "me", "password": "secret"}}
+}`
+
+func TestLoadConfig(t *testing.T) {
+	path := writeTestFile(t, "config.json", []byte(sampleConfig))
+	c, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.DefaultProvider != Filebin || c.UserAgent != "my-tool/1.0" || c.HTTPClient == nil || c.HTTPClient.Timeout != 2*time.Minute {
+		t.Fatalf("got %+v", c)
+	}
+	if c.Endpoints[Filebin] != "https://filebin.example.com/" || c.Endpoints[TtmSh] != "https://ttm.example.com/" || len(c.Endpoints) != 2 {
+		t.Fatalf("Endpoints = %v", c.Endpoints)
+	}
+	if c.Credentials[Imgur].APIKey != "0123456789abcde" || c.Credentials[Streamable] != (ProviderCredentials{User: "me", Password: "secret"}) {
+		t.Fatalf("Credentials = %v", c.Credentials)
+	}
+	if c.FallbackResolver == nil || c.Resolver != nil {
+		t.Fatal("the fallback DNS server isn't used as the FallbackResolver")
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := map[string]string{
+		"not JSON":        `default_provider = "filebin"`,
+		"timeout":         `{"timeout": "soon"}`,
+		"provider":        `{"default_provider": "nowhere"}`,
+		"endpoint":        `{"endpoints": {"nowhere": "https://example.com/"}}`,
+		"credentials":     `{"credentials": {"nowhere": {"token": "x"}}}`,
+		"provider number": `{"default_provider": "0"}`,
+	}
+	for name, config := range tests {
+		path := writeTestFile(t, "config.json", []byte(config))
+		if _, err := LoadConfig(path); err == nil {
+			t.Errorf("%s: no error for %s", name, config)
+		}
+	}
+	path := writeTestFile(t, "config.json", []byte(`{"endpoints": {"nowhere": "https://example.com/"}}`))
+	if _, err := LoadConfig(path); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("err = %v for an unknown provider, want ErrUnknownProvider", err)
+	}
+	if _, err := LoadConfig(filepath.Join(tempDir(t), "missing.json")); !os.IsNotExist(err) {
+		t.Errorf("err = %v for a missing file, want it not to exist", err)
+	}
+}
+
+func TestLoadConfigDefaultPath(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the default path is only redirected through XDG_CONFIG_HOME on Linux")
+	}
+	home := tempDir(t)
+	setenv(t, "XDG_CONFIG_HOME", home)
+	if DefaultConfigPath() != filepath.Join(home, "particeps", "config.json") {
+		t.Fatalf("DefaultConfigPath() = %s", DefaultConfigPath())
+	}
+	if err := os.MkdirAll(filepath.Join(home, "particeps"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(DefaultConfigPath(), []byte(sampleConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadConfig("")
+	if err != nil || c.DefaultProvider != Filebin {
+		t.Fatalf("got %+v, %v, want the config at the default path", c, err)
+	}
+}
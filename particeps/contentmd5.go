@@ -0,0 +1,50 @@
+package particeps
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"io"
+	"net/http"
+)
+
+// setContentMD5 sets req's Content-MD5 header to the digest of its body. Streamed bodies are first spooled
+// so they can be read twice, as for Client.spool; the returned function releases them once the request is done.
+func (c *Client) setContentMD5(req *http.Request) (func(), error) {
+	release := func() {}
+	if req.Body == nil || req.Body == http.NoBody {
+		sum := md5.Sum(nil)
+		req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+		return release, nil
+	}
+	spooled := req.GetBody == nil
+	if spooled {
+		s, err := c.spool(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return release, err
+		}
+		release = s.close
+		req.GetBody = s.open
+		if req.Body, err = s.open(); err != nil {
+			release()
+			return func() {}, err
+		}
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		release()
+		return func() {}, err
+	}
+	defer body.Close()
+	h := md5.New()
+	n, err := io.Copy(h, body)
+	if err != nil {
+		release()
+		return func() {}, err
+	}
+	if spooled {
+		req.ContentLength = n
+	}
+	req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(h.Sum(nil)))
+	return release, nil
+}
@@ -0,0 +1,42 @@
+package particeps
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestSendContentMD5(t *testing.T) {
+	uguu := newTextServer(t, `{"success": true, "files": [{"url": "https://a.uguu.se/abc.txt"}]}`)
+	transfer := newTextServer(t, "https://transfer.sh/abc/notes.txt")
+	c := &Client{
+		Endpoints:       map[int]string{Uguu: uguu.URL, TransferSh: transfer.URL},
+		SendContentMD5:  true,
+		MaxMemoryBuffer: 1 << 10,
+		TempDir:         tempDir(t),
+	}
+	small := writeTestFile(t, "notes.txt", []byte("hello"))
+	large := writeTestFile(t, "large.txt", []byte(strings.Repeat("particeps ", 1000)))
+	for _, provider := range []int{Uguu, TransferSh} {
+		for _, path := range []string{small, large} {
+			if _, err := c.UploadContext(context.Background(), provider, path); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	for _, req := range append(uguu.received(), transfer.received()...) {
+		sum := md5.Sum(req.Body)
+		if want := base64.StdEncoding.EncodeToString(sum[:]); req.Header.Get("Content-MD5") != want {
+			t.Errorf("%s: Content-MD5 is %q, want %q", req.Path, req.Header.Get("Content-MD5"), want)
+		}
+		if req.ContentLength != int64(len(req.Body)) {
+			t.Errorf("%s: Content-Length is %d for a %d byte body", req.Path, req.ContentLength, len(req.Body))
+		}
+	}
+	if entries := dirEntries(t, c.TempDir); len(entries) != 0 {
+		t.Fatalf("spooled bodies left behind: %v", entries)
+	}
+}
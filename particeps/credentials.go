@@ -0,0 +1,45 @@
+package particeps
+
+import "os"
+
+// ProviderCredentials holds whatever a provider needs to authenticate uploads.
+// Each provider only looks at the fields it uses.
+type ProviderCredentials struct {
+	APIKey   string `json:"api_key"` // e.g. Imgur's Client-ID
+	Token    string `json:"token"`   // Bearer token, e.g. imgchest's
+	User     string `json:"user"`    // Basic auth user, e.g. Streamable's
+	Password string `json:"password"`
+	UserHash string `json:"user_hash"` // Account hash used by pomf-like hosts such as catbox
+}
+
+// credentialEnv names the environment variables each field falls back to, per provider
+var credentialEnv = map[int]ProviderCredentials{
+	Imgur:      {APIKey: "IMGUR_CLIENT_ID"},
+	Streamable: {User: "STREAMABLE_USER", Password: "STREAMABLE_PASSWORD"},
+	ImgChest:   {Token: "IMGCHEST_TOKEN"},
+}
+
+// SetCredentials sets the credentials used for uploads to the given provider
+func (c *Client) SetCredentials(provider int, creds ProviderCredentials) {
+	if c.Credentials == nil {
+		c.Credentials = map[int]ProviderCredentials{}
+	}
+	c.Credentials[provider] = creds
+}
+
+// credentials returns the credentials for provider, filling unset fields from the provider's environment variables
+func (c *Client) credentials(provider int) ProviderCredentials {
+	creds := c.Credentials[provider]
+	env := credentialEnv[provider]
+	fallback := func(value *string, name string) {
+		if *value == "" && name != "" {
+			*value = os.Getenv(name)
+		}
+	}
+	fallback(&creds.APIKey, env.APIKey)
+	fallback(&creds.Token, env.Token)
+	fallback(&creds.User, env.User)
+	fallback(&creds.Password, env.Password)
+	fallback(&creds.UserHash, env.UserHash)
+	return creds
+}
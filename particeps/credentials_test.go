@@ -0,0 +1,69 @@
+package particeps
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+)
+
+// setenv sets an environment variable until the test ends
+func setenv(t *testing.T, name, value string) {
+	previous, ok := os.LookupEnv(name)
+	if err := os.Setenv(name, value); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv(name, previous)
+		} else {
+			os.Unsetenv(name)
+		}
+	})
+}
+
+func TestCredentialsReachTheirProvider(t *testing.T) {
+	var mu sync.Mutex
+	auth := map[string]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		auth[r.URL.Path] = r.Header.Get("Authorization")
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/imgur":
+			w.Write([]byte(imgurImageResponse))
+		case "/imgchest":
+			w.Write([]byte(`{"data": {"id": "abc", "link": "https://imgchest.com/p/abc"}}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	png, _ := pngWithText(t)
+	path := writeTestFile(t, "a.png", png)
+	setenv(t, "IMGUR_CLIENT_ID", "env-client-id")
+	setenv(t, "IMGCHEST_TOKEN", "env-token")
+
+	c := &Client{Endpoints: map[int]string{Imgur: srv.URL + "/imgur", ImgChest: srv.URL + "/imgchest"}}
+	c.SetCredentials(Imgur, ProviderCredentials{APIKey: "client-id"})
+	c.SetCredentials(ImgChest, ProviderCredentials{Token: "token"})
+	for _, provider := range []int{Imgur, ImgChest} {
+		if _, err := c.UploadContext(context.Background(), provider, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if auth["/imgur"] != "Client-ID client-id" || auth["/imgchest"] != "Bearer token" {
+		t.Fatalf("sent %v, want each provider's own credentials", auth)
+	}
+
+	// Unset credentials come from the environment
+	c.Credentials = nil
+	for _, provider := range []int{Imgur, ImgChest} {
+		if _, err := c.UploadContext(context.Background(), provider, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if auth["/imgur"] != "Client-ID env-client-id" || auth["/imgchest"] != "Bearer env-token" {
+		t.Fatalf("sent %v, want the credentials from the environment", auth)
+	}
+}
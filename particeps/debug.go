@@ -0,0 +1,98 @@
+package particeps
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+)
+
+// redactedHeaders are never written out by DebugRoundTripper
+var redactedHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+// DebugRoundTripper writes a trace of every request and response going through it to Out,
+// with credentials redacted. Bodies are left out unless Bodies is set, since uploads can be large.
+type DebugRoundTripper struct {
+	Next   http.RoundTripper // Defaults to http.DefaultTransport
+	Out    io.Writer
+	Bodies bool
+	// Redact lists further headers to redact, such as API keys sent in custom headers
+	Redact []string
+}
+
+// RoundTrip implements http.RoundTripper
+func (d *DebugRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := d.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	fmt.Fprintf(d.Out, "> %s %s\n", req.Method, req.URL)
+	d.writeHeaders("> ", req.Header)
+	if d.Bodies && req.Body != nil && req.Body != http.NoBody {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(d.Out, ">\n%s\n", body)
+		// RoundTrippers mustn't modify the request, so the body read for the trace is sent with a copy
+		req = req.Clone(req.Context())
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		fmt.Fprintf(d.Out, "< error: %v\n", err)
+		return nil, err
+	}
+	fmt.Fprintf(d.Out, "< %s %s\n", resp.Proto, resp.Status)
+	d.writeHeaders("< ", resp.Header)
+	if d.Bodies {
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+		fmt.Fprintf(d.Out, "<\n%s\n", body)
+	}
+	return resp, nil
+}
+
+// writeHeaders writes header in a stable order, one line per value, redacting credentials
+func (d *DebugRoundTripper) writeHeaders(prefix string, header http.Header) {
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range header[key] {
+			if d.redacted(key) {
+				value = "[redacted]"
+			}
+			fmt.Fprintf(d.Out, "%s%s: %s\n", prefix, key, value)
+		}
+	}
+}
+
+// redacted reports whether the values of the given header are left out of the trace
+func (d *DebugRoundTripper) redacted(key string) bool {
+	if redactedHeaders[key] {
+		return true
+	}
+	for _, name := range d.Redact {
+		if http.CanonicalHeaderKey(name) == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package particeps
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithDebugTrace(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Header().Set("X-Served-By", "mock")
+		w.Write([]byte("https://ttm.sh/abc.txt"))
+	}))
+	defer srv.Close()
+	c := &Client{Endpoints: map[int]string{TtmSh: srv.URL}, EndpointHeaders: map[int]http.Header{TtmSh: {"Authorization": {"Bearer secret"}}}}
+
+	var trace bytes.Buffer
+	res, err := c.UploadReaderContext(context.Background(), TtmSh, strings.NewReader("file contents"), "a.txt", WithDebug(&trace))
+	if err != nil || res.FullURL != "https://ttm.sh/abc.txt" {
+		t.Fatalf("got %+v, %v", res, err)
+	}
+	out := trace.String()
+	for _, line := range []string{
+		"> POST " + srv.URL + "\n",
+		"> Authorization: [redacted]\n",
+		"< HTTP/1.1 200 OK\n",
+		"< Set-Cookie: [redacted]\n",
+		"< X-Served-By: mock\n",
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("trace lacks %q:\n%s", line, out)
+		}
+	}
+	if strings.Contains(out, "secret") || strings.Contains(out, "file contents") {
+		t.Errorf("trace leaks credentials or the body:\n%s", out)
+	}
+
+	trace.Reset()
+	if _, err := c.UploadReaderContext(context.Background(), TtmSh, strings.NewReader("hello"), "a.txt"); err != nil || trace.Len() != 0 {
+		t.Fatalf("traced an upload without WithDebug: %v %q", err, trace.String())
+	}
+}
+
+func TestDebugRoundTripperBodies(t *testing.T) {
+	srv := newTextServer(t, "response body")
+	var trace bytes.Buffer
+	hc := &http.Client{Transport: &DebugRoundTripper{Out: &trace, Bodies: true}}
+	resp, err := hc.Post(srv.URL, "text/plain", strings.NewReader("request body"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "response body" || string(srv.received()[0].Body) != "request body" {
+		t.Fatal("tracing bodies changed what was sent or received")
+	}
+	if out := trace.String(); !strings.Contains(out, ">\nrequest body\n") || !strings.Contains(out, "<\nresponse body\n") {
+		t.Fatalf("trace lacks the bodies:\n%s", out)
+	}
+}
+
+func TestDebugRedactsConfiguredAndSignedHeaders(t *testing.T) {
+	srv := newTextServer(t, "https://ttm.sh/abc.txt")
+	c := &Client{
+		Endpoints:       map[int]string{TtmSh: srv.URL},
+		EndpointHeaders: map[int]http.Header{TtmSh: {"x-api-key": {"key-secret"}}},
+		Signers:         map[int]RequestSigner{TtmSh: HMACSigner{Secret: []byte("k"), SignatureHeader: "X-Sig"}},
+	}
+
+	var trace bytes.Buffer
+	if _, err := c.UploadReaderContext(context.Background(), TtmSh, strings.NewReader("hello"), "a.txt", WithDebug(&trace)); err != nil {
+		t.Fatal(err)
+	}
+	out := trace.String()
+	for _, line := range []string{"> X-Api-Key: [redacted]\n", "> X-Sig: [redacted]\n", "> Content-Type: application/octet-stream\n"} {
+		if !strings.Contains(out, line) {
+			t.Errorf("trace lacks %q:\n%s", line, out)
+		}
+	}
+	got := srv.received()[0].Header
+	if strings.Contains(out, "key-secret") || strings.Contains(out, got.Get("X-Sig")) {
+		t.Errorf("trace leaks the API key or the signature:\n%s", out)
+	}
+}
+
+func TestDebugRoundTripperLeavesRequestAlone(t *testing.T) {
+	srv := newTextServer(t, "ok")
+	req, _ := http.NewRequest("POST", srv.URL, strings.NewReader("request body"))
+	body := req.Body
+	var trace bytes.Buffer
+	resp, err := (&DebugRoundTripper{Out: &trace, Bodies: true}).RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if req.Body != body {
+		t.Fatal("RoundTrip replaced the caller's request body")
+	}
+	if string(srv.received()[0].Body) != "request body" {
+		t.Fatalf("sent %q", srv.received()[0].Body)
+	}
+}
@@ -0,0 +1,115 @@
+package particeps
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrDeleteUnsupported is returned when deleting an upload from a provider that offers no way to do so
+var ErrDeleteUnsupported = errors.New("particeps: provider doesn't support deleting uploads")
+
+// deleteToken is what MarshalDeleteToken keeps of an upload. The provider is recorded by name, which,
+// unlike the constants of providers registered at runtime, stays the same from one process to the next.
+type deleteToken struct {
+	Provider   string `json:"provider"`
+	URL        string `json:"url"`
+	DeleteHash string `json:"delete_hash,omitempty"`
+	DeleteURL  string `json:"delete_url,omitempty"`
+}
+
+// MarshalDeleteToken encodes what is needed to delete the upload that returned res, so it can be stored
+// and handed to Delete later, possibly by another process. It fails with ErrDeleteUnsupported if the
+// upload's provider can't delete it.
+func MarshalDeleteToken(res UniversalResponse) ([]byte, error) {
+	def, err := lookupProvider(res.Provider)
+	if err != nil {
+		return nil, err
+	}
+	if !res.Status {
+		return nil, fmt.Errorf("particeps: no successful upload to delete")
+	}
+	if def.delete == nil {
+		return nil, fmt.Errorf("%w: %s", ErrDeleteUnsupported, def.name)
+	}
+	return json.Marshal(deleteToken{Provider: def.name, URL: res.FullURL, DeleteHash: res.DeleteHash, DeleteURL: res.DeleteURL})
+}
+
+// Delete deletes the upload described by a token from MarshalDeleteToken
+func Delete(ctx context.Context, token []byte) error {
+	return DefaultClient.Delete(ctx, token)
+}
+
+// Delete deletes the upload described by a token from MarshalDeleteToken
+func (c *Client) Delete(ctx context.Context, token []byte) error {
+	var t deleteToken
+	if err := json.Unmarshal(token, &t); err != nil {
+		return fmt.Errorf("particeps: invalid delete token: %w", err)
+	}
+	provider, err := ProviderByName(t.Provider)
+	if err != nil {
+		return err
+	}
+	def, err := lookupProvider(provider)
+	if err != nil {
+		return err
+	}
+	if def.delete == nil {
+		return fmt.Errorf("%w: %s", ErrDeleteUnsupported, def.name)
+	}
+	ctx, done, err := c.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+	return def.delete(c, &uploadRequest{provider: provider, ctx: ctx}, t)
+}
+
+// deleteURL deletes an upload with a DELETE request to the given URL
+func (c *Client) deleteURL(u *uploadRequest, url string, header http.Header) error {
+	req, err := http.NewRequestWithContext(u.ctx, "DELETE", url, nil)
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	u.endpoint = url
+	resp, err := c.send(u, req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+		return nil // already deleted, or expired
+	}
+	_, err = httpSuccess(resp, nil)
+	return err
+}
+
+// filebinDelete deletes a file from its bin; Filebin lets anyone with the link do so
+func (c *Client) filebinDelete(u *uploadRequest, t deleteToken) error {
+	return c.deleteURL(u, t.URL, nil)
+}
+
+// transferShDelete uses the deletion link transfer.sh returned in the X-Url-Delete header
+func (c *Client) transferShDelete(u *uploadRequest, t deleteToken) error {
+	if t.DeleteURL == "" {
+		return fmt.Errorf("%w: transfer.sh gave no deletion link", ErrDeleteUnsupported)
+	}
+	return c.deleteURL(u, t.DeleteURL, nil)
+}
+
+// imgurDelete deletes an anonymously uploaded image using its delete hash
+func (c *Client) imgurDelete(u *uploadRequest, t deleteToken) error {
+	clientID := c.credentials(Imgur).APIKey
+	if clientID == "" {
+		return fmt.Errorf("particeps: no Imgur Client-ID set")
+	}
+	if t.DeleteHash == "" {
+		return fmt.Errorf("%w: no Imgur delete hash", ErrDeleteUnsupported)
+	}
+	return c.deleteURL(u, c.imgurImageURL(t.DeleteHash), http.Header{"Authorization": {"Client-ID " + clientID}})
+}
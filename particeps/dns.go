@@ -0,0 +1,93 @@
+package particeps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// ErrDNS is returned when a provider's host name couldn't be resolved
+var ErrDNS = errors.New("particeps: DNS lookup failed")
+
+// HostResolver looks up the addresses of a host. *net.Resolver implements it.
+type HostResolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// DNSServerResolver returns a resolver that queries the DNS server at addr, e.g. "1.1.1.1:53",
+// instead of the system's, for use as a Client's Resolver or FallbackResolver
+func DNSServerResolver(addr string) *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}
+}
+
+// resolvingDialer dials hosts by the addresses its resolvers give, trying fallback when primary fails
+type resolvingDialer struct {
+	dialer   net.Dialer
+	primary  HostResolver // net.DefaultResolver if nil
+	fallback HostResolver // Optional
+}
+
+func newResolvingDialer(primary, fallback HostResolver) *resolvingDialer {
+	return &resolvingDialer{
+		dialer:   net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}, // as http.DefaultTransport
+		primary:  primary,
+		fallback: fallback,
+	}
+}
+
+func (d *resolvingDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) != nil {
+		return d.dialer.DialContext(ctx, network, addr)
+	}
+	ips, err := d.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	for _, ip := range ips {
+		if network == "tcp4" && ip.IP.To4() == nil || network == "tcp6" && ip.IP.To4() != nil {
+			continue
+		}
+		var conn net.Conn
+		if conn, err = d.dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port)); err == nil {
+			return conn, nil
+		}
+	}
+	if err == nil {
+		err = &net.DNSError{Err: "no suitable address found", Name: host, IsNotFound: true}
+	}
+	return nil, err
+}
+
+// lookup resolves host with the primary resolver, falling back to the fallback resolver on failure
+func (d *resolvingDialer) lookup(ctx context.Context, host string) ([]net.IPAddr, error) {
+	primary := d.primary
+	if primary == nil {
+		primary = net.DefaultResolver
+	}
+	ips, err := primary.LookupIPAddr(ctx, host)
+	if (err != nil || len(ips) == 0) && d.fallback != nil && ctx.Err() == nil {
+		ips, err = d.fallback.LookupIPAddr(ctx, host)
+	}
+	if err == nil && len(ips) == 0 {
+		err = &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return ips, err
+}
+
+// classifyDNS wraps err in ErrDNS if it is a failure to resolve a host name
+func classifyDNS(err error) error {
+	var dnsErr *net.DNSError
+	if err != nil && !errors.Is(err, ErrDNS) && errors.As(err, &dnsErr) {
+		return fmt.Errorf("%w: %v", ErrDNS, err)
+	}
+	return err
+}
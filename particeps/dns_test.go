@@ -0,0 +1,90 @@
+package particeps
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeResolver resolves every host to ip, or fails if ip is nil, counting its lookups
+type fakeResolver struct {
+	ip      net.IP
+	mu      sync.Mutex
+	lookups int
+}
+
+func (r *fakeResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	r.mu.Lock()
+	r.lookups++
+	r.mu.Unlock()
+	if r.ip == nil {
+		return nil, &net.DNSError{Err: "server misbehaving", Name: host, IsTemporary: true}
+	}
+	return []net.IPAddr{{IP: r.ip}}, nil
+}
+
+func (r *fakeResolver) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lookups
+}
+
+func TestFallbackResolver(t *testing.T) {
+	srv := newTextServer(t, "https://ttm.sh/abc.txt")
+	u, _ := url.Parse(srv.URL)
+	endpoint := "http://uploads.example:" + u.Port() + "/"
+	failing, fallback := &fakeResolver{}, &fakeResolver{ip: net.IPv4(127, 0, 0, 1)}
+
+	c := &Client{Endpoints: map[int]string{TtmSh: endpoint}, Resolver: failing}
+	_, err := c.UploadReaderContext(context.Background(), TtmSh, strings.NewReader("hello"), "a.txt")
+	if !errors.Is(err, ErrDNS) {
+		t.Fatalf("err = %v, want ErrDNS", err)
+	}
+	if !DefaultFailoverPredicate(TtmSh, nil, err) {
+		t.Fatal("DNS failures don't fail over")
+	}
+
+	c.FallbackResolver = fallback
+	res, err := c.UploadReaderContext(context.Background(), TtmSh, strings.NewReader("hello"), "a.txt")
+	if err != nil || res.FullURL != "https://ttm.sh/abc.txt" {
+		t.Fatalf("with a fallback resolver: got %+v, %v", res, err)
+	}
+	if failing.count() != 2 || fallback.count() != 1 {
+		t.Fatalf("%d lookups on the primary resolver and %d on the fallback, want 2 and 1", failing.count(), fallback.count())
+	}
+	if got := srv.received(); len(got) != 1 || string(got[0].Body) != "hello" {
+		t.Fatalf("the upload didn't reach the resolved address")
+	}
+}
+
+// resolverFunc is a HostResolver that, being a func, can't be compared
+type resolverFunc func(ctx context.Context, host string) ([]net.IPAddr, error)
+
+func (f resolverFunc) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	return f(ctx, host)
+}
+
+func TestUncomparableResolver(t *testing.T) {
+	srv := newTextServer(t, "https://ttm.sh/abc.txt")
+	u, _ := url.Parse(srv.URL)
+	var lookups []string
+	c := &Client{
+		Endpoints: map[int]string{TtmSh: "http://uploads.example:" + u.Port() + "/"},
+		Resolver: resolverFunc(func(ctx context.Context, host string) ([]net.IPAddr, error) {
+			lookups = append(lookups, host)
+			return []net.IPAddr{{IP: net.IPv4(127, 0, 0, 1)}}, nil
+		}),
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := c.UploadReaderContext(context.Background(), TtmSh, strings.NewReader("hello"), "a.txt"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(lookups) != 1 || lookups[0] != "uploads.example" {
+		t.Fatalf("lookups = %v, want one for uploads.example and its connection reused", lookups)
+	}
+}
@@ -0,0 +1,36 @@
+package particeps
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithExpectContinue(t *testing.T) {
+	for _, status := range []int{http.StatusRequestEntityTooLarge, http.StatusExpectationFailed} {
+		var expect string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Answering without reading the body tells the client not to send it
+			expect = r.Header.Get("Expect")
+			w.WriteHeader(status)
+		}))
+		fs := &countingFS{}
+		c := &Client{Endpoints: map[int]string{TransferSh: srv.URL}, FS: fs}
+		path := writeTestFile(t, "big.bin", make([]byte, 8<<20))
+
+		res, err := c.UploadContext(context.Background(), TransferSh, path, WithExpectContinue())
+		srv.Close()
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != status || res.Status {
+			t.Fatalf("%d: got %+v, %v, want a StatusError", status, res, err)
+		}
+		if expect != "100-continue" {
+			t.Fatalf("%d: Expect = %q", status, expect)
+		}
+		if n := fs.bytesRead(); n >= 1<<20 {
+			t.Fatalf("%d: read %d bytes of the file, want the body not sent", status, n)
+		}
+	}
+}
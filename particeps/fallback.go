@@ -0,0 +1,71 @@
+package particeps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrAllProvidersFailed is returned by UploadFallback when no provider accepted the file
+var ErrAllProvidersFailed = errors.New("particeps: every provider failed")
+
+// FailoverPredicate decides whether a failed upload to provider should be retried on the next provider.
+// resp is the provider's last HTTP response, or nil if none was received; err is the upload's error, if any.
+type FailoverPredicate func(provider int, resp *http.Response, err error) bool
+
+// DefaultFailoverPredicate fails over on network errors, including ErrDNS, rate limiting and server errors, but not when the
+// provider rejected the request itself (e.g. 413 Payload Too Large), since the next provider would most
+// likely reject it as well
+func DefaultFailoverPredicate(provider int, resp *http.Response, err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if resp == nil {
+		return true
+	}
+	switch {
+	case resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode == http.StatusTooManyRequests:
+		return true
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return false
+	}
+	return true
+}
+
+// UploadFallback uploads the given file to the first provider in the list, moving on to the next one
+// whenever the upload fails and the Client's FailoverPredicate allows it
+func UploadFallback(providers []int, filename string, opts ...Option) (ProviderResult, error) {
+	return DefaultClient.UploadFallbackContext(context.Background(), providers, filename, opts...)
+}
+
+// UploadFallbackContext uploads the given file to the first provider in the list that accepts it.
+// The returned ProviderResult is the last attempt made.
+func (c *Client) UploadFallbackContext(ctx context.Context, providers []int, filename string, opts ...Option) (ProviderResult, error) {
+	failover := c.FailoverPredicate
+	if failover == nil {
+		failover = DefaultFailoverPredicate
+	}
+
+	var last ProviderResult
+	for _, provider := range providers {
+		if c.SkipUnavailable {
+			if up, _ := c.ProviderAvailable(ctx, provider); !up {
+				last = ProviderResult{Provider: provider, Filename: filename, Err: fmt.Errorf("%w: %d", ErrProviderUnavailable, provider)}
+				continue
+			}
+		}
+		res, resp, err := c.uploadFile(ctx, provider, filename, opts)
+		last = ProviderResult{Provider: provider, Filename: filename, Response: res, Err: err}
+		if err == nil && res.Status {
+			return last, nil
+		}
+		if !failover(provider, resp, err) {
+			break
+		}
+	}
+	if last.Err != nil {
+		return last, last.Err
+	}
+	return last, ErrAllProvidersFailed
+}
@@ -0,0 +1,88 @@
+package particeps
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// statusServer answers every request with the given status code
+func statusServer(t *testing.T, code int) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(code)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestFailoverStatusCodes(t *testing.T) {
+	for code, failover := range map[int]bool{
+		http.StatusServiceUnavailable:    true,
+		http.StatusTooManyRequests:       true,
+		http.StatusInternalServerError:   true,
+		http.StatusRequestTimeout:        true,
+		http.StatusRequestEntityTooLarge: false,
+		http.StatusBadRequest:            false,
+		http.StatusUnauthorized:          false,
+	} {
+		next := newTextServer(t, "https://ttm.sh/abc.txt")
+		c := &Client{Endpoints: map[int]string{TransferSh: statusServer(t, code).URL, TtmSh: next.URL}}
+		path := writeTestFile(t, "a.txt", []byte("hello"))
+
+		res, err := c.UploadFallbackContext(context.Background(), []int{TransferSh, TtmSh}, path)
+		if tried := len(next.received()) == 1; tried != failover {
+			t.Errorf("%d: failed over = %v, want %v", code, tried, failover)
+		}
+		if failover && (err != nil || res.Provider != TtmSh || res.Response.FullURL != "https://ttm.sh/abc.txt") {
+			t.Errorf("%d: got %+v, %v, want the next provider's upload", code, res, err)
+		}
+		if !failover && (err == nil || res.Provider != TransferSh) {
+			t.Errorf("%d: got %+v, %v, want the first provider's failure", code, res, err)
+		}
+	}
+}
+
+func TestFailoverOnNetworkError(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	next := newTextServer(t, "https://ttm.sh/abc.txt")
+	c := &Client{Endpoints: map[int]string{TransferSh: down.URL, TtmSh: next.URL}}
+	res, err := c.UploadFallbackContext(context.Background(), []int{TransferSh, TtmSh}, writeTestFile(t, "a.txt", []byte("hello")))
+	if err != nil || res.Provider != TtmSh {
+		t.Fatalf("got %+v, %v, want the next provider used", res, err)
+	}
+}
+
+func TestCustomFailoverPredicate(t *testing.T) {
+	next := newTextServer(t, "https://ttm.sh/abc.txt")
+	var asked []int
+	c := &Client{
+		Endpoints: map[int]string{TransferSh: statusServer(t, http.StatusServiceUnavailable).URL, TtmSh: next.URL},
+		FailoverPredicate: func(provider int, resp *http.Response, err error) bool {
+			asked = append(asked, provider, resp.StatusCode)
+			return false
+		},
+	}
+	if _, err := c.UploadFallbackContext(context.Background(), []int{TransferSh, TtmSh}, writeTestFile(t, "a.txt", []byte("hello"))); err == nil {
+		t.Fatal("want the failure reported")
+	}
+	if len(next.received()) != 0 || len(asked) != 2 || asked[0] != TransferSh || asked[1] != http.StatusServiceUnavailable {
+		t.Fatalf("predicate was asked %v, want once about the 503", asked)
+	}
+}
+
+func TestUploadAutoFailover(t *testing.T) {
+	path := writeTestFile(t, "a.txt", []byte("hello"))
+	for code, failover := range map[int]bool{http.StatusServiceUnavailable: true, http.StatusRequestEntityTooLarge: false} {
+		next := newTextServer(t, "https://ttm.sh/abc.txt")
+		c := &Client{
+			Endpoints:     map[int]string{TransferSh: statusServer(t, code).URL, TtmSh: next.URL},
+			AutoProviders: []int{TransferSh, TtmSh},
+		}
+		c.UploadAutoContext(context.Background(), path)
+		if tried := len(next.received()) == 1; tried != failover {
+			t.Errorf("%d: failed over = %v, want %v", code, tried, failover)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package particeps
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRemoteFilename(t *testing.T) {
+	for path, want := range map[string]string{
+		`C:\path\to\file.txt`:            "file.txt",
+		`C:/path/to/file.txt`:            "file.txt",
+		`\\server\share\dir\file.txt`:    "file.txt",
+		`\\?\C:\very\long\path\file.txt`: "file.txt",
+		`/home/me/file.txt`:              "file.txt",
+		`relative/dir\mixed\file.txt`:    "file.txt",
+		"file.txt":                       "file.txt",
+		`C:\path\to\`:                    "file",
+		"..":                             "file",
+	} {
+		if got := remoteFilename(path); got != want {
+			t.Errorf("remoteFilename(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestNoLocalPathOnTheWire(t *testing.T) {
+	const local = `C:\Users\me\Documents\notes.txt`
+	filebin := newTextServer(t, filebinResponse)
+	uguu := newTextServer(t, `{"success": true, "files": [{"url": "https://a.uguu.se/abc.txt"}]}`)
+	transferSh := newTextServer(t, "https://transfer.sh/abc/notes.txt")
+	c := &Client{Endpoints: map[int]string{Filebin: filebin.URL, Uguu: uguu.URL, TransferSh: transferSh.URL}}
+	for _, provider := range []int{Filebin, Uguu, TransferSh} {
+		if _, err := c.UploadReaderContext(context.Background(), provider, strings.NewReader("hello"), local); err != nil {
+			t.Fatalf("provider %d: %v", provider, err)
+		}
+	}
+
+	if name := filebin.received()[0].Header.Get("Filename"); name != "notes.txt" {
+		t.Errorf("Filebin's Filename header is %q", name)
+	}
+	body := uguu.received()[0].Body
+	if !bytes.Contains(body, []byte(`filename="notes.txt"`)) || bytes.Contains(body, []byte("Users")) {
+		t.Errorf("multipart body leaks the local path:\n%s", body)
+	}
+	if path := transferSh.received()[0].Path; path != "/notes.txt" {
+		t.Errorf("transfer.sh upload path is %q", path)
+	}
+}
+
+func TestCheckFileWindowsPaths(t *testing.T) {
+	if runtime.GOOS != "windows" {
+		t.Skip("backslashes only separate paths on Windows")
+	}
+	path := writeTestFile(t, "file.txt", []byte("hello"))
+	for _, p := range []string{path, strings.Replace(path, `\`, "/", -1), `\\?\` + path} {
+		if _, err := CheckFile(p); err != nil {
+			t.Errorf("CheckFile(%q): %v", p, err)
+		}
+	}
+}
+
+func TestWithRemoteName(t *testing.T) {
+	filebin := newTextServer(t, filebinResponse)
+	uguu := newTextServer(t, `{"success": true, "files": [{"url": "https://a.uguu.se/abc.pdf"}]}`)
+	c := &Client{Endpoints: map[int]string{Filebin: filebin.URL, Uguu: uguu.URL}}
+	path := writeTestFile(t, "report_final_v3.pdf", []byte("hello"))
+	for _, provider := range []int{Filebin, Uguu} {
+		if _, err := c.UploadContext(context.Background(), provider, path, WithRemoteName(`../docs\report.pdf`)); err != nil {
+			t.Fatalf("provider %d: %v", provider, err)
+		}
+	}
+
+	if name := filebin.received()[0].Header.Get("Filename"); name != "report.pdf" {
+		t.Errorf("Filebin's Filename header is %q, want report.pdf", name)
+	}
+	if body := uguu.received()[0].Body; !bytes.Contains(body, []byte(`filename="report.pdf"`)) {
+		t.Errorf("multipart body doesn't name the file report.pdf:\n%s", body)
+	}
+}
+
+func TestLongFilenameTruncated(t *testing.T) {
+	uguu := newTextServer(t, `{"success": true, "files": [{"url": "https://a.uguu.se/abc.txt"}]}`)
+	c := &Client{Endpoints: map[int]string{Uguu: uguu.URL}}
+	long := strings.Repeat("a", 396) + ".txt"
+
+	if _, err := c.UploadReaderContext(context.Background(), Uguu, strings.NewReader("hello"), "bad\x00\x1fname\n"+long); err != nil {
+		t.Fatal(err)
+	}
+	want := "badname" + strings.Repeat("a", 255-len("badname.txt")) + ".txt"
+	if body := uguu.received()[0].Body; !bytes.Contains(body, []byte(`filename="`+want+`"`)) {
+		t.Fatalf("multipart body doesn't name the file %s:\n%s", want, body)
+	}
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := []struct {
+		max        int
+		name, want string
+	}{
+		{0, "notes.txt", "notes.txt"},
+		{10, "notes\tfile.txt", "notesf.txt"},
+		{10, "abcdefghijkl.md", "abcdefg.md"},
+		{9, "ééééé.txt", "éé.txt"},    // never splits a rune
+		{4, "archive.tar.gz", "arch"}, // an extension longer than half the limit isn't kept
+	}
+	for _, tc := range tests {
+		c := &Client{MaxFilenameLength: tc.max}
+		if got := c.sanitizeFilename(tc.name); got != tc.want {
+			t.Errorf("sanitizeFilename(%q) with a limit of %d = %q, want %q", tc.name, tc.max, got, tc.want)
+		}
+	}
+}
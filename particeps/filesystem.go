@@ -0,0 +1,103 @@
+package particeps
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// FileSystem is where a Client reads the files it uploads from. Replacing it, e.g. with an in-memory
+// implementation, lets callers exercise missing files, permission errors or huge sizes without real files.
+type FileSystem interface {
+	Open(name string) (File, error)
+	Stat(name string) (os.FileInfo, error)
+	Lstat(name string) (os.FileInfo, error)
+	// EvalSymlinks returns name with any symlinks resolved, as filepath.EvalSymlinks does
+	EvalSymlinks(name string) (string, error)
+	// ReadDir returns the entries of the named directory sorted by name, as ioutil.ReadDir does
+	ReadDir(name string) ([]os.FileInfo, error)
+}
+
+// File is an open file from a FileSystem. *os.File implements it.
+type File interface {
+	io.Reader
+	io.ReaderAt
+	io.Seeker
+	io.Closer
+	Stat() (os.FileInfo, error)
+}
+
+// OSFileSystem is the FileSystem backed by the os package, used when Client.FS is nil
+type OSFileSystem struct{}
+
+// Open implements FileSystem
+func (OSFileSystem) Open(name string) (File, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err // not a nil *os.File wrapped in a non-nil File
+	}
+	return f, nil
+}
+
+// Stat implements FileSystem
+func (OSFileSystem) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
+
+// Lstat implements FileSystem
+func (OSFileSystem) Lstat(name string) (os.FileInfo, error) {
+	return os.Lstat(name)
+}
+
+// EvalSymlinks implements FileSystem
+func (OSFileSystem) EvalSymlinks(name string) (string, error) {
+	return filepath.EvalSymlinks(name)
+}
+
+// ReadDir implements FileSystem
+func (OSFileSystem) ReadDir(name string) ([]os.FileInfo, error) {
+	return ioutil.ReadDir(name)
+}
+
+// fileSystem returns the FileSystem the Client reads from
+func (c *Client) fileSystem() FileSystem {
+	if c.FS != nil {
+		return c.FS
+	}
+	return OSFileSystem{}
+}
+
+// walk is filepath.Walk over the Client's FileSystem: it calls fn for root and everything under it,
+// in lexical order, without following symlinks
+func (c *Client) walk(root string, fn filepath.WalkFunc) error {
+	fsys := c.fileSystem()
+	info, err := fsys.Lstat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = walkFileSystem(fsys, root, info, fn)
+	}
+	if err == filepath.SkipDir {
+		return nil
+	}
+	return err
+}
+
+func walkFileSystem(fsys FileSystem, path string, info os.FileInfo, fn filepath.WalkFunc) error {
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+	entries, err := fsys.ReadDir(path)
+	if err1 := fn(path, info, err); err != nil || err1 != nil {
+		return err1
+	}
+	for _, entry := range entries {
+		if err := walkFileSystem(fsys, filepath.Join(path, entry.Name()), entry, fn); err != nil {
+			if !entry.IsDir() || err != filepath.SkipDir {
+				return err
+			}
+		}
+	}
+	return nil
+}
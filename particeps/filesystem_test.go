@@ -0,0 +1,238 @@
+package particeps
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// memFS is an in-memory FileSystem. Directories are implied by the paths of the files under them,
+// and the paths in denied fail to be opened or listed with a permission error.
+type memFS struct {
+	files  map[string]memFile
+	denied map[string]bool
+}
+
+// memFile is a file in a memFS. Files with a size but no data read as zeros, so huge ones cost nothing.
+type memFile struct {
+	data string
+	size int64
+}
+
+func (f memFile) len() int64 {
+	if f.size > 0 {
+		return f.size
+	}
+	return int64(len(f.data))
+}
+
+type memFileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (fi memFileInfo) Name() string       { return fi.name }
+func (fi memFileInfo) Size() int64        { return fi.size }
+func (fi memFileInfo) ModTime() time.Time { return time.Time{} }
+func (fi memFileInfo) IsDir() bool        { return fi.dir }
+func (fi memFileInfo) Sys() interface{}   { return nil }
+func (fi memFileInfo) Mode() os.FileMode {
+	if fi.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+type memHandle struct {
+	*io.SectionReader
+	info memFileInfo
+}
+
+func (h memHandle) Stat() (os.FileInfo, error) { return h.info, nil }
+func (h memHandle) Close() error               { return nil }
+
+type zeros struct{}
+
+func (zeros) ReadAt(p []byte, off int64) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+func (fs memFS) Open(name string) (File, error) {
+	info, err := fs.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if fs.denied[name] {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrPermission}
+	}
+	f := fs.files[name]
+	var r io.ReaderAt = zeros{}
+	if f.data != "" {
+		r = strings.NewReader(f.data)
+	}
+	return memHandle{io.NewSectionReader(r, 0, f.len()), info.(memFileInfo)}, nil
+}
+
+func (fs memFS) Stat(name string) (os.FileInfo, error) {
+	if f, ok := fs.files[name]; ok {
+		return memFileInfo{name: path.Base(name), size: f.len()}, nil
+	}
+	for filename := range fs.files {
+		if strings.HasPrefix(filename, name+"/") {
+			return memFileInfo{name: path.Base(name), dir: true}, nil
+		}
+	}
+	return nil, &os.PathError{Op: "stat", Path: name, Err: os.ErrNotExist}
+}
+
+func (fs memFS) Lstat(name string) (os.FileInfo, error) {
+	return fs.Stat(name)
+}
+
+func (fs memFS) EvalSymlinks(name string) (string, error) {
+	return name, nil
+}
+
+func (fs memFS) ReadDir(name string) ([]os.FileInfo, error) {
+	if fs.denied[name] {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrPermission}
+	}
+	seen := map[string]bool{}
+	var entries []os.FileInfo
+	for filename := range fs.files {
+		if !strings.HasPrefix(filename, name+"/") {
+			continue
+		}
+		child := name + "/" + strings.SplitN(strings.TrimPrefix(filename, name+"/"), "/", 2)[0]
+		if !seen[child] {
+			seen[child] = true
+			info, _ := fs.Stat(child)
+			entries = append(entries, info)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+func TestFileSystemMissingFiles(t *testing.T) {
+	srv := newTextServer(t, "https://ttm.sh/abc.txt")
+	c := &Client{Endpoints: map[int]string{TtmSh: srv.URL}, FS: memFS{files: map[string]memFile{"/docs/a.txt": {data: "a"}}}}
+
+	if _, err := c.UploadContext(context.Background(), TtmSh, "/docs/b.txt"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("UploadContext: err = %v, want os.ErrNotExist", err)
+	}
+	if _, err := c.UploadDirContext(context.Background(), TtmSh, "/photos"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("UploadDirContext: err = %v, want os.ErrNotExist", err)
+	}
+	if _, err := c.UploadZipContext(context.Background(), TtmSh, "/photos"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("UploadZipContext: err = %v, want os.ErrNotExist", err)
+	}
+	if n := len(srv.received()); n != 0 {
+		t.Fatalf("%d requests sent for missing files", n)
+	}
+}
+
+func TestFileSystemPermissionErrors(t *testing.T) {
+	srv := newTextServer(t, "https://ttm.sh/abc.txt")
+	fs := memFS{
+		files: map[string]memFile{
+			"/docs/a.txt":          {data: "hello"},
+			"/docs/secret.txt":     {data: "hidden"},
+			"/docs/private/b.txt":  {data: "private"},
+			"/other/c.txt":         {data: "abc"},
+			"/other/nested/d.txt":  {data: "d"},
+			"/other/nested/e.txt":  {data: "e"},
+			"/other/nested/f.data": {data: "ff"},
+		},
+		denied: map[string]bool{"/docs/secret.txt": true, "/docs/private": true},
+	}
+	c := &Client{Endpoints: map[int]string{TtmSh: srv.URL}, FS: fs}
+
+	if _, err := c.UploadContext(context.Background(), TtmSh, "/docs/secret.txt"); !errors.Is(err, os.ErrPermission) {
+		t.Errorf("UploadContext: err = %v, want os.ErrPermission", err)
+	}
+	if _, err := c.UploadDirContext(context.Background(), TtmSh, "/docs"); !errors.Is(err, os.ErrPermission) {
+		t.Errorf("UploadDirContext: err = %v, want os.ErrPermission", err)
+	}
+	if n := len(srv.received()); n != 0 {
+		t.Fatalf("%d requests sent despite the permission errors", n)
+	}
+
+	useDefaultClient(t, &Client{FS: fs})
+	total, _, err := EstimateBatchSizeRecursive([]string{"/docs", "/other"})
+	var errs FileErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || !errors.Is(errs["/docs/private"], os.ErrPermission) {
+		t.Fatalf("err = %v, want only /docs/private reported", err)
+	}
+	if want := int64(len("hello") + len("hidden") + len("abc") + len("d") + len("e") + len("ff")); total != want {
+		t.Fatalf("total = %d, want %d", total, want)
+	}
+}
+
+func TestFileSystemHugeFiles(t *testing.T) {
+	srv := newTextServer(t, "https://transfer.sh/abc/movie.mkv")
+	fs := memFS{files: map[string]memFile{
+		"/videos/movie.mkv": {size: 40 << 30},
+		"/videos/notes.txt": {data: "hello"},
+	}}
+	c := &Client{Endpoints: map[int]string{TransferSh: srv.URL}, FS: fs}
+
+	if _, err := c.UploadContext(context.Background(), TransferSh, "/videos/movie.mkv"); !errors.Is(err, ErrFileTooLarge) {
+		t.Fatalf("err = %v, want ErrFileTooLarge", err)
+	}
+	if n := len(srv.received()); n != 0 {
+		t.Fatalf("%d requests sent for a file over the limit", n)
+	}
+
+	useDefaultClient(t, &Client{FS: fs})
+	for _, filenames := range [][]string{{"/videos/movie.mkv", "/videos/notes.txt"}, {"/videos"}} {
+		total, pretty, err := EstimateBatchSizeRecursive(filenames)
+		if err != nil || total != 40<<30+5 {
+			t.Fatalf("%v: got %d (%s), %v, want %d", filenames, total, pretty, err, int64(40<<30+5))
+		}
+	}
+}
+
+func TestUploadDirWalksFileSystem(t *testing.T) {
+	srv := newTextServer(t, "https://ttm.sh/abc.txt")
+	c := &Client{Endpoints: map[int]string{TtmSh: srv.URL}, FS: memFS{files: map[string]memFile{
+		"/docs/a.txt":        {data: "a"},
+		"/docs/sub/b.txt":    {data: "bb"},
+		"/docs/sub/deep/c.x": {data: "ccc"},
+		"/elsewhere/d.txt":   {data: "d"},
+	}}}
+
+	results, err := c.UploadDirContext(context.Background(), TtmSh, "/docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for filename, res := range results {
+		if res.failed() {
+			t.Fatalf("%s: %v", filename, res.Err)
+		}
+		got = append(got, filename)
+	}
+	sort.Strings(got)
+	if want := "/docs/a.txt /docs/sub/b.txt /docs/sub/deep/c.x"; strings.Join(got, " ") != want {
+		t.Fatalf("uploaded %v, want %s", got, want)
+	}
+	var bodies []string
+	for _, req := range srv.received() {
+		bodies = append(bodies, string(req.Body))
+	}
+	sort.Strings(bodies)
+	if strings.Join(bodies, ",") != "a,bb,ccc" {
+		t.Fatalf("bodies = %q, want the in-memory contents", bodies)
+	}
+}
@@ -0,0 +1,53 @@
+package particeps
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+)
+
+// UploadFromURL re-hosts the file at srcURL on the given provider. The file is streamed from the source to
+// the provider as it downloads, without being stored locally. Its name comes from the source's
+// Content-Disposition header or, failing that, from the URL's path.
+func UploadFromURL(provider int, srcURL string, opts ...Option) (UniversalResponse, error) {
+	return DefaultClient.UploadFromURLContext(context.Background(), provider, srcURL, opts...)
+}
+
+// UploadFromURLContext re-hosts the file at srcURL on the given provider.
+// Sources announcing a Content-Length beyond the provider's MaxFileSize fail with ErrFileTooLarge before the
+// upload starts.
+func (c *Client) UploadFromURLContext(ctx context.Context, provider int, srcURL string, opts ...Option) (UniversalResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", srcURL, nil)
+	if err != nil {
+		return UniversalResponse{}, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return UniversalResponse{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return UniversalResponse{}, &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		opts = append([]Option{WithContentType(contentType)}, opts...)
+	}
+	res, _, err := c.upload(ctx, provider, withSize(resp.Body, resp.ContentLength), sourceFilename(resp), opts)
+	return res, err
+}
+
+// sourceFilename picks the name of a downloaded file from the response's Content-Disposition header
+// or, failing that, the last element of the requested URL's path
+func sourceFilename(resp *http.Response) string {
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		return params["filename"]
+	}
+	if resp.Request != nil {
+		if name, err := url.PathUnescape(path.Base(resp.Request.URL.EscapedPath())); err == nil && name != "/" && name != "." {
+			return name
+		}
+	}
+	return "file"
+}
@@ -0,0 +1,68 @@
+package particeps
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestUploadFromURL(t *testing.T) {
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/files/report 2021.txt":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("quarterly numbers"))
+		case "/download":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Content-Disposition", `attachment; filename="notes.txt"`)
+			w.Write([]byte("some notes"))
+		case "/huge":
+			w.Header().Set("Content-Length", strconv.Itoa(200<<20))
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer source.Close()
+	dest := newTextServer(t, "https://transfer.sh/abc/file")
+	c := &Client{Endpoints: map[int]string{TransferSh: dest.URL, Uguu: dest.URL}}
+
+	tests := []struct {
+		path        string
+		name        string
+		body        string
+		contentType string
+	}{
+		{"/files/report%202021.txt?dl=1", "/report 2021.txt", "quarterly numbers", "text/plain"},
+		{"/download", "/notes.txt", "some notes", "application/octet-stream"},
+	}
+	for _, tc := range tests {
+		before := len(dest.received())
+		res, err := c.UploadFromURLContext(context.Background(), TransferSh, source.URL+tc.path)
+		if err != nil || !res.Status {
+			t.Fatalf("%s: got %+v, %v", tc.path, res, err)
+		}
+		got := dest.received()[before]
+		if got.Path != tc.name || string(got.Body) != tc.body || got.ContentLength != int64(len(tc.body)) {
+			t.Errorf("%s: re-hosted as %s holding %q (Content-Length %d)", tc.path, got.Path, got.Body, got.ContentLength)
+		}
+		if ct := got.Header.Get("Content-Type"); ct != tc.contentType {
+			t.Errorf("%s: re-hosted as %s, want %s", tc.path, ct, tc.contentType)
+		}
+	}
+
+	before := len(dest.received())
+	if _, err := c.UploadFromURLContext(context.Background(), Uguu, source.URL+"/huge"); !errors.Is(err, ErrFileTooLarge) {
+		t.Errorf("source larger than the provider allows: err = %v, want ErrFileTooLarge", err)
+	}
+	var statusErr *StatusError
+	if _, err := c.UploadFromURLContext(context.Background(), TransferSh, source.URL+"/missing"); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("missing source: err = %v, want a StatusError for 404", err)
+	}
+	if n := len(dest.received()) - before; n != 0 {
+		t.Errorf("%d failed re-hosts reached the provider", n)
+	}
+}
@@ -0,0 +1,124 @@
+package particeps
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// recordedRequest is what a test server kept of a request it received
+type recordedRequest struct {
+	Method string
+	Path   string
+	Header http.Header
+	Body   []byte
+	// ContentLength is the length the request announced, or -1 if it was sent chunked
+	ContentLength int64
+}
+
+// textServer is a mock provider answering every request with the same body, recording the requests
+type textServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []recordedRequest
+}
+
+// newTextServer starts a textServer answering with body, closed when the test ends
+func newTextServer(t *testing.T, body string) *textServer {
+	s := &textServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		s.mu.Lock()
+		s.requests = append(s.requests, recordedRequest{r.Method, r.URL.Path, r.Header.Clone(), data, r.ContentLength})
+		s.mu.Unlock()
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// received returns the requests received so far
+func (s *textServer) received() []recordedRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]recordedRequest(nil), s.requests...)
+}
+
+// tempDir creates a temporary directory removed when the test ends
+func tempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "particeps-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+// writeTestFile writes data to a file named name in a temporary directory and returns its path
+func writeTestFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(tempDir(t), name)
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// countingFS is the OS FileSystem, counting the bytes read from the files it opens
+type countingFS struct {
+	OSFileSystem
+	mu   sync.Mutex
+	read int64
+}
+
+func (fs *countingFS) Open(name string) (File, error) {
+	f, err := fs.OSFileSystem.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &countingFile{File: f, fs: fs}, nil
+}
+
+// bytesRead returns the bytes read from the opened files so far
+func (fs *countingFS) bytesRead() int64 {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.read
+}
+
+type countingFile struct {
+	File
+	fs *countingFS
+}
+
+func (f *countingFile) Read(p []byte) (int, error) {
+	n, err := f.File.Read(p)
+	f.fs.mu.Lock()
+	f.fs.read += int64(n)
+	f.fs.mu.Unlock()
+	return n, err
+}
+
+func (f *countingFile) ReadAt(p []byte, off int64) (int, error) {
+	n, err := f.File.ReadAt(p, off)
+	f.fs.mu.Lock()
+	f.fs.read += int64(n)
+	f.fs.mu.Unlock()
+	return n, err
+}
+
+// useDefaultClient makes c the DefaultClient until the test ends
+func useDefaultClient(t *testing.T, c *Client) {
+	previous := DefaultClient
+	DefaultClient = c
+	t.Cleanup(func() { DefaultClient = previous })
+}
+
+// filebinResponse is Filebin's answer to the upload of a 5 byte file, such as "hello"
+const filebinResponse = `{"filename": "notes.txt", "bin": {"id": "bin1", "readonly": false, "expired_at": "2030-01-01T00:00:00Z"},
+	"bytes": 5, "links": [{"rel": "bin", "href": "https://filebin.net/bin1"}, {"rel": "file", "href": "https://filebin.net/bin1/notes.txt"}]}`
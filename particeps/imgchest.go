@@ -0,0 +1,111 @@
+package particeps
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+)
+
+const imgChestURL = "https://api.imgchest.com/v1/post"
+
+// ImgChestUpload creates an imgchest.com post holding the given images and returns the post's URL in FullURL.
+// The token is the ImgChest Token in the Client's Credentials, or the IMGCHEST_TOKEN environment variable.
+func ImgChestUpload(files []string, title string) (UniversalResponse, error) {
+	return DefaultClient.ImgChestUploadContext(context.Background(), files, title)
+}
+
+// ImgChestUploadContext creates an imgchest.com post holding the given images
+func (c *Client) ImgChestUploadContext(ctx context.Context, files []string, title string) (UniversalResponse, error) {
+	ctx, done, err := c.begin(ctx)
+	if err != nil {
+		return UniversalResponse{}, err
+	}
+	defer done()
+	readers := make([]namedReader, 0, len(files))
+	for _, filename := range files {
+		f, filename, err := c.openFile(filename)
+		if err != nil {
+			return UniversalResponse{}, err
+		}
+		defer f.Close()
+		readers = append(readers, namedReader{r: f, filename: c.sanitizeFilename(remoteFilename(filename))})
+	}
+	endpoint := imgChestURL
+	if override := c.Endpoints[ImgChest]; override != "" {
+		endpoint = override
+	}
+	u := &uploadRequest{provider: ImgChest, ctx: ctx, endpoint: endpoint}
+	res, err := c.imgChestPost(u, readers, title)
+	if err != nil {
+		return res, err
+	}
+	res.Status, err = imgChestSuccess(u.resp, u.parsed)
+	return res, err
+}
+
+func (c *Client) imgChestUpload(u *uploadRequest) (UniversalResponse, error) {
+	return c.imgChestPost(u, []namedReader{{r: u.r, filename: u.filename}}, "")
+}
+
+// imgChestPost creates a post from the given images. u supplies the context and endpoint.
+func (c *Client) imgChestPost(u *uploadRequest, images []namedReader, title string) (UniversalResponse, error) {
+	var returnValue UniversalResponse
+	returnValue.Status = false
+
+	token := c.credentials(ImgChest).Token
+	if token == "" {
+		return returnValue, fmt.Errorf("particeps: no imgchest token set")
+	}
+
+	// Multi-part Body
+	mpb := getBuffer()
+	pooled := newPooledBody(mpb)
+	defer pooled.release()
+	mw := multipart.NewWriter(mpb)
+	if title != "" {
+		if err := mw.WriteField("title", title); err != nil {
+			return returnValue, err
+		}
+	}
+	for _, image := range images {
+		r, err := checkMediaType(image.r, image.filename, "image/")
+		if err != nil {
+			return returnValue, err
+		}
+		partWriter, err := createFormFile(mw, "images[]", image.filename)
+		if err != nil {
+			return returnValue, err
+		}
+		if err := copyPart(partWriter, r, -1, image.filename); err != nil {
+			return returnValue, err
+		}
+	}
+	mw.Close()
+
+	req, err := http.NewRequestWithContext(u.ctx, "POST", u.endpoint, pooled.reader())
+	if err != nil {
+		return returnValue, err
+	}
+	withPooledBody(req)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := c.send(u, req)
+	if err != nil {
+		return returnValue, err
+	}
+	defer resp.Body.Close()
+
+	body, err := readBody(resp)
+	if err != nil {
+		return returnValue, err
+	}
+	var successResponse ImgChestPost
+	if err := json.Unmarshal(body, &successResponse); err != nil {
+		return returnValue, err
+	}
+	u.parsed = &successResponse
+	returnValue.FullURL = successResponse.Data.Link
+	return returnValue, nil
+}
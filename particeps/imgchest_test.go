@@ -0,0 +1,70 @@
+package particeps
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestImgChestUpload(t *testing.T) {
+	var images []string
+	var title, auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Error(err)
+			return
+		}
+		title = r.FormValue("title")
+		for _, fh := range r.MultipartForm.File["images[]"] {
+			images = append(images, fh.Filename)
+		}
+		w.Write([]byte(`{"data":{"id":"abc","title":"Holiday","link":"https://imgchest.com/p/abc"}}`))
+	}))
+	t.Cleanup(srv.Close)
+	c := &Client{Endpoints: map[int]string{ImgChest: srv.URL}}
+	c.SetCredentials(ImgChest, ProviderCredentials{Token: "secret"})
+	png, _ := pngWithText(t)
+	jpg, _ := jpegWithEXIF(t)
+	files := []string{writeTestFile(t, "a.png", png), writeTestFile(t, "b.jpg", jpg)}
+
+	res, err := c.ImgChestUploadContext(context.Background(), files, "Holiday")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Status || res.FullURL != "https://imgchest.com/p/abc" {
+		t.Fatalf("got %+v, want the post URL", res)
+	}
+	if auth != "Bearer secret" || title != "Holiday" || len(images) != 2 || images[0] != "a.png" || images[1] != "b.jpg" {
+		t.Fatalf("sent auth %q, title %q and images %v", auth, title, images)
+	}
+
+	// Posts with anything but images are refused before anything is sent
+	images = nil
+	files = append(files, writeTestFile(t, "c.txt", []byte("not an image")))
+	if _, err := c.ImgChestUploadContext(context.Background(), files, ""); !errors.Is(err, ErrUnsupportedMediaType) {
+		t.Fatalf("err = %v, want ErrUnsupportedMediaType", err)
+	}
+	if images != nil {
+		t.Fatal("a post was created with a non-image file")
+	}
+}
+
+func TestImgChestUploadNoPostLink(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	t.Cleanup(srv.Close)
+	c := &Client{Endpoints: map[int]string{ImgChest: srv.URL}}
+	c.SetCredentials(ImgChest, ProviderCredentials{Token: "secret"})
+	png, _ := pngWithText(t)
+
+	res, err := c.ImgChestUploadContext(context.Background(), []string{writeTestFile(t, "a.png", png)}, "")
+	if !errors.Is(err, ErrProviderRejected) || res.Status {
+		t.Fatalf("got %+v, %v, want ErrProviderRejected", res, err)
+	}
+}
@@ -0,0 +1,315 @@
+package particeps
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const imgurURL = "https://api.imgur.com/3/image"
+
+// imgurAPIURL is the root of Imgur's API
+const imgurAPIURL = "https://api.imgur.com/3"
+
+// imgurVideoURL takes videos as well as images, sent in the "video" field
+const imgurVideoURL = "https://api.imgur.com/3/upload"
+
+// Imgur's limits on anonymous uploads
+const (
+	imgurMaxImageSize     = 20 << 20
+	imgurMaxVideoSize     = 200 << 20
+	imgurMaxVideoDuration = 60 * time.Second
+)
+
+// RateLimit holds the credits reported by Imgur's X-RateLimit-* headers
+type RateLimit struct {
+	ClientLimit     int
+	ClientRemaining int
+	UserLimit       int
+	UserRemaining   int
+	UserReset       time.Time // When the user credits are replenished
+}
+
+// ErrRateLimited is returned when a provider refuses uploads until its rate limit resets,
+// including when it answers 429 Too Many Requests
+type ErrRateLimited struct {
+	Reset      time.Time
+	RetryAfter time.Duration // How long to wait before trying again, from the Retry-After header
+}
+
+func (e *ErrRateLimited) Error() string {
+	switch {
+	case e.RetryAfter > 0:
+		return fmt.Sprintf("particeps: rate limited by provider, retry after %s", e.RetryAfter)
+	case !e.Reset.IsZero():
+		return fmt.Sprintf("particeps: rate limited by provider until %s", e.Reset.Format(time.RFC1123))
+	}
+	return "particeps: rate limited by provider"
+}
+
+// rateLimited builds the error for a 429 response, honoring its Retry-After header
+func rateLimited(resp *http.Response, now time.Time) *ErrRateLimited {
+	err := &ErrRateLimited{}
+	if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After"), now); ok {
+		err.RetryAfter = wait
+		err.Reset = now.Add(wait)
+	}
+	return err
+}
+
+// parseRetryAfter reads a Retry-After header, given either in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	at, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if wait := at.Sub(now); wait > 0 {
+		return wait, true
+	}
+	return 0, true
+}
+
+// parseRateLimit reads Imgur's rate limit headers, returning nil if there are none
+func parseRateLimit(header http.Header) *RateLimit {
+	if header.Get("X-RateLimit-ClientRemaining") == "" && header.Get("X-RateLimit-UserRemaining") == "" {
+		return nil
+	}
+	atoi := func(key string) int {
+		n, _ := strconv.Atoi(header.Get(key))
+		return n
+	}
+	limit := &RateLimit{
+		ClientLimit:     atoi("X-RateLimit-ClientLimit"),
+		ClientRemaining: atoi("X-RateLimit-ClientRemaining"),
+		UserLimit:       atoi("X-RateLimit-UserLimit"),
+		UserRemaining:   atoi("X-RateLimit-UserRemaining"),
+	}
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-UserReset"), 10, 64); err == nil {
+		limit.UserReset = time.Unix(reset, 0)
+	}
+	return limit
+}
+
+// exhausted reports whether either the client or the user credits have run out
+func (l *RateLimit) exhausted() bool {
+	return l.ClientLimit > 0 && l.ClientRemaining <= 0 || l.UserLimit > 0 && l.UserRemaining <= 0
+}
+
+// ImgurUpload uploads an image or a short video to imgur.com anonymously and returns an UniversalResponse
+// with the upload's data. Videos are reported by their .mp4 link, along with the .gifv page in GIFVURL.
+// Videos longer than Imgur's MaxVideoDuration fail with ErrVideoTooLong, when their duration can be read.
+// The Client-ID is the Imgur APIKey in the Client's Credentials, or the IMGUR_CLIENT_ID environment variable.
+func ImgurUpload(filename string) (UniversalResponse, error) {
+	return Upload(Imgur, filename)
+}
+
+func (c *Client) imgurUpload(u *uploadRequest) (UniversalResponse, error) {
+	var returnValue UniversalResponse
+	returnValue.Status = false
+
+	clientID := c.credentials(Imgur).APIKey
+	if clientID == "" {
+		return returnValue, fmt.Errorf("particeps: no Imgur Client-ID set")
+	}
+
+	video, err := c.imgurPrepare(u)
+	if err != nil {
+		return returnValue, err
+	}
+
+	resp, body, err := c.sendFile(u, nil, http.Header{"Authorization": {"Client-ID " + clientID}})
+	if resp != nil {
+		returnValue.RateLimit = parseRateLimit(resp.Header)
+		// An upload that went through while using up the last credits is still reported, along with RateLimit
+		if limit := returnValue.RateLimit; limit != nil && limit.exhausted() && resp.StatusCode >= 400 {
+			return returnValue, &ErrRateLimited{Reset: limit.UserReset}
+		}
+	}
+	if err != nil {
+		return returnValue, err
+	}
+
+	var successResponse ImgurSuccess
+	err = json.Unmarshal(body, &successResponse)
+	if err != nil {
+		return returnValue, err
+	}
+
+	u.parsed = &successResponse
+	returnValue.FullURL = successResponse.Data.Link
+	returnValue.ID = successResponse.Data.ID
+	returnValue.DeleteHash = successResponse.Data.Deletehash
+	if video {
+		if successResponse.Data.MP4 != "" {
+			returnValue.FullURL = successResponse.Data.MP4
+		}
+		returnValue.GIFVURL = successResponse.Data.GIFV
+		return returnValue, nil
+	}
+	returnValue.Thumbnails = imgurThumbnails(successResponse.Data.Link)
+	return returnValue, nil
+}
+
+// imgurPrepare checks the upload against Imgur's limits for its media type and, for videos, switches it to
+// the video field and endpoint. It reports whether the upload is a video.
+func (c *Client) imgurPrepare(u *uploadRequest) (bool, error) {
+	contentType := u.opts.contentType
+	if contentType == "" {
+		size := readerSize(u.r)
+		detected, r, err := sniffContentType(u.r, u.filename)
+		if err != nil {
+			return false, err
+		}
+		contentType, u.r = detected, withSize(r, size)
+	}
+	video := strings.HasPrefix(contentType, "video/")
+
+	max := int64(imgurMaxImageSize)
+	if video {
+		max = imgurMaxVideoSize
+	}
+	if size := readerSize(u.r); size >= 0 && size > max {
+		return video, fmt.Errorf("%w: %s is %d bytes, Imgur takes %s files up to %d", ErrFileTooLarge, u.filename, size, contentType, max)
+	}
+	if video {
+		u.shape.field = "video"
+		if u.endpoint == imgurURL {
+			u.endpoint = imgurVideoURL
+		}
+	}
+	return video, nil
+}
+
+// ImgurUpdate sets the title and description of an image uploaded to Imgur anonymously,
+// given the delete hash returned when it was uploaded
+func ImgurUpdate(deleteHash, title, description string) error {
+	return DefaultClient.ImgurUpdateContext(context.Background(), deleteHash, title, description)
+}
+
+// ImgurUpdateContext sets the title and description of an anonymously uploaded Imgur image
+func (c *Client) ImgurUpdateContext(ctx context.Context, deleteHash, title, description string) error {
+	clientID := c.credentials(Imgur).APIKey
+	if clientID == "" {
+		return fmt.Errorf("particeps: no Imgur Client-ID set")
+	}
+	form := url.Values{"title": {title}, "description": {description}}
+	if err := c.imgurPostForm(ctx, clientID, c.imgurImageURL(deleteHash), form); err != nil {
+		return fmt.Errorf("particeps: updating Imgur image %s: %w", deleteHash, err)
+	}
+	return nil
+}
+
+// ImgurAddToAlbum adds images uploaded to Imgur, given by the ID of their UniversalResponse, to an album.
+// For albums created anonymously, albumHash is the album's delete hash.
+func ImgurAddToAlbum(albumHash string, imageIDs []string) error {
+	return DefaultClient.ImgurAddToAlbumContext(context.Background(), albumHash, imageIDs)
+}
+
+// ImgurAddToAlbumContext adds the images with the given ids to an Imgur album
+func (c *Client) ImgurAddToAlbumContext(ctx context.Context, albumHash string, imageIDs []string) error {
+	clientID := c.credentials(Imgur).APIKey
+	if clientID == "" {
+		return fmt.Errorf("particeps: no Imgur Client-ID set")
+	}
+	if len(imageIDs) == 0 {
+		return nil
+	}
+	form := url.Values{"ids[]": imageIDs}
+	endpoint := c.imgurAPIURL("/album/" + url.PathEscape(albumHash) + "/add")
+	if err := c.imgurPostForm(ctx, clientID, endpoint, form); err != nil {
+		return fmt.Errorf("particeps: adding images to Imgur album %s: %w", albumHash, err)
+	}
+	return nil
+}
+
+// imgurPostForm posts form to one of Imgur's API endpoints and checks that it succeeded
+func (c *Client) imgurPostForm(ctx context.Context, clientID, endpoint string, form url.Values) error {
+	ctx, done, err := c.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+	u := &uploadRequest{provider: Imgur, ctx: ctx, endpoint: endpoint}
+	req, err := http.NewRequestWithContext(ctx, "POST", u.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Client-ID "+clientID)
+	resp, err := c.send(u, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := readBody(resp)
+	if err != nil {
+		return err
+	}
+	if ok, err := httpSuccess(resp, nil); !ok {
+		return err
+	}
+	var result struct {
+		Success bool `json:"success"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return err
+	}
+	if !result.Success {
+		return fmt.Errorf("%w: Imgur refused the request", ErrProviderRejected)
+	}
+	return nil
+}
+
+// imgurAPIURL returns the URL of an Imgur API path, under the same root as the Client's Imgur endpoint override
+func (c *Client) imgurAPIURL(path string) string {
+	root := imgurAPIURL
+	if override := c.Endpoints[Imgur]; override != "" {
+		root = strings.TrimSuffix(strings.TrimSuffix(override, "/"), "/image")
+	}
+	return root + path
+}
+
+// imgurImageURL returns the API URL of the image with the given id or delete hash
+func (c *Client) imgurImageURL(id string) string {
+	endpoint := imgurURL
+	if override := c.Endpoints[Imgur]; override != "" {
+		endpoint = override
+	}
+	return strings.TrimSuffix(endpoint, "/") + "/" + url.PathEscape(id)
+}
+
+// imgurThumbnailSizes are the suffixes Imgur serves thumbnails under, appended to an image's id
+var imgurThumbnailSizes = []string{"s", "b", "t", "m", "l", "h"}
+
+// imgurThumbnails derives the thumbnail URLs of an image from its link, e.g. https://i.imgur.com/abc1234m.jpg
+// for the "m" thumbnail of https://i.imgur.com/abc1234.png. Thumbnails are always JPEGs, and
+// animations and videos have none.
+func imgurThumbnails(link string) map[string]string {
+	i := strings.LastIndexByte(link, '.')
+	if i <= strings.LastIndexByte(link, '/') {
+		return nil
+	}
+	switch strings.ToLower(link[i:]) {
+	case ".gif", ".gifv", ".mp4", ".webm":
+		return nil
+	}
+	thumbnails := make(map[string]string, len(imgurThumbnailSizes))
+	for _, size := range imgurThumbnailSizes {
+		thumbnails[size] = link[:i] + size + ".jpg"
+	}
+	return thumbnails
+}
@@ -0,0 +1,294 @@
+package particeps
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// imgurClient returns a Client sending Imgur uploads to srv
+func imgurClient(srv *httptest.Server) *Client {
+	return &Client{
+		Endpoints:   map[int]string{Imgur: srv.URL + "/3/image"},
+		Credentials: map[int]ProviderCredentials{Imgur: {APIKey: "client-id"}},
+	}
+}
+
+const imgurImageResponse = `{"data": {"id": "abc1234", "deletehash": "dh123", "link": "https://i.imgur.com/abc1234.png",
+	"type": "image/png"}, "success": true, "status": 200}`
+
+func setExhaustedRateLimit(header http.Header, reset time.Time) {
+	header.Set("X-RateLimit-ClientLimit", "12500")
+	header.Set("X-RateLimit-ClientRemaining", "4000")
+	header.Set("X-RateLimit-UserLimit", "500")
+	header.Set("X-RateLimit-UserRemaining", "0")
+	header.Set("X-RateLimit-UserReset", strconv.FormatInt(reset.Unix(), 10))
+}
+
+func TestImgurExhaustedRateLimitRefused(t *testing.T) {
+	reset := time.Unix(1700000000, 0)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		setExhaustedRateLimit(w.Header(), reset)
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"data": {"error": "Too Many Requests"}, "success": false, "status": 429}`))
+	}))
+	defer srv.Close()
+
+	_, err := imgurClient(srv).UploadReaderContext(context.Background(), Imgur, strings.NewReader("\x89PNG"), "a.png")
+	var limited *ErrRateLimited
+	if !errors.As(err, &limited) {
+		t.Fatalf("err = %v, want ErrRateLimited", err)
+	}
+	if !limited.Reset.Equal(reset) {
+		t.Fatalf("Reset = %v, want %v", limited.Reset, reset)
+	}
+}
+
+func TestImgurLastCreditUploadStillReported(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		setExhaustedRateLimit(w.Header(), time.Unix(1700000000, 0))
+		w.Write([]byte(imgurImageResponse))
+	}))
+	defer srv.Close()
+
+	res, err := imgurClient(srv).UploadReaderContext(context.Background(), Imgur, strings.NewReader("\x89PNG"), "a.png")
+	if err != nil || !res.Status {
+		t.Fatalf("upload failed: %v", err)
+	}
+	if res.FullURL != "https://i.imgur.com/abc1234.png" || res.DeleteHash != "dh123" {
+		t.Fatalf("got %q and delete hash %q", res.FullURL, res.DeleteHash)
+	}
+	if res.RateLimit == nil || res.RateLimit.UserRemaining != 0 || res.RateLimit.ClientRemaining != 4000 || !res.RateLimit.exhausted() {
+		t.Fatalf("RateLimit = %+v", res.RateLimit)
+	}
+}
+
+func TestTooManyRequests(t *testing.T) {
+	clock := newFakeClock()
+	var retryAfter string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", retryAfter)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+	c := &Client{Endpoints: map[int]string{TtmSh: srv.URL}, Clock: clock}
+	path := writeTestFile(t, "notes.txt", []byte("hello"))
+
+	tests := []struct {
+		header string
+		wait   time.Duration
+	}{
+		{"120", 2 * time.Minute},
+		{clock.now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{clock.now.Add(-time.Hour).Format(http.TimeFormat), 0},
+		{"", 0},
+		{"soon", 0},
+	}
+	for _, tc := range tests {
+		retryAfter = tc.header
+		res, err := c.UploadContext(context.Background(), TtmSh, path)
+		var limited *ErrRateLimited
+		if !errors.As(err, &limited) || res.Status {
+			t.Fatalf("Retry-After %q: got %+v, %v, want ErrRateLimited", tc.header, res, err)
+		}
+		if limited.RetryAfter != tc.wait {
+			t.Errorf("Retry-After %q: RetryAfter = %s, want %s", tc.header, limited.RetryAfter, tc.wait)
+		}
+		if tc.wait > 0 && !limited.Reset.Equal(clock.now.Add(tc.wait)) {
+			t.Errorf("Retry-After %q: Reset = %v, want %v", tc.header, limited.Reset, clock.now.Add(tc.wait))
+		}
+	}
+}
+
+func TestImgurUpdate(t *testing.T) {
+	var method, path, auth, title, description string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path, auth = r.Method, r.URL.Path, r.Header.Get("Authorization")
+		title, description = r.PostFormValue("title"), r.PostFormValue("description")
+		if title == "refused" {
+			w.Write([]byte(`{"data": false, "success": false, "status": 200}`))
+			return
+		}
+		w.Write([]byte(`{"data": true, "success": true, "status": 200}`))
+	}))
+	defer srv.Close()
+	c := imgurClient(srv)
+
+	if err := c.ImgurUpdateContext(context.Background(), "dh123", "Holiday", "At the beach"); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	if method != http.MethodPost || path != "/3/image/dh123" || auth != "Client-ID client-id" {
+		t.Fatalf("got %s %s with Authorization %q", method, path, auth)
+	}
+	if title != "Holiday" || description != "At the beach" {
+		t.Fatalf("title = %q, description = %q", title, description)
+	}
+
+	if err := c.ImgurUpdateContext(context.Background(), "dh123", "refused", ""); !errors.Is(err, ErrProviderRejected) {
+		t.Fatalf("err = %v, want ErrProviderRejected", err)
+	}
+
+	path = ""
+	c.Credentials = nil
+	if err := c.ImgurUpdateContext(context.Background(), "dh123", "Holiday", ""); err == nil || path != "" {
+		t.Fatalf("update without a Client-ID: err = %v, request to %q", err, path)
+	}
+}
+
+func TestImgurRejectedWith200(t *testing.T) {
+	tests := []struct {
+		response string
+		message  string
+	}{
+		{`{"data": {"error": "File type invalid (1)", "request": "/3/image", "method": "POST"}, "success": false, "status": 400}`, "File type invalid (1)"},
+		{`{"data": {"error": {"code": 1003, "message": "File type invalid (2)", "type": "ImgurException"}}, "success": false, "status": 400}`, "File type invalid (2)"},
+	}
+	for _, tc := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(tc.response))
+		}))
+		res, err := imgurClient(srv).UploadReaderContext(context.Background(), Imgur, strings.NewReader("\x89PNG"), "a.png")
+		srv.Close()
+		if !errors.Is(err, ErrProviderRejected) || res.Status {
+			t.Errorf("got %+v, %v, want ErrProviderRejected", res, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), tc.message) {
+			t.Errorf("err = %v, want Imgur's message %q", err, tc.message)
+		}
+	}
+}
+
+func TestImgurThumbnails(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(imgurImageResponse))
+	}))
+	defer srv.Close()
+
+	res, err := imgurClient(srv).UploadReaderContext(context.Background(), Imgur, strings.NewReader("\x89PNG"), "a.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"s": "https://i.imgur.com/abc1234s.jpg",
+		"b": "https://i.imgur.com/abc1234b.jpg",
+		"t": "https://i.imgur.com/abc1234t.jpg",
+		"m": "https://i.imgur.com/abc1234m.jpg",
+		"l": "https://i.imgur.com/abc1234l.jpg",
+		"h": "https://i.imgur.com/abc1234h.jpg",
+	}
+	if len(res.Thumbnails) != len(want) {
+		t.Fatalf("Thumbnails = %v, want %v", res.Thumbnails, want)
+	}
+	for size, url := range want {
+		if res.Thumbnails[size] != url {
+			t.Errorf("thumbnail %q = %q, want %q", size, res.Thumbnails[size], url)
+		}
+	}
+
+	for _, link := range []string{"https://i.imgur.com/abc1234.gif", "https://i.imgur.com/abc1234.mp4", "https://imgur.com/abc1234"} {
+		if thumbnails := imgurThumbnails(link); thumbnails != nil {
+			t.Errorf("%s has thumbnails %v, want none", link, thumbnails)
+		}
+	}
+}
+
+func TestImgurVideoUpload(t *testing.T) {
+	var fields []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("not a multipart form: %v", err)
+			return
+		}
+		for field := range r.MultipartForm.File {
+			fields = append(fields, field)
+		}
+		w.Write([]byte(`{"data": {"id": "vid1234", "deletehash": "dh456", "link": "https://i.imgur.com/vid1234.mp4",
+			"type": "video/mp4", "mp4": "https://i.imgur.com/vid1234.mp4", "gifv": "https://i.imgur.com/vid1234.gifv"},
+			"success": true, "status": 200}`))
+	}))
+	defer srv.Close()
+	mp4 := "\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom" + strings.Repeat("\x00", 64)
+
+	res, err := imgurClient(srv).UploadReaderContext(context.Background(), Imgur, strings.NewReader(mp4), "clip.mp4")
+	if err != nil || !res.Status {
+		t.Fatalf("upload failed: %v", err)
+	}
+	if len(fields) != 1 || fields[0] != "video" {
+		t.Fatalf("sent the file in %v, want the video field", fields)
+	}
+	if res.FullURL != "https://i.imgur.com/vid1234.mp4" || res.GIFVURL != "https://i.imgur.com/vid1234.gifv" || res.Thumbnails != nil {
+		t.Fatalf("got %q, GIFV %q and thumbnails %v", res.FullURL, res.GIFVURL, res.Thumbnails)
+	}
+}
+
+func TestImgurSizeLimitsByMediaType(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("oversized file sent")
+	}))
+	defer srv.Close()
+	big := make([]byte, imgurMaxImageSize+1)
+	copy(big, "\x89PNG\r\n\x1a\n")
+
+	_, err := imgurClient(srv).UploadReaderContext(context.Background(), Imgur, bytes.NewReader(big), "big.png")
+	if !errors.Is(err, ErrFileTooLarge) {
+		t.Fatalf("image over Imgur's image limit: err = %v, want ErrFileTooLarge", err)
+	}
+}
+
+func TestImgurAddToAlbum(t *testing.T) {
+	var mu sync.Mutex
+	uploads := 0
+	var albumPath, auth string
+	var albumIDs []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path == "/3/image" {
+			uploads++
+			id := "img" + strconv.Itoa(uploads)
+			w.Write([]byte(`{"data": {"id": "` + id + `", "deletehash": "dh` + id + `", "link": "https://i.imgur.com/` + id + `.png",
+				"type": "image/png"}, "success": true, "status": 200}`))
+			return
+		}
+		r.ParseForm()
+		albumPath, auth, albumIDs = r.URL.Path, r.Header.Get("Authorization"), r.PostForm["ids[]"]
+		w.Write([]byte(`{"data": true, "success": true, "status": 200}`))
+	}))
+	defer srv.Close()
+	c := imgurClient(srv)
+
+	var ids []string
+	for i := 0; i < 2; i++ {
+		res, err := c.UploadReaderContext(context.Background(), Imgur, strings.NewReader("\x89PNG"), "a.png")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "img" + strconv.Itoa(i+1); res.ID != want || res.DeleteHash != "dh"+want {
+			t.Fatalf("upload %d: ID %q and delete hash %q, want %q and dh%s", i, res.ID, res.DeleteHash, want, want)
+		}
+		ids = append(ids, res.ID)
+	}
+
+	if err := c.ImgurAddToAlbumContext(context.Background(), "albumDH", ids); err != nil {
+		t.Fatal(err)
+	}
+	if albumPath != "/3/album/albumDH/add" || auth != "Client-ID client-id" {
+		t.Fatalf("sent to %s with Authorization %q", albumPath, auth)
+	}
+	if strings.Join(albumIDs, ",") != "img1,img2" {
+		t.Fatalf("added %v, want the uploaded ids", albumIDs)
+	}
+
+	albumPath = ""
+	if err := c.ImgurAddToAlbumContext(context.Background(), "albumDH", nil); err != nil || albumPath != "" {
+		t.Fatalf("adding no images: %v, request to %q", err, albumPath)
+	}
+}
@@ -1,21 +1,63 @@
 package particeps
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // UniversalResponse is the struct that all uploads return
 type UniversalResponse struct {
 	Status   bool
+	Provider int // Constant of the provider the file was uploaded to
 	FullURL  string
 	ShortURL string
+	Location string // Redirect target, set when the Client captures redirects instead of following them
+	RawURL   string // FullURL as returned by the provider, set when the Client's URLTransform rewrote it
+
+	ShortenedURL string // FullURL as shortened by the Client's ShortenWith
+
+	RateLimit  *RateLimit    // Remaining credits, for providers that report them
+	ModTime    time.Time     // Modification time of the uploaded file, when uploaded from disk
+	Cached     bool          // The URL comes from a previous upload of the same content, see Client.ReuseIfUploaded
+	ExpiresAt  time.Time     // When the provider deletes the upload, for providers that report it
+	Compressed bool          // The file was sent gzip-compressed, see WithGzip
+	Size       int64         // Bytes uploaded, before any compression
+	StoredSize int64         // Bytes the provider reports having stored, for providers that report it
+	Duration   time.Duration // Time taken by the upload
+
+	PasswordProtected bool              // The upload was protected with the password given by WithPassword
+	IdempotencyKey    string            // Sent in the Idempotency-Key header, see WithIdempotencyKey
+	Labels            map[string]string // Given by WithLabels, for the caller's bookkeeping
+	ScanResult        ScanResult        // Safety verdict, for providers that scan uploads
+	RemoteFilename    string            // Name the provider stored the file under, for providers that may rename files
+	ID                string            // Provider's id of the upload, e.g. Imgur's image id for ImgurAddToAlbum
+	// Thumbnails maps size labels to URLs of smaller versions of an uploaded image, for providers that make
+	// them. Imgur's labels are its URL suffixes: "s" (90x90 square), "b" (160x160 square), "t" (160),
+	// "m" (320), "l" (640) and "h" (1024), where plain sizes bound the longest side.
+	Thumbnails map[string]string
+	GIFVURL    string // Imgur's looping .gifv page of an uploaded video, whose FullURL is the .mp4
+
+	// Secrets for deleting the upload, for providers that give them; see MarshalDeleteToken
+	DeleteHash string // Imgur's delete hash
+	DeleteURL  string // transfer.sh's deletion link
+
+	// Filebin bin the file was added to, and whether the bin is locked against further uploads
+	Bin       string
+	BinLocked bool
+
+	// Hex digests of the uploaded content, for comparing with what providers report
+	MD5    string
+	SHA256 string
 }
 
 // FilebinSuccess matches the successful JSON response given by Filebin
 type FilebinSuccess struct {
-	Filename string    `json:"filename"`
-	Bin      string    `json:"bin"`
-	Bytes    int       `json:"bytes"`
-	Mime     string    `json:"mime"`
-	Created  time.Time `json:"created"`
+	Filename string     `json:"filename"`
+	Bin      string     `json:"-"` // The bin's id, as in BinInfo
+	BinInfo  FilebinBin `json:"bin"`
+	Bytes    int        `json:"bytes"`
+	Mime     string     `json:"mime"`
+	Created  time.Time  `json:"created"`
 	Links    []struct {
 		Rel  string `json:"rel"`
 		Href string `json:"href"`
@@ -23,6 +65,42 @@ type FilebinSuccess struct {
 	Datetime time.Time `json:"datetime"`
 }
 
+// UnmarshalJSON fills Bin from the bin's id, since Filebin now describes the bin as an object
+func (s *FilebinSuccess) UnmarshalJSON(data []byte) error {
+	type success FilebinSuccess // without the UnmarshalJSON method
+	if err := json.Unmarshal(data, (*success)(s)); err != nil {
+		return err
+	}
+	s.Bin = s.BinInfo.ID
+	return nil
+}
+
+// FilebinBin describes the bin a file was uploaded to.
+// Older Filebin versions only report the bin's id.
+type FilebinBin struct {
+	ID        string    `json:"id"`
+	Readonly  bool      `json:"readonly"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+// UnmarshalJSON accepts both the bin object and the bare id sent by older Filebin versions
+func (b *FilebinBin) UnmarshalJSON(data []byte) error {
+	var id string
+	if err := json.Unmarshal(data, &id); err == nil {
+		*b = FilebinBin{ID: id}
+		return nil
+	}
+	type bin FilebinBin // without the UnmarshalJSON method
+	return json.Unmarshal(data, (*bin)(b))
+}
+
+// KekShPost matches the JSON response given by kek.sh for an uploaded file
+type KekShPost struct {
+	Filename string `json:"filename"` // Stored name, possibly with a random prefix added
+	Key      string `json:"key"`
+	Size     int64  `json:"size"`
+}
+
 // AnonFilesSuccess matches the successful JSON response given by AnonFiles
 type AnonFilesSuccess struct {
 	Status bool `json:"status"`
@@ -53,3 +131,56 @@ type AnonFilesFailure struct {
 		Code    int    `json:"code"`
 	} `json:"error"`
 }
+
+// ImgurSuccess matches the JSON response given by Imgur's image upload endpoint
+type ImgurSuccess struct {
+	Data struct {
+		ID         string `json:"id"`
+		Deletehash string `json:"deletehash"`
+		Link       string `json:"link"`
+		MP4        string `json:"mp4"`  // Set for videos
+		GIFV       string `json:"gifv"` // Set for videos
+		Type       string `json:"type"`
+		Size       int    `json:"size"`
+		// Error explains why the upload failed, when Success is false
+		Error ImgurError `json:"error"`
+	} `json:"data"`
+	Success bool `json:"success"`
+	Status  int  `json:"status"`
+}
+
+// ImgurError is the error message Imgur gives for a failed request
+type ImgurError string
+
+// UnmarshalJSON accepts both the bare message and the {"message": ...} object Imgur sends for some errors
+func (e *ImgurError) UnmarshalJSON(data []byte) error {
+	var message string
+	if err := json.Unmarshal(data, &message); err == nil {
+		*e = ImgurError(message)
+		return nil
+	}
+	var object struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return err
+	}
+	*e = ImgurError(object.Message)
+	return nil
+}
+
+// StreamableVideo matches the JSON responses given by Streamable for uploads and video status
+type StreamableVideo struct {
+	Shortcode string `json:"shortcode"`
+	Status    int    `json:"status"`
+	URL       string `json:"url"`
+}
+
+// ImgChestPost matches the JSON response given by imgchest when creating a post
+type ImgChestPost struct {
+	Data struct {
+		ID    string `json:"id"`
+		Title string `json:"title"`
+		Link  string `json:"link"`
+	} `json:"data"`
+}
@@ -0,0 +1,46 @@
+package particeps
+
+import (
+	"encoding/json"
+	"net/url"
+)
+
+const (
+	kekShURL = "https://kek.sh/api/v1/posts"
+	// kekShFileURL is where kek.sh serves the file stored under a given name
+	kekShFileURL = "https://i.kek.sh/"
+)
+
+// KekShUpload uploads an image to kek.sh and returns an UniversalResponse with the upload's data.
+// kek.sh may store the file under a different name than the one sent; see UniversalResponse.RemoteFilename.
+func KekShUpload(filename string) (UniversalResponse, error) {
+	return Upload(KekSh, filename)
+}
+
+func (c *Client) kekShUpload(u *uploadRequest) (UniversalResponse, error) {
+	var returnValue UniversalResponse
+	returnValue.Status = false
+	if err := requireMediaType(u, "image/"); err != nil {
+		return returnValue, err
+	}
+
+	_, body, err := c.sendFile(u, nil, nil)
+	if err != nil {
+		return returnValue, err
+	}
+	var successResponse KekShPost
+	if err := json.Unmarshal(body, &successResponse); err != nil {
+		return returnValue, err
+	}
+	u.parsed = &successResponse
+
+	// The stored name, which may have gained a random prefix, is what the file is served under
+	returnValue.RemoteFilename = successResponse.Filename
+	if returnValue.RemoteFilename == "" {
+		returnValue.RemoteFilename = successResponse.Key
+	}
+	if returnValue.RemoteFilename != "" {
+		returnValue.FullURL = kekShFileURL + url.PathEscape(returnValue.RemoteFilename)
+	}
+	return returnValue, nil
+}
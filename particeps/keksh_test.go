@@ -0,0 +1,30 @@
+package particeps
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestKekShUpload(t *testing.T) {
+	tests := []struct {
+		response string
+		remote   string
+		url      string
+	}{
+		{`{"filename": "xK3a9-holiday photo.png", "key": "xK3a9", "size": 4}`, "xK3a9-holiday photo.png", "https://i.kek.sh/xK3a9-holiday%20photo.png"},
+		{`{"filename": "holiday photo.png", "key": "", "size": 4}`, "holiday photo.png", "https://i.kek.sh/holiday%20photo.png"},
+		{`{"key": "xK3a9", "size": 4}`, "xK3a9", "https://i.kek.sh/xK3a9"},
+	}
+	for _, tc := range tests {
+		srv := newTextServer(t, tc.response)
+		c := &Client{Endpoints: map[int]string{KekSh: srv.URL}}
+		res, err := c.UploadReaderContext(context.Background(), KekSh, strings.NewReader("\x89PNG\r\n\x1a\n"), "holiday photo.png")
+		if err != nil || !res.Status {
+			t.Fatalf("%s: upload failed: %v", tc.response, err)
+		}
+		if res.RemoteFilename != tc.remote || res.FullURL != tc.url {
+			t.Errorf("%s: got %q at %q, want %q at %q", tc.response, res.RemoteFilename, res.FullURL, tc.remote, tc.url)
+		}
+	}
+}
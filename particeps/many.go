@@ -0,0 +1,155 @@
+package particeps
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ProviderResult is the outcome of uploading a file to one of several providers
+type ProviderResult struct {
+	Provider int
+	Filename string
+	// RenamedTo is the name the file was uploaded under, when UploadDir had to rename it to avoid a collision
+	RenamedTo string
+	Response  UniversalResponse
+	Err       error
+}
+
+// failed reports whether the upload didn't go through
+func (r ProviderResult) failed() bool {
+	return r.Err != nil || !r.Response.Status
+}
+
+// UploadToMany uploads the given file to every provider in the list concurrently.
+// Results are returned in the same order as providers.
+func UploadToMany(providers []int, filename string, opts ...Option) ([]ProviderResult, error) {
+	return DefaultClient.UploadToManyContext(context.Background(), providers, filename, opts...)
+}
+
+// UploadToManyContext uploads the given file to every provider in the list concurrently.
+// The error is only set when WithAbortOnFirstError is used, in which case it is the first failure.
+func (c *Client) UploadToManyContext(ctx context.Context, providers []int, filename string, opts ...Option) ([]ProviderResult, error) {
+	batch := newBatch(ctx, opts)
+	defer batch.cancel()
+	results := make([]ProviderResult, len(providers))
+	var wg sync.WaitGroup
+	for i, provider := range providers {
+		wg.Add(1)
+		go func(i, provider int) {
+			defer wg.Done()
+			res, err := c.UploadContext(batch.ctx, provider, filename, opts...)
+			results[i] = ProviderResult{Provider: provider, Filename: filename, Response: res, Err: err}
+			batch.done(results[i])
+		}(i, provider)
+	}
+	wg.Wait()
+	return results, batch.err()
+}
+
+// ProviderURL is where one provider serves an uploaded file
+type ProviderURL struct {
+	Provider int
+	URL      string
+}
+
+// MirroredResponse bundles the outcome of uploading one file to several providers
+type MirroredResponse struct {
+	// Mirrors holds the URL of every successful upload, in the order the providers were given
+	Mirrors []ProviderURL
+	// Results holds every upload's outcome, including failures
+	Results []ProviderResult
+}
+
+// newMirroredResponse collects the successful uploads among results
+func newMirroredResponse(results []ProviderResult) MirroredResponse {
+	mirrored := MirroredResponse{Results: results}
+	for _, res := range results {
+		if !res.failed() {
+			mirrored.Mirrors = append(mirrored.Mirrors, ProviderURL{Provider: res.Provider, URL: res.Response.FullURL})
+		}
+	}
+	return mirrored
+}
+
+// UploadMirrored is like UploadToMany, but also bundles the URL of every successful upload
+func UploadMirrored(providers []int, filename string, opts ...Option) (MirroredResponse, error) {
+	return DefaultClient.UploadMirroredContext(context.Background(), providers, filename, opts...)
+}
+
+// UploadMirroredContext is like UploadToManyContext, but also bundles the URL of every successful upload
+func (c *Client) UploadMirroredContext(ctx context.Context, providers []int, filename string, opts ...Option) (MirroredResponse, error) {
+	results, err := c.UploadToManyContext(ctx, providers, filename, opts...)
+	return newMirroredResponse(results), err
+}
+
+// batch tracks a set of concurrent uploads, cancelling the remaining ones on the first failure
+// when WithAbortOnFirstError is used
+type batch struct {
+	ctx         context.Context
+	cancel      context.CancelFunc
+	abortOnFail bool
+
+	mu       sync.Mutex
+	firstErr error
+}
+
+func newBatch(ctx context.Context, opts []Option) *batch {
+	ctx, cancel := context.WithCancel(ctx)
+	return &batch{ctx: ctx, cancel: cancel, abortOnFail: newUploadOptions(opts).abortOnFirstError}
+}
+
+// done records a finished upload
+func (b *batch) done(res ProviderResult) {
+	if !b.abortOnFail || !res.failed() {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.firstErr != nil {
+		return
+	}
+	b.firstErr = res.Err
+	if b.firstErr == nil {
+		b.firstErr = fmt.Errorf("particeps: upload of %q failed", res.Filename)
+	}
+	b.cancel()
+}
+
+// err returns the failure that aborted the batch, if any
+func (b *batch) err() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.firstErr
+}
+
+// UploadReaderToManyContext uploads the contents of r to every provider in the list.
+// Since r can only be read once, its contents are first buffered, in memory or in the Client's TempDir
+// depending on MaxMemoryBuffer. Temporary files are removed before returning.
+func (c *Client) UploadReaderToManyContext(ctx context.Context, providers []int, r io.Reader, filename string) ([]ProviderResult, error) {
+	buffered, err := c.spool(r)
+	if err != nil {
+		return nil, err
+	}
+	defer buffered.close()
+
+	results := make([]ProviderResult, len(providers))
+	var wg sync.WaitGroup
+	for i, provider := range providers {
+		wg.Add(1)
+		go func(i, provider int) {
+			defer wg.Done()
+			results[i] = ProviderResult{Provider: provider, Filename: filename}
+			body, err := buffered.open()
+			if err != nil {
+				results[i].Err = err
+				return
+			}
+			defer body.Close()
+			results[i].Response, results[i].Err = c.UploadReaderContext(ctx, provider, body, filename)
+		}(i, provider)
+	}
+	wg.Wait()
+	return results, nil
+}
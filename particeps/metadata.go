@@ -0,0 +1,113 @@
+package particeps
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+)
+
+var errMalformedImage = errors.New("particeps: malformed image")
+
+// stripMetadata removes EXIF, XMP, IPTC and text metadata from JPEG and PNG uploads when the Client's
+// StripMetadata is set. Images are recognised by their first bytes, whatever their name says. Image data
+// is left untouched, so there is no loss of quality; other files pass through.
+func (c *Client) stripMetadata(u *uploadRequest) error {
+	if !c.StripMetadata {
+		return nil
+	}
+	size := readerSize(u.r)
+	head := make([]byte, len(pngSignature))
+	n, err := io.ReadFull(u.r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	head = head[:n]
+	u.r = withSize(io.MultiReader(bytes.NewReader(head), u.r), size)
+	isJPEG, isPNG := bytes.HasPrefix(head, jpegSignature), bytes.HasPrefix(head, pngSignature)
+	if !isJPEG && !isPNG {
+		return nil
+	}
+
+	data, err := ioutil.ReadAll(u.r)
+	if err != nil {
+		return err
+	}
+	if isJPEG {
+		data, err = stripJPEG(data)
+	} else {
+		data, err = stripPNG(data)
+	}
+	if err != nil {
+		return err
+	}
+	u.r = bytes.NewReader(data)
+	return nil
+}
+
+// jpegSignature starts every JPEG file: the start of image marker, followed by that of the first segment
+var jpegSignature = []byte{0xFF, 0xD8, 0xFF}
+
+// stripJPEG drops the APP1 (EXIF, XMP), APP13 (IPTC) and comment segments of a JPEG.
+// The ICC profile in APP2 is kept so colours render the same.
+func stripJPEG(data []byte) ([]byte, error) {
+	if len(data) < 2 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, errMalformedImage
+	}
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:2])
+	i := 2
+	for i+4 <= len(data) {
+		if data[i] != 0xFF {
+			return nil, errMalformedImage
+		}
+		marker := data[i+1]
+		if marker == 0xDA { // Start of scan: the rest is image data
+			break
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, errMalformedImage
+		}
+		if marker != 0xE1 && marker != 0xED && marker != 0xFE {
+			out.Write(data[i:end])
+		}
+		i = end
+	}
+	out.Write(data[i:])
+	return out.Bytes(), nil
+}
+
+// pngSignature starts every PNG file
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// strippedPNGChunks are the PNG chunk types holding metadata
+var strippedPNGChunks = map[string]bool{"eXIf": true, "tEXt": true, "zTXt": true, "iTXt": true, "tIME": true}
+
+// stripPNG drops the metadata chunks of a PNG
+func stripPNG(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, errMalformedImage
+	}
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(pngSignature)
+	i := len(pngSignature)
+	for i < len(data) {
+		if i+8 > len(data) {
+			return nil, errMalformedImage
+		}
+		length := int(binary.BigEndian.Uint32(data[i : i+4]))
+		chunkType := string(data[i+4 : i+8])
+		end := i + 12 + length // length, type, data and CRC
+		if length < 0 || end > len(data) {
+			return nil, errMalformedImage
+		}
+		if !strippedPNGChunks[chunkType] {
+			out.Write(data[i:end])
+		}
+		i = end
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,95 @@
+package particeps
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// exifMarker stands for the GPS position a photo's EXIF data would leak
+const exifMarker = "GPS 48.8584N 2.2945E"
+
+func testImage() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for i := range img.Pix {
+		img.Pix[i] = byte(i * 7)
+	}
+	return img
+}
+
+// jpegWithEXIF returns a JPEG and the same JPEG with an APP1 EXIF segment added after its start of image marker
+func jpegWithEXIF(t *testing.T) (clean, tagged []byte) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(), nil); err != nil {
+		t.Fatal(err)
+	}
+	clean = buf.Bytes()
+	payload := append([]byte("Exif\x00\x00"), exifMarker...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	tagged = append(append(append([]byte{}, clean[:2]...), append(segment, payload...)...), clean[2:]...)
+	return clean, tagged
+}
+
+// pngWithText returns a PNG and the same PNG with a tEXt chunk added after its IHDR chunk
+func pngWithText(t *testing.T) (clean, tagged []byte) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage()); err != nil {
+		t.Fatal(err)
+	}
+	clean = buf.Bytes()
+	data := append([]byte("Comment\x00"), exifMarker...)
+	chunk := make([]byte, 8, 12+len(data))
+	binary.BigEndian.PutUint32(chunk, uint32(len(data)))
+	copy(chunk[4:], "tEXt")
+	chunk = append(chunk, data...)
+	crc := make([]byte, 4)
+	binary.BigEndian.PutUint32(crc, crc32.ChecksumIEEE(chunk[4:]))
+	chunk = append(chunk, crc...)
+	ihdrEnd := len(pngSignature) + 25
+	tagged = append(append(append([]byte{}, clean[:ihdrEnd]...), chunk...), clean[ihdrEnd:]...)
+	return clean, tagged
+}
+
+func uploadStripped(t *testing.T, filename string, data []byte) []byte {
+	srv := newTextServer(t, "https://ttm.sh/abc")
+	c := &Client{Endpoints: map[int]string{TtmSh: srv.URL}, StripMetadata: true}
+	if _, err := c.UploadReaderContext(context.Background(), TtmSh, bytes.NewReader(data), filename); err != nil {
+		t.Fatal(err)
+	}
+	return srv.received()[0].Body
+}
+
+func TestStripMetadata(t *testing.T) {
+	cleanJPEG, taggedJPEG := jpegWithEXIF(t)
+	cleanPNG, taggedPNG := pngWithText(t)
+	for _, tt := range []struct {
+		name, filename string
+		tagged, clean  []byte
+	}{
+		{"jpeg", "photo.jpg", taggedJPEG, cleanJPEG},
+		{"png", "shot.png", taggedPNG, cleanPNG},
+		{"jpeg named as png", "photo.png", taggedJPEG, cleanJPEG},
+		{"png without extension", "shot", taggedPNG, cleanPNG},
+	} {
+		body := uploadStripped(t, tt.filename, tt.tagged)
+		if bytes.Contains(body, []byte(exifMarker)) {
+			t.Errorf("%s: metadata was uploaded", tt.name)
+		}
+		if !bytes.Contains(body, tt.clean) {
+			t.Errorf("%s: image data was changed", tt.name)
+		}
+	}
+}
+
+func TestStripMetadataPassesOtherFiles(t *testing.T) {
+	data := []byte("not an image at all, " + exifMarker)
+	if body := uploadStripped(t, "photo.jpg", data); !bytes.Contains(body, data) {
+		t.Fatal("a non-image named like a JPEG wasn't passed through unchanged")
+	}
+}
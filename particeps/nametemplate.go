@@ -0,0 +1,75 @@
+package particeps
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrTemplateNeedsSeek is returned when a filename template uses {hash} but the upload's reader can't be rewound
+var ErrTemplateNeedsSeek = errors.New("particeps: {hash} in a filename template needs a seekable reader")
+
+// filenameTemplate returns the template that applies to an upload to provider, if any
+func (c *Client) filenameTemplate(provider int, o uploadOptions) string {
+	if o.filenameTemplate != "" {
+		return o.filenameTemplate
+	}
+	return c.FilenameTemplates[provider]
+}
+
+// expandFilenameTemplate derives a remote filename from tmpl. It supports
+//
+//	{name}  the original name without its extension
+//	{slug}  {name} lowercased, with runs of spaces and punctuation turned into single dashes
+//	{ext}   the original extension, including its dot
+//	{date}  the upload's UTC time, as 20060102-150405
+//	{hash}  the first 12 hex digits of the content's SHA-256
+//
+// Path separators in the result are replaced with dashes.
+// digest is the content's hex SHA-256 if already known, so that r needn't be hashed.
+func expandFilenameTemplate(tmpl, original string, r io.Reader, digest string, now time.Time) (string, error) {
+	original = remoteFilename(original)
+	ext := filepath.Ext(original)
+	name := strings.TrimSuffix(original, ext)
+	var hash string
+	if strings.Contains(tmpl, "{hash}") {
+		if digest == "" {
+			seeker, ok := r.(io.ReadSeeker)
+			if !ok {
+				return "", ErrTemplateNeedsSeek
+			}
+			var err error
+			if digest, err = hashFile(seeker); err != nil {
+				return "", err
+			}
+		}
+		hash = digest[:12]
+	}
+	expanded := strings.NewReplacer(
+		"{name}", name,
+		"{slug}", slugify(name),
+		"{ext}", ext,
+		"{date}", now.UTC().Format("20060102-150405"),
+		"{hash}", hash,
+	).Replace(tmpl)
+	return strings.NewReplacer("/", "-", `\`, "-").Replace(expanded), nil
+}
+
+// slugify lowercases s and collapses everything but letters and digits into single dashes
+func slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToLower(r))
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
@@ -0,0 +1,48 @@
+package particeps
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestFilenameTemplate(t *testing.T) {
+	srv := newTextServer(t, "https://transfer.sh/abc/file")
+	c := &Client{
+		Endpoints:         map[int]string{TransferSh: srv.URL},
+		FilenameTemplates: map[int]string{TransferSh: "{date}-{slug}{ext}"},
+		Clock:             newFakeClock(),
+	}
+	path := writeTestFile(t, "My  Holiday, Photo!.JPG", []byte("hello"))
+
+	if _, err := c.UploadContext(context.Background(), TransferSh, path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.UploadContext(context.Background(), TransferSh, path, WithFilenameTemplate("../{hash}/{name}{ext}")); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"/20210101-000000-my-holiday-photo.JPG", "/..-2cf24dba5fb0-My  Holiday, Photo!.JPG"}
+	got := srv.received()
+	if len(got) != len(want) {
+		t.Fatalf("got %d requests, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Path != want[i] {
+			t.Errorf("upload %d sent as %q, want %q", i, got[i].Path, want[i])
+		}
+	}
+}
+
+func TestFilenameTemplateHashNeedsSeek(t *testing.T) {
+	srv := newTextServer(t, "https://transfer.sh/abc/file")
+	c := &Client{Endpoints: map[int]string{TransferSh: srv.URL}}
+	r := ioutil.NopCloser(strings.NewReader("hello"))
+	if _, err := c.UploadReaderContext(context.Background(), TransferSh, r, "a.txt", WithFilenameTemplate("{hash}{ext}")); !errors.Is(err, ErrTemplateNeedsSeek) {
+		t.Fatalf("err = %v, want ErrTemplateNeedsSeek", err)
+	}
+	if n := len(srv.received()); n != 0 {
+		t.Fatalf("%d requests sent", n)
+	}
+}
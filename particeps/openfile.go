@@ -0,0 +1,53 @@
+package particeps
+
+import (
+	"fmt"
+	"os"
+)
+
+// statFile stats a file to upload, refusing anything but regular files. Symlinks are followed
+// only when ResolveSymlinks is set, in which case the returned name is the target's.
+func (c *Client) statFile(filename string) (os.FileInfo, string, error) {
+	fsys := c.fileSystem()
+	info, err := fsys.Lstat(filename)
+	if err != nil {
+		return nil, "", err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		if !c.ResolveSymlinks {
+			return nil, "", fmt.Errorf("%w: %s is a symlink", ErrNotRegularFile, filename)
+		}
+		if filename, err = fsys.EvalSymlinks(filename); err != nil {
+			return nil, "", err
+		}
+		if info, err = fsys.Stat(filename); err != nil {
+			return nil, "", err
+		}
+	}
+	if !info.Mode().IsRegular() {
+		return nil, "", fmt.Errorf("%w: %s is %s", ErrNotRegularFile, filename, info.Mode()&os.ModeType)
+	}
+	return info, filename, nil
+}
+
+// openFile opens a file to upload, checking it with statFile before opening it,
+// since opening a named pipe would block
+func (c *Client) openFile(filename string) (File, string, error) {
+	_, filename, err := c.statFile(filename)
+	if err != nil {
+		return nil, "", err
+	}
+	f, err := c.fileSystem().Open(filename)
+	if err != nil {
+		return nil, "", err
+	}
+	// The path may have been swapped for something else since the Lstat
+	if info, err := f.Stat(); err != nil || !info.Mode().IsRegular() {
+		f.Close()
+		if err == nil {
+			err = fmt.Errorf("%w: %s is %s", ErrNotRegularFile, filename, info.Mode()&os.ModeType)
+		}
+		return nil, "", err
+	}
+	return f, filename, nil
+}
@@ -0,0 +1,74 @@
+package particeps
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSymlinkUploads(t *testing.T) {
+	srv := newTextServer(t, "https://transfer.sh/abc/target.txt")
+	target := writeTestFile(t, "target.txt", []byte("hello"))
+	link := filepath.Join(tempDir(t), "link.txt")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("can't create symlinks: %v", err)
+	}
+	c := &Client{Endpoints: map[int]string{TransferSh: srv.URL}}
+
+	if _, err := c.UploadContext(context.Background(), TransferSh, link); !errors.Is(err, ErrNotRegularFile) {
+		t.Fatalf("err = %v, want ErrNotRegularFile without ResolveSymlinks", err)
+	}
+	if len(srv.received()) != 0 {
+		t.Fatal("the symlink was uploaded without ResolveSymlinks")
+	}
+
+	c.ResolveSymlinks = true
+	if _, err := c.UploadContext(context.Background(), TransferSh, link); err != nil {
+		t.Fatal(err)
+	}
+	if req := srv.received()[0]; req.Path != "/target.txt" || string(req.Body) != "hello" {
+		t.Fatalf("sent %s with %q, want the target under its own name", req.Path, req.Body)
+	}
+}
+
+func TestCheckFileSymlinks(t *testing.T) {
+	target := writeTestFile(t, "target.txt", []byte("hello"))
+	link := filepath.Join(tempDir(t), "link.txt")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("can't create symlinks: %v", err)
+	}
+	useDefaultClient(t, &Client{})
+	if _, err := CheckFile(link); !errors.Is(err, ErrNotRegularFile) {
+		t.Fatalf("CheckFile: err = %v, want ErrNotRegularFile without ResolveSymlinks", err)
+	}
+	if _, _, err := CheckFileContext(context.Background(), link); !errors.Is(err, ErrNotRegularFile) {
+		t.Fatalf("CheckFileContext: err = %v, want ErrNotRegularFile without ResolveSymlinks", err)
+	}
+
+	useDefaultClient(t, &Client{ResolveSymlinks: true})
+	if pretty, err := CheckFile(link); err != nil || pretty != "5 B" {
+		t.Fatalf("CheckFile: got %s, %v, want the target's 5 B", pretty, err)
+	}
+	if size, _, err := CheckFileContext(context.Background(), link); err != nil || size != 5 {
+		t.Fatalf("CheckFileContext: got %d, %v, want the target's 5 bytes", size, err)
+	}
+}
+
+func TestDeviceRefused(t *testing.T) {
+	if _, err := os.Stat(os.DevNull); err != nil || os.DevNull != "/dev/null" {
+		t.Skip("no /dev/null")
+	}
+	srv := newTextServer(t, "https://ttm.sh/abc.txt")
+	c := &Client{Endpoints: map[int]string{TtmSh: srv.URL}}
+	if _, err := c.UploadContext(context.Background(), TtmSh, os.DevNull); !errors.Is(err, ErrNotRegularFile) {
+		t.Fatalf("err = %v, want ErrNotRegularFile", err)
+	}
+	if _, err := CheckFile(os.DevNull); !errors.Is(err, ErrNotRegularFile) {
+		t.Fatalf("CheckFile: err = %v, want ErrNotRegularFile", err)
+	}
+	if len(srv.received()) != 0 {
+		t.Fatal("a device was uploaded")
+	}
+}
@@ -0,0 +1,39 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package particeps
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestFIFORefused(t *testing.T) {
+	fifo := filepath.Join(tempDir(t), "pipe")
+	if err := syscall.Mkfifo(fifo, 0644); err != nil {
+		t.Skipf("can't create a FIFO: %v", err)
+	}
+	srv := newTextServer(t, "https://ttm.sh/abc.txt")
+	c := &Client{Endpoints: map[int]string{TtmSh: srv.URL}}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.UploadContext(context.Background(), TtmSh, fifo)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrNotRegularFile) {
+			t.Fatalf("err = %v, want ErrNotRegularFile", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("uploading a FIFO blocked")
+	}
+	if len(srv.received()) != 0 {
+		t.Fatal("a FIFO was uploaded")
+	}
+}
@@ -0,0 +1,272 @@
+package particeps
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+)
+
+// Option configures a single upload
+type Option func(*uploadOptions)
+
+// uploadOptions holds the per-call settings applied by Options
+type uploadOptions struct {
+	remoteName       string
+	filenameTemplate string
+	endpoint         string
+	debug            io.Writer
+	contentType      string
+	timeout          time.Duration
+	deadline         time.Time
+	gzip             bool
+	password         string
+	expectContinue   bool
+	expiry           time.Duration
+	expiresAt        time.Time
+	progress         func(Progress)
+	progressInterval time.Duration
+
+	idempotent     bool
+	idempotencyKey string
+
+	abortOnFirstError bool
+	renameCollisions  bool
+	dedupe            bool
+	baseDir           string
+
+	labels map[string]string
+
+	preferredProviders []int
+
+	sha256       string
+	verifySHA256 bool
+}
+
+func newUploadOptions(opts []Option) uploadOptions {
+	var o uploadOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// hasDeadline reports whether WithTimeout or WithDeadline was given
+func (o uploadOptions) hasDeadline() bool {
+	return o.timeout > 0 || !o.deadline.IsZero()
+}
+
+// context derives the context of a single upload from ctx, applying WithTimeout and WithDeadline
+func (o uploadOptions) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	cancel := func() {}
+	if !o.deadline.IsZero() {
+		ctx, cancel = context.WithDeadline(ctx, o.deadline)
+	}
+	if o.timeout > 0 {
+		parentCancel := cancel
+		var timeoutCancel context.CancelFunc
+		ctx, timeoutCancel = context.WithTimeout(ctx, o.timeout)
+		cancel = func() {
+			timeoutCancel()
+			parentCancel()
+		}
+	}
+	return ctx, cancel
+}
+
+// WithRemoteName uploads the file under the given name instead of the local file's name.
+// Any directory components in name are dropped.
+func WithRemoteName(name string) Option {
+	return func(o *uploadOptions) {
+		o.remoteName = name
+	}
+}
+
+// WithFilenameTemplate derives the remote filename from tmpl, e.g. "{date}-{slug}{ext}".
+// It overrides the Client's FilenameTemplates; see Client.FilenameTemplates for the supported placeholders.
+func WithFilenameTemplate(tmpl string) Option {
+	return func(o *uploadOptions) {
+		o.filenameTemplate = tmpl
+	}
+}
+
+// WithEndpoint sends this upload to url instead of the provider's usual endpoint, e.g. a regional mirror.
+// The response is still parsed as the provider's.
+func WithEndpoint(url string) Option {
+	return func(o *uploadOptions) {
+		o.endpoint = url
+	}
+}
+
+// WithDebug writes a trace of this upload's HTTP requests and responses to w, without their bodies. The provider's
+// EndpointHeaders and the headers added by its RequestSigner are redacted along with the usual credentials.
+// See DebugRoundTripper.
+func WithDebug(w io.Writer) Option {
+	return func(o *uploadOptions) {
+		o.debug = w
+	}
+}
+
+// WithContentType sends the file with the given Content-Type instead of the detected one,
+// for providers that take the file as the raw request body
+func WithContentType(contentType string) Option {
+	return func(o *uploadOptions) {
+		o.contentType = contentType
+	}
+}
+
+// WithTimeout gives this upload d to complete, in place of the HTTPClient's Timeout,
+// e.g. to allow a large file more time than the Client's default
+func WithTimeout(d time.Duration) Option {
+	return func(o *uploadOptions) {
+		o.timeout = d
+	}
+}
+
+// WithDeadline makes this upload fail if it hasn't completed by t, in place of the HTTPClient's Timeout
+func WithDeadline(t time.Time) Option {
+	return func(o *uploadOptions) {
+		o.deadline = t
+	}
+}
+
+// WithGzip uploads the file gzip-compressed, with ".gz" appended to its name.
+// Data that looks already compressed, such as JPEG or MP4 files, is sent as is; see UniversalResponse.Compressed.
+func WithGzip() Option {
+	return func(o *uploadOptions) {
+		o.gzip = true
+	}
+}
+
+// WithPassword protects the upload with a password, for providers whose Capabilities include Password.
+// Other providers fail with ErrPasswordUnsupported.
+func WithPassword(password string) Option {
+	return func(o *uploadOptions) {
+		o.password = password
+	}
+}
+
+// WithExpiry asks the provider to delete the upload once d has passed, for providers whose Capabilities
+// include SetExpiry. Providers that count expiry in whole days round it up.
+func WithExpiry(d time.Duration) Option {
+	return func(o *uploadOptions) {
+		o.expiry, o.expiresAt = d, time.Time{}
+	}
+}
+
+// WithExpiresAt asks the provider to delete the upload at t, e.g. at the end of the week.
+// It is the absolute form of WithExpiry; whichever of the two is given last wins.
+func WithExpiresAt(t time.Time) Option {
+	return func(o *uploadOptions) {
+		o.expiry, o.expiresAt = 0, t
+	}
+}
+
+// WithExpectContinue sends the upload with an "Expect: 100-continue" header, so the provider can refuse it,
+// e.g. as too large or unauthorized, before the file is sent. The transport must wait for the provider's answer:
+// http.DefaultTransport, which Clients use unless given an HTTPClient, waits up to its ExpectContinueTimeout of
+// a second before sending the file anyway, while transports whose ExpectContinueTimeout is zero don't wait.
+func WithExpectContinue() Option {
+	return func(o *uploadOptions) {
+		o.expectContinue = true
+	}
+}
+
+// WithLabels attaches labels, e.g. {"project": "thesis"}, to the upload for the caller's own bookkeeping.
+// They are never sent to the provider; they are copied into UniversalResponse.Labels and reports.
+// Labels given by several WithLabels are merged.
+func WithLabels(labels map[string]string) Option {
+	return func(o *uploadOptions) {
+		if o.labels == nil {
+			o.labels = make(map[string]string, len(labels))
+		}
+		for key, value := range labels {
+			o.labels[key] = value
+		}
+	}
+}
+
+// WithSHA256 gives the hex SHA-256 of the file's contents, when the caller already knows it, so that it isn't
+// computed again to look the file up in the Client's CacheDir, to expand {hash} in filename templates or to
+// report UniversalResponse.SHA256. Add WithVerifySHA256 to check it against the contents sent.
+func WithSHA256(digest string) Option {
+	return func(o *uploadOptions) {
+		o.sha256 = strings.ToLower(digest)
+	}
+}
+
+// WithVerifySHA256 hashes the contents as they are sent and fails with ErrDigestMismatch if they don't match
+// the digest given by WithSHA256. The upload has gone through by then, so its URL is still reported.
+func WithVerifySHA256() Option {
+	return func(o *uploadOptions) {
+		o.verifySHA256 = true
+	}
+}
+
+// WithPreferredProviders makes UploadAuto try the given providers first, in this order, when they are among
+// its eligible candidates. The Client's AutoProviders are left as they are.
+func WithPreferredProviders(providers ...int) Option {
+	return func(o *uploadOptions) {
+		o.preferredProviders = append(o.preferredProviders, providers...)
+	}
+}
+
+// WithProgress calls report as the file is read for uploading, with the bytes sent so far,
+// the transfer rate and the estimated time remaining
+func WithProgress(report func(Progress)) Option {
+	return func(o *uploadOptions) {
+		o.progress = report
+	}
+}
+
+// WithProgressInterval calls the WithProgress callback at most once every d, plus once when the file has been
+// read in full. It defaults to DefaultProgressInterval; a negative d reports every read.
+func WithProgressInterval(d time.Duration) Option {
+	return func(o *uploadOptions) {
+		o.progressInterval = d
+	}
+}
+
+// WithIdempotencyKey sends key in an Idempotency-Key header with every request of this upload, so providers that
+// honor it don't store a second copy when a request is retried. An empty key is replaced by a random UUID.
+// The key used is reported in UniversalResponse.IdempotencyKey; pass it again when retrying the whole upload.
+// Chunked uploads send it when opening their session, and keys derived from it, such as key+"-part-3", with the
+// parts and the completion.
+func WithIdempotencyKey(key string) Option {
+	return func(o *uploadOptions) {
+		o.idempotent = true
+		o.idempotencyKey = key
+	}
+}
+
+// WithAbortOnFirstError makes batch uploads such as UploadDir and UploadToMany cancel the remaining uploads
+// as soon as one fails, returning the partial results along with the failure
+func WithAbortOnFirstError() Option {
+	return func(o *uploadOptions) {
+		o.abortOnFirstError = true
+	}
+}
+
+// WithBaseDir makes UploadZip store each file under its path relative to dir, preserving the directory tree,
+// instead of under its base name. Files outside dir fail the upload with ErrOutsideBaseDir.
+func WithBaseDir(dir string) Option {
+	return func(o *uploadOptions) {
+		o.baseDir = dir
+	}
+}
+
+// WithDedupe makes UploadDir upload files with identical contents only once. Every copy is reported
+// with the result of the upload of the first one.
+func WithDedupe() Option {
+	return func(o *uploadOptions) {
+		o.dedupe = true
+	}
+}
+
+// WithRenameCollisions makes UploadDir rename files whose names clash with another file's, instead of failing
+// with ErrNameCollision. The new names are reported in ProviderResult.RenamedTo.
+func WithRenameCollisions() Option {
+	return func(o *uploadOptions) {
+		o.renameCollisions = true
+	}
+}
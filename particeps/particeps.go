@@ -1,19 +1,18 @@
 package particeps
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"io/ioutil"
-	"log"
 	"math"
-	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 const (
@@ -27,51 +26,148 @@ const (
 	Filebin
 	// Imagebin is the constant for https://imagebin.ca/
 	Imagebin
+	// PutRe is the constant for https://put.re/
+	PutRe
+	// Uguu is the constant for https://uguu.se/
+	Uguu
+	// Pixeldrain is the constant for https://pixeldrain.com/
+	Pixeldrain
+	// Streamable is the constant for https://streamable.com/
+	Streamable
+	// ImgChest is the constant for https://imgchest.com/
+	ImgChest
+	// KekSh is the constant for https://kek.sh/
+	KekSh
+	// TransferSh is the constant for https://transfer.sh/
+	TransferSh
+	// TtmSh is the constant for https://ttm.sh/
+	TtmSh
+	// Gofile is the constant for https://gofile.io/
+	Gofile
+	// S3 is the constant for S3-compatible buckets, uploaded to through presigned URLs; see S3PresignedUpload
+	S3
+
+	// lastProvider is the highest built-in provider constant
+	lastProvider = iota
+)
+
+// Upload endpoints for each provider
+const (
+	anonFilesURL  = "https://api.anonfiles.com/upload"
+	bayFilesURL   = "https://api.bayfiles.com/upload"
+	filebinURL    = "https://filebin.net"
+	imagebinURL   = "https://imagebin.ca/upload.php"
+	putReURL      = "https://api.put.re/upload"
+	uguuURL       = "https://uguu.se/upload.php"
+	pixeldrainURL = "https://pixeldrain.com/api/file"
 )
 
-// CheckFile checks if the filename exists and returns its size in pretty-print form
+// ErrNotRegularFile is returned when asked to upload something other than a regular file,
+// such as a directory, a named pipe or a device, or a symlink when symlinks aren't resolved
+var ErrNotRegularFile = errors.New("particeps: not a regular file")
+
+// CheckFile checks if the filename exists and is a regular file, and returns its size in pretty-print form.
+// Symlinks are refused unless DefaultClient.ResolveSymlinks is set, just as when uploading.
 func CheckFile(filename string) (string, error) {
-	fileInfo, err := os.Stat(filename)
+	info, _, err := DefaultClient.statFile(filepath.Clean(filename)) // os takes care of long Windows paths on its own
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "particeps: error: could not find file \"%s\"\n", filename)
+		if !errors.Is(err, ErrNotRegularFile) {
+			fmt.Fprintf(os.Stderr, "particeps: error: could not find file \"%s\"\n", filename)
+		}
 		return "", err
 	}
-	return prettySize(float64(fileInfo.Size())), nil
+	return prettySize(float64(info.Size())), nil
+}
+
+// CheckFileContext is like CheckFile, but gives up with ctx.Err() if the file can't be stat'ed before ctx is done,
+// as can happen on unresponsive network mounts. It also returns the size in bytes.
+func CheckFileContext(ctx context.Context, filename string) (int64, string, error) {
+	type statResult struct {
+		info os.FileInfo
+		err  error
+	}
+	c := DefaultClient
+	done := make(chan statResult, 1) // buffered so the goroutine can finish even if nobody is waiting anymore
+	go func() {
+		info, _, err := c.statFile(filepath.Clean(filename))
+		done <- statResult{info, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return 0, "", ctx.Err()
+	case res := <-done:
+		if res.err != nil {
+			return 0, "", res.err
+		}
+		return res.info.Size(), prettySize(float64(res.info.Size())), nil
+	}
 }
 
 // ImagebinUpload uploads an image to imagebin.ca and returns an UniversalResponse with the upload's data
 func ImagebinUpload(filename string) (UniversalResponse, error) {
-	// Multi-part Body
+	return Upload(Imagebin, filename)
+}
+
+func (c *Client) imagebinUpload(u *uploadRequest) (UniversalResponse, error) {
 	var result UniversalResponse
 	result.Status = false
-	mpb := bytes.NewBuffer(nil)
-	mw := multipart.NewWriter(mpb)
-
-	partWriter, err := mw.CreateFormFile("file", filename)
+	resp, body, err := c.sendFile(u, nil, nil)
+	if resp != nil {
+		if res, ok := c.capturedRedirect(resp); ok {
+			return res, nil
+		}
+	}
 	if err != nil {
 		return result, err
 	}
 
-	fileReader, err := os.Open(filename)
-	io.Copy(partWriter, fileReader) // TODO: refactor to only call this once when uploading to several providers
-	mw.Close()
+	result.FullURL = getStringAfterWord(string(body), "url:")
+	u.parsed = result.FullURL
+	return result, nil
+}
 
-	resp, err := http.Post("https://imagebin.ca/upload.php", mw.FormDataContentType(), mpb)
-	if err != nil {
-		return result, err
+// remoteFilename returns the base name of a local path, so that only the file's name is sent to providers.
+// Both '/' and '\' are treated as separators regardless of GOOS, which also covers Windows drive and UNC paths.
+func remoteFilename(filename string) string {
+	if i := strings.LastIndexAny(filename, `/\`); i >= 0 {
+		filename = filename[i+1:]
 	}
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return result, err
+	if filename == "" || filename == "." || filename == ".." {
+		return "file"
 	}
+	return filename
+}
+
+// DefaultMaxFilenameLength is the longest filename, in bytes, sent to providers by default
+const DefaultMaxFilenameLength = 255
 
-	if result.FullURL = getStringAfterWord(string(body), "url:"); result.FullURL == "" {
-		return result, nil
+// sanitizeFilename strips control characters from a filename and shortens it to the Client's MaxFilenameLength,
+// keeping its extension and never splitting a UTF-8 sequence
+func (c *Client) sanitizeFilename(filename string) string {
+	filename = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, filename)
+	max := c.MaxFilenameLength
+	if max <= 0 {
+		max = DefaultMaxFilenameLength
 	}
-	result.Status = true
-	return result, nil
+	if len(filename) <= max {
+		return filename
+	}
+	ext := filepath.Ext(filename)
+	if len(ext) > max/2 {
+		ext = ""
+	}
+	stem := filename[:len(filename)-len(ext)]
+	cut := max - len(ext)
+	for cut > 0 && !utf8.RuneStart(stem[cut]) {
+		cut--
+	}
+	return stem[:cut] + ext
 }
 
 func getStringAfterWord(value string, word string) string {
@@ -86,31 +182,17 @@ func getStringAfterWord(value string, word string) string {
 	return value[adjustedPos:len(value)]
 }
 
-// Façade function for uploads to Anonfiles and Bayfiles
-func uploadFile(filename, destURI string) (UniversalResponse, error) {
+// anonFilesUpload sends a file to AnonFiles or BayFiles, which share the same API
+func (c *Client) anonFilesUpload(u *uploadRequest) (UniversalResponse, error) {
 	var returnValue UniversalResponse
 	returnValue.Status = false
 
-	// Multi-part Body
-	mpb := bytes.NewBuffer(nil)
-	mw := multipart.NewWriter(mpb)
-
-	partWriter, err := mw.CreateFormFile("file", filename)
-	if err != nil {
-		log.Fatalln(err) // TODO: return
+	resp, body, err := c.sendFile(u, nil, nil)
+	if resp != nil {
+		if res, ok := c.capturedRedirect(resp); ok {
+			return res, nil
+		}
 	}
-
-	fileReader, err := os.Open(filename)
-	io.Copy(partWriter, fileReader) // TODO: refactor to only call this once when uploading to several providers
-	mw.Close()
-
-	resp, err := http.Post(destURI, mw.FormDataContentType(), mpb) // then we send the multipart body with the file to http.Post
-	if err != nil {
-		return returnValue, err
-	}
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return returnValue, err
 	}
@@ -120,10 +202,25 @@ func uploadFile(filename, destURI string) (UniversalResponse, error) {
 	if err != nil {
 		return returnValue, err
 	}
+	u.parsed = &successResponse
+	if !successResponse.Status {
+		var failureResponse AnonFilesFailure
+		if json.Unmarshal(body, &failureResponse) == nil && failureResponse.Error.Message != "" {
+			u.parsed = &failureResponse
+			return returnValue, nil
+		}
+	}
 
-	returnValue.Status = successResponse.Status
 	returnValue.FullURL = successResponse.Data.File.URL.Full
 	returnValue.ShortURL = successResponse.Data.File.URL.Short
+	returnValue.StoredSize = int64(successResponse.Data.File.Metadata.Size.Bytes)
+	if returnValue.FullURL == "" {
+		if url := recoverURL(body); url != "" {
+			c.warnf("response from %s didn't match the expected schema, using %s", u.endpoint, url)
+			returnValue.FullURL = url
+			u.parsed = url
+		}
+	}
 	return returnValue, nil
 }
 
@@ -155,25 +252,28 @@ func uploadFile(filename, destURI string) (UniversalResponse, error) {
 
 // FilebinUpload uploads the given file to filebin.net and returns a UniversalResponse with status and URL
 func FilebinUpload(filename string) (UniversalResponse, error) {
+	return Upload(Filebin, filename)
+}
+
+func (c *Client) filebinUpload(u *uploadRequest) (UniversalResponse, error) {
 	var returnValue UniversalResponse
 	returnValue.Status = false
-	f, err := os.Open(filename)
-	if err != nil {
-		return returnValue, err
-	}
-	defer f.Close()
-	req, err := http.NewRequest("POST", "https://filebin.net", f)
-	if err != nil {
-		return returnValue, err
+	contentType := u.opts.contentType
+	if contentType == "" {
+		size := readerSize(u.r)
+		detected, r, err := sniffContentType(u.r, u.filename)
+		if err != nil {
+			return returnValue, err
+		}
+		contentType, u.r = detected, withSize(r, size)
 	}
-	req.Header.Set("Filename", filepath.Base(filename))
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return returnValue, err
+	header := http.Header{"Filename": {u.filename}, "Content-Type": {contentType}, "Accept": {"application/json"}}
+	resp, body, err := c.sendFile(u, nil, header)
+	if resp != nil {
+		if res, ok := c.capturedRedirect(resp); ok {
+			return res, nil
+		}
 	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return returnValue, err
 	}
@@ -182,10 +282,21 @@ func FilebinUpload(filename string) (UniversalResponse, error) {
 	if err != nil {
 		return returnValue, err
 	}
+	u.parsed = &successResponse
 
-	if successResponse.Links[1].Href != "" {
+	if len(successResponse.Links) > 1 {
 		returnValue.FullURL = successResponse.Links[1].Href
-		returnValue.Status = true
+	}
+	returnValue.StoredSize = int64(successResponse.Bytes)
+	returnValue.Bin = successResponse.Bin
+	returnValue.BinLocked = successResponse.BinInfo.Readonly
+	returnValue.ExpiresAt = successResponse.BinInfo.ExpiredAt
+	if returnValue.FullURL == "" {
+		if url := recoverURL(body); url != "" {
+			c.warnf("response from %s didn't match the expected schema, using %s", u.endpoint, url)
+			returnValue.FullURL = url
+			u.parsed = url
+		}
 	}
 
 	return returnValue, nil
@@ -193,12 +304,12 @@ func FilebinUpload(filename string) (UniversalResponse, error) {
 
 // BayFilesUpload attemps to upload a file to AnonFiles and returns a success/failure string
 func BayFilesUpload(filename string) (UniversalResponse, error) {
-	return uploadFile(filename, "https://api.bayfiles.com/upload")
+	return Upload(BayFiles, filename)
 }
 
 // AnonFilesUpload attemps to upload a file to AnonFiles and returns a success/failure string
 func AnonFilesUpload(filename string) (UniversalResponse, error) {
-	return uploadFile(filename, "https://api.anonfiles.com/upload")
+	return Upload(AnonFiles, filename)
 }
 
 func round(val float64, roundOn float64, places int) (newVal float64) {
@@ -215,10 +326,18 @@ func round(val float64, roundOn float64, places int) (newVal float64) {
 	return
 }
 
+// PrettySize formats a size in bytes in human-readable form, e.g. "3.82 MB"
+func PrettySize(sizeInBytes int64) string {
+	return prettySize(float64(sizeInBytes))
+}
+
 func prettySize(sizeInBytes float64) string {
-	suffixes := [4]string{"B", "KB", "MB", "GB"}
-	base := math.Log(sizeInBytes) / math.Log(1024)
+	if sizeInBytes < 1 {
+		return "0 B"
+	}
+	suffixes := [5]string{"B", "KB", "MB", "GB", "TB"}
+	base := math.Min(math.Log(sizeInBytes)/math.Log(1024), float64(len(suffixes)-1))
 	size := round(math.Pow(1024, base-math.Floor(base)), .5, 2)
 	suffix := suffixes[int(math.Floor(base))]
 	return strconv.FormatFloat(size, 'f', -1, 64) + " " + string(suffix)
-}
\ No newline at end of file
+}
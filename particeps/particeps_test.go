@@ -0,0 +1,150 @@
+package particeps
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// filebinServer mocks Filebin, answering with the number of bytes it received and recording the requests
+func filebinServer(t *testing.T) (*httptest.Server, func() []recordedRequest) {
+	var mu sync.Mutex
+	var requests []recordedRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, recordedRequest{r.Method, r.URL.Path, r.Header.Clone(), data, r.ContentLength})
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"filename": %q, "bin": {"id": "bin1"}, "bytes": %d, "links": [{"rel": "bin", "href": "https://filebin.net/bin1"}, {"rel": "file", "href": "https://filebin.net/bin1/%s"}]}`,
+			r.Header.Get("Filename"), len(data), r.Header.Get("Filename"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []recordedRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]recordedRequest(nil), requests...)
+	}
+}
+
+func TestFilebinHeaders(t *testing.T) {
+	srv, requests := filebinServer(t)
+	c := &Client{Endpoints: map[int]string{Filebin: srv.URL}}
+	binary := make([]byte, 256)
+	for i := range binary {
+		binary[i] = byte(i)
+	}
+	png := append([]byte("\x89PNG\r\n\x1a\n"), binary...)
+
+	for _, tt := range []struct {
+		filename    string
+		data        []byte
+		opts        []Option
+		contentType string
+	}{
+		{"photo.png", png, nil, "image/png"},
+		{"blob", binary, nil, "application/octet-stream"},
+		{"unnamed", png, nil, "image/png"},
+		{"notes.txt", []byte("hello"), []Option{WithContentType("text/markdown")}, "text/markdown"},
+	} {
+		res, err := c.UploadReaderContext(context.Background(), Filebin, bytes.NewReader(tt.data), tt.filename, tt.opts...)
+		if err != nil || res.FullURL != "https://filebin.net/bin1/"+tt.filename {
+			t.Errorf("%s: got %+v, %v", tt.filename, res, err)
+			continue
+		}
+		got := requests()[len(requests())-1]
+		if ct := got.Header.Get("Content-Type"); ct != tt.contentType {
+			t.Errorf("%s: Content-Type = %q, want %q", tt.filename, ct, tt.contentType)
+		}
+		if accept := got.Header.Get("Accept"); accept != "application/json" {
+			t.Errorf("%s: Accept = %q", tt.filename, accept)
+		}
+		if !bytes.Equal(got.Body, tt.data) {
+			t.Errorf("%s: the body didn't arrive byte for byte", tt.filename)
+		}
+	}
+}
+
+// stallingFS is the OS FileSystem, except that Lstat blocks until release is closed
+type stallingFS struct {
+	OSFileSystem
+	release chan struct{}
+}
+
+func (fs stallingFS) Lstat(name string) (os.FileInfo, error) {
+	<-fs.release
+	return fs.OSFileSystem.Lstat(name)
+}
+
+func TestCheckFileContext(t *testing.T) {
+	path := writeTestFile(t, "notes.txt", []byte("hello"))
+	fs := stallingFS{release: make(chan struct{})}
+	useDefaultClient(t, &Client{FS: fs})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, _, err := CheckFileContext(ctx, path); err != context.DeadlineExceeded {
+		t.Fatalf("err = %v, want the deadline exceeded while Stat is stuck", err)
+	}
+
+	close(fs.release)
+	size, pretty, err := CheckFileContext(context.Background(), path)
+	if err != nil || size != 5 || pretty != "5 B" {
+		t.Fatalf("got %d (%s), %v, want 5 bytes", size, pretty, err)
+	}
+	if _, _, err := CheckFileContext(context.Background(), tempDir(t)); !errors.Is(err, ErrNotRegularFile) {
+		t.Fatalf("err = %v for a directory, want ErrNotRegularFile", err)
+	}
+}
+
+func TestFilebinSuccessBin(t *testing.T) {
+	for _, body := range []string{`{"bin": "bin1"}`, `{"bin": {"id": "bin1", "readonly": true}}`} {
+		var success FilebinSuccess
+		if err := json.Unmarshal([]byte(body), &success); err != nil {
+			t.Fatal(err)
+		}
+		if success.Bin != "bin1" || success.BinInfo.ID != "bin1" {
+			t.Errorf("%s: got Bin %q and BinInfo %+v, want both to hold bin1", body, success.Bin, success.BinInfo)
+		}
+	}
+}
+
+func TestFilebinBinLifecycle(t *testing.T) {
+	path := writeTestFile(t, "notes.txt", []byte("hello"))
+	// Captured from filebin.net, trimmed
+	captured := `{"bin": {"id": "x7k2pq9m", "readonly": true, "bytes": 5, "bytes_readable": "5 B", "files": 1,
+		"updated_at": "2024-01-02T15:04:05.123456Z", "created_at": "2024-01-02T15:04:05.123456Z",
+		"expired_at": "2024-01-09T15:04:05.123456Z"},
+		"file": {"filename": "notes.txt", "content-type": "text/plain; charset=utf-8", "bytes": 5},
+		"filename": "notes.txt", "bytes": 5, "mime": "text/plain; charset=utf-8",
+		"links": [{"rel": "bin", "href": "https://filebin.net/x7k2pq9m"}, {"rel": "file", "href": "https://filebin.net/x7k2pq9m/notes.txt"}]}`
+	srv := newTextServer(t, captured)
+	c := &Client{Endpoints: map[int]string{Filebin: srv.URL}}
+
+	res, err := c.UploadContext(context.Background(), Filebin, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expires := time.Date(2024, 1, 9, 15, 4, 5, 123456000, time.UTC)
+	if res.Bin != "x7k2pq9m" || !res.BinLocked || !res.ExpiresAt.Equal(expires) {
+		t.Fatalf("got bin %q, locked %v, expiring %v, want x7k2pq9m, locked, expiring %v", res.Bin, res.BinLocked, res.ExpiresAt, expires)
+	}
+
+	// Older versions only give the bin's id
+	srv = newTextServer(t, strings.Replace(filebinResponse, `{"id": "bin1", "readonly": false, "expired_at": "2030-01-01T00:00:00Z"}`, `"bin1"`, 1))
+	c.Endpoints[Filebin] = srv.URL
+	res, err = c.UploadContext(context.Background(), Filebin, path)
+	if err != nil || res.Bin != "bin1" || res.BinLocked || !res.ExpiresAt.IsZero() {
+		t.Fatalf("got %+v, %v, want bin1 with no lock or expiry", res, err)
+	}
+}
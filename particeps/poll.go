@@ -0,0 +1,73 @@
+package particeps
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// DefaultPollInterval and DefaultPollTimeout are used when Client.PollInterval and Client.PollTimeout are zero
+	DefaultPollInterval = 5 * time.Second
+	DefaultPollTimeout  = 10 * time.Minute
+	// pollBackoff is how much the wait between polls grows after each one, up to maxPollBackoff times the interval
+	pollBackoff    = 1.5
+	maxPollBackoff = 6
+)
+
+// pollCheck checks once whether a pending resource is ready
+type pollCheck func() (done bool, result UniversalResponse, err error)
+
+// pollUntil calls check until it reports done or fails, waiting interval between the first polls and
+// backing off from there. It gives up with context.DeadlineExceeded once timeout has elapsed on clock.
+func pollUntil(ctx context.Context, clock Clock, interval, timeout time.Duration, check pollCheck) (UniversalResponse, error) {
+	deadline := clock.Now().Add(timeout)
+	wait := interval
+	for {
+		done, result, err := check()
+		if err != nil || done {
+			return result, err
+		}
+		remaining := deadline.Sub(clock.Now())
+		expired := wait >= remaining
+		if expired {
+			wait = remaining
+		}
+		if err := sleep(ctx, clock, wait); err != nil {
+			return result, err
+		}
+		if expired {
+			return result, context.DeadlineExceeded
+		}
+		if wait = time.Duration(float64(wait) * pollBackoff); wait > maxPollBackoff*interval {
+			wait = maxPollBackoff * interval
+		}
+	}
+}
+
+// sleep waits d on clock, returning ctx's error early if ctx is done first.
+// An abandoned Sleep finishes in the background.
+func sleep(ctx context.Context, clock Clock, d time.Duration) error {
+	slept := make(chan struct{})
+	go func() {
+		clock.Sleep(d)
+		close(slept)
+	}()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-slept:
+		return nil
+	}
+}
+
+// poll is pollUntil with the Client's PollInterval and PollTimeout
+func (c *Client) poll(ctx context.Context, check pollCheck) (UniversalResponse, error) {
+	interval, timeout := c.PollInterval, c.PollTimeout
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	if timeout <= 0 {
+		timeout = DefaultPollTimeout
+	}
+	return pollUntil(ctx, c.clock(), interval, timeout, check)
+}
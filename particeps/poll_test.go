@@ -0,0 +1,82 @@
+package particeps
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPollUntil(t *testing.T) {
+	clock := newFakeClock()
+	start := clock.now
+	var polledAt []time.Duration
+	check := func(readyAfter int) pollCheck {
+		polledAt = nil
+		return func() (bool, UniversalResponse, error) {
+			polledAt = append(polledAt, clock.Now().Sub(start))
+			if len(polledAt) < readyAfter {
+				return false, UniversalResponse{}, nil
+			}
+			return true, UniversalResponse{Status: true, FullURL: "https://example.com/ready"}, nil
+		}
+	}
+
+	res, err := pollUntil(context.Background(), clock, time.Second, time.Minute, check(4))
+	if err != nil || res.FullURL != "https://example.com/ready" {
+		t.Fatalf("got %+v, %v", res, err)
+	}
+	want := []time.Duration{0, time.Second, 2500 * time.Millisecond, 4750 * time.Millisecond}
+	if len(polledAt) != len(want) {
+		t.Fatalf("polled at %v, want %v", polledAt, want)
+	}
+	for i := range want {
+		if polledAt[i] != want[i] {
+			t.Fatalf("polled at %v, want %v", polledAt, want)
+		}
+	}
+
+	if want := []time.Duration{time.Second, 1500 * time.Millisecond, 2250 * time.Millisecond}; !reflect.DeepEqual(clock.slept, want) {
+		t.Fatalf("slept %v, want %v", clock.slept, want)
+	}
+
+	start = clock.now
+	if _, err := pollUntil(context.Background(), clock, time.Second, 5*time.Second, check(100)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if len(polledAt) != 4 || clock.now.Sub(start) != 5*time.Second {
+		t.Fatalf("polled at %v and gave up after %s, want 4 polls over 5s", polledAt, clock.now.Sub(start))
+	}
+}
+
+func TestPollUntilBackoffIsCapped(t *testing.T) {
+	clock := newFakeClock()
+	var last time.Time
+	var longest time.Duration
+	polls := 0
+	pollUntil(context.Background(), clock, time.Second, time.Hour, func() (bool, UniversalResponse, error) {
+		if now := clock.Now(); !last.IsZero() && now.Sub(last) > longest {
+			longest = now.Sub(last)
+		}
+		last = clock.now
+		polls++
+		return polls == 20, UniversalResponse{}, nil
+	})
+	if longest != maxPollBackoff*time.Second {
+		t.Fatalf("longest wait = %s, want %s", longest, maxPollBackoff*time.Second)
+	}
+}
+
+func TestPollUntilCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	polls := 0
+	_, err := pollUntil(ctx, realClock{}, time.Hour, time.Hour, func() (bool, UniversalResponse, error) {
+		polls++
+		return false, UniversalResponse{}, nil
+	})
+	if !errors.Is(err, context.Canceled) || polls != 1 {
+		t.Fatalf("err = %v after %d polls, want context.Canceled after 1", err, polls)
+	}
+}
@@ -0,0 +1,87 @@
+package particeps
+
+import (
+	"io"
+	"time"
+)
+
+// DefaultProgressInterval is the shortest time between two progress reports, see WithProgressInterval
+const DefaultProgressInterval = 100 * time.Millisecond
+
+const (
+	// rateSampleInterval is the shortest time over which the transfer rate is sampled
+	rateSampleInterval = 250 * time.Millisecond
+	// rateSmoothing is the weight of the latest sample in the rolling transfer rate; lower is smoother
+	rateSmoothing = 0.3
+)
+
+// Progress reports how far along an upload is, see WithProgress
+type Progress struct {
+	Sent  int64 // Bytes read from the file so far
+	Total int64 // Size of the file, or -1 if unknown
+	// BytesPerSecond is a rolling average of the recent transfer rate
+	BytesPerSecond float64
+	// ETA is the estimated time remaining, or zero when it can't be estimated yet
+	ETA time.Duration
+}
+
+// progressReader reports the progress of reading r
+type progressReader struct {
+	r        io.Reader
+	report   func(Progress)
+	progress Progress
+	interval time.Duration
+	clock    Clock
+
+	lastSample time.Time
+	lastSent   int64
+
+	lastReport time.Time
+	reported   int64 // Sent at the last report, or -1 before the first
+}
+
+func newProgressReader(r io.Reader, total int64, report func(Progress), interval time.Duration, clock Clock) *progressReader {
+	if interval == 0 {
+		interval = DefaultProgressInterval
+	}
+	now := clock.Now()
+	return &progressReader{
+		r: r, report: report, progress: Progress{Total: total}, interval: interval, clock: clock,
+		lastSample: now, lastReport: now, reported: -1,
+	}
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	now := p.clock.Now()
+	if n > 0 {
+		p.progress.Sent += int64(n)
+		p.sample(now)
+	}
+	done := err == io.EOF || (p.progress.Total >= 0 && p.progress.Sent >= p.progress.Total)
+	if p.progress.Sent != p.reported && (done || now.Sub(p.lastReport) >= p.interval) {
+		p.lastReport, p.reported = now, p.progress.Sent
+		p.report(p.progress)
+	}
+	return n, err
+}
+
+// sample folds the rate since the last sample into the rolling average and updates the ETA
+func (p *progressReader) sample(now time.Time) {
+	elapsed := now.Sub(p.lastSample)
+	if elapsed < rateSampleInterval {
+		return
+	}
+	rate := float64(p.progress.Sent-p.lastSent) / elapsed.Seconds()
+	if p.progress.BytesPerSecond == 0 {
+		p.progress.BytesPerSecond = rate
+	} else {
+		p.progress.BytesPerSecond = rateSmoothing*rate + (1-rateSmoothing)*p.progress.BytesPerSecond
+	}
+	p.lastSample, p.lastSent = now, p.progress.Sent
+
+	p.progress.ETA = 0
+	if remaining := p.progress.Total - p.progress.Sent; p.progress.Total >= 0 && p.progress.BytesPerSecond > 0 {
+		p.progress.ETA = time.Duration(float64(remaining) / p.progress.BytesPerSecond * float64(time.Second))
+	}
+}
@@ -0,0 +1,132 @@
+package particeps
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// feedProgress reads len(steps) chunks through a progressReader, advancing the clock before each;
+// steps[i] is the size of chunk i and every chunk takes step to arrive. It returns the report after each chunk.
+func feedProgress(steps []int, step time.Duration) []Progress {
+	total := 0
+	for _, n := range steps {
+		total += n
+	}
+	clock := newFakeClock()
+	var last Progress
+	p := newProgressReader(strings.NewReader(strings.Repeat("x", total)), int64(total), func(pr Progress) {
+		last = pr
+	}, -1, clock)
+	reports := make([]Progress, len(steps))
+	for i, n := range steps {
+		clock.advance(step)
+		p.Read(make([]byte, n))
+		reports[i] = last
+	}
+	return reports
+}
+
+func TestProgressETA(t *testing.T) {
+	// 1000 bytes every 500ms is 2000 B/s, leaving 5s for the second half of 20000 bytes
+	steps := make([]int, 20)
+	for i := range steps {
+		steps[i] = 1000
+	}
+	reports := feedProgress(steps, 500*time.Millisecond)
+	half := reports[9]
+	if half.Sent != 10000 || half.BytesPerSecond != 2000 || half.ETA != 5*time.Second {
+		t.Fatalf("halfway: %+v, want 10000 bytes sent at 2000 B/s, 5s left", half)
+	}
+	if last := reports[len(reports)-1]; last.Sent != 20000 || last.ETA != 0 {
+		t.Fatalf("at the end: %+v, want everything sent and no time left", last)
+	}
+}
+
+func TestProgressRateIsSmoothed(t *testing.T) {
+	// Bursts of 4000 bytes alternate with trickles of 400, averaging 4400 B/s, one chunk every 500ms
+	steps := make([]int, 40)
+	for i := range steps {
+		steps[i] = 400
+		if i%2 == 0 {
+			steps[i] = 4000
+		}
+	}
+	reports := feedProgress(steps, 500*time.Millisecond)
+	lowest, highest := reports[10].BytesPerSecond, reports[10].BytesPerSecond
+	for _, pr := range reports[10:] {
+		if pr.BytesPerSecond < lowest {
+			lowest = pr.BytesPerSecond
+		}
+		if pr.BytesPerSecond > highest {
+			highest = pr.BytesPerSecond
+		}
+	}
+	// The raw rate swings between 800 and 8000 B/s
+	if lowest < 2500 || highest > 6500 {
+		t.Fatalf("rate swings between %.0f and %.0f B/s, want it to stay near 4400", lowest, highest)
+	}
+	pr := reports[29]
+	if eta := time.Duration(float64(pr.Total-pr.Sent) / 4400 * float64(time.Second)); pr.ETA < eta/2 || pr.ETA > eta*2 {
+		t.Fatalf("ETA = %s with %d bytes left, want about %s", pr.ETA, pr.Total-pr.Sent, eta)
+	}
+}
+
+func TestWithProgress(t *testing.T) {
+	srv := newTextServer(t, "https://ttm.sh/abc.txt")
+	c := &Client{Endpoints: map[int]string{TtmSh: srv.URL}}
+	path := writeTestFile(t, "notes.txt", []byte(strings.Repeat("particeps ", 10000)))
+	var reports []Progress
+	_, err := c.UploadContext(context.Background(), TtmSh, path, WithProgress(func(pr Progress) {
+		reports = append(reports, pr)
+	}), WithProgressInterval(-1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) == 0 {
+		t.Fatal("no progress reported")
+	}
+	for i, pr := range reports {
+		if pr.Total != 100000 || i > 0 && pr.Sent <= reports[i-1].Sent {
+			t.Fatalf("report %d is %+v, want the sent bytes of a 100000 byte file growing", i, pr)
+		}
+	}
+	if last := reports[len(reports)-1]; last.Sent != 100000 {
+		t.Fatalf("last report at %d bytes, want 100000", last.Sent)
+	}
+}
+
+func TestProgressInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, 250 * time.Millisecond} {
+		want := interval
+		if want == 0 {
+			want = DefaultProgressInterval
+		}
+		clock := newFakeClock()
+		var at []time.Time
+		var last Progress
+		p := newProgressReader(strings.NewReader(strings.Repeat("x", 1000)), 1000, func(pr Progress) {
+			at = append(at, clock.Now())
+			last = pr
+		}, interval, clock)
+
+		// A read of 10 bytes every 30ms, 3s in all
+		buf := make([]byte, 10)
+		for i := 0; i < 100; i++ {
+			clock.advance(30 * time.Millisecond)
+			p.Read(buf)
+		}
+		if last.Sent != 1000 {
+			t.Fatalf("interval %s: last report at %d bytes, want the final one at 1000", want, last.Sent)
+		}
+		for i := 1; i < len(at)-1; i++ {
+			if gap := at[i].Sub(at[i-1]); gap < want {
+				t.Fatalf("interval %s: reports %d and %d only %s apart", want, i-1, i, gap)
+			}
+		}
+		if expected := int(3 * time.Second / want); len(at) < expected*2/3 || len(at) > expected+1 {
+			t.Fatalf("interval %s: %d reports over 3s, want about %d", want, len(at), expected)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package particeps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Capabilities describes what a provider's API supports
+type Capabilities struct {
+	MultiFile bool // Several files can be sent as parts of a single request
+	Password  bool // Uploads can be password protected, see WithPassword
+	Expires   bool // Uploads are deleted after some time
+	SetExpiry bool // Uploads can be given an expiry, see WithExpiry and WithExpiresAt
+	Chunked   bool // Files are sent in parts, several at once, see Client.ChunkParallelism
+	// MaxExpiry is the furthest expiry the provider accepts, or zero if unknown or unlimited
+	MaxExpiry time.Duration
+	// MaxFileSize is the largest file, in bytes, the provider accepts, or zero if unknown or unlimited
+	MaxFileSize int64
+	// MaxVideoSize and MaxVideoDuration bound videos, for providers that take them under other limits than
+	// the rest of the files. Zero if the provider has no separate limit. Longer videos fail with ErrVideoTooLong
+	// before anything is sent, for MP4 and QuickTime videos whose duration can be read up front.
+	MaxVideoSize     int64
+	MaxVideoDuration time.Duration
+}
+
+// maxSize returns the largest file of any kind the provider accepts, or zero if unknown or unlimited
+func (caps Capabilities) maxSize() int64 {
+	if caps.MaxFileSize > 0 && caps.MaxVideoSize > caps.MaxFileSize {
+		return caps.MaxVideoSize
+	}
+	return caps.MaxFileSize
+}
+
+// namedReader is a file's contents along with the name it is uploaded under
+type namedReader struct {
+	r        io.Reader
+	filename string
+}
+
+// providerDef holds everything the package needs to know to upload to a provider
+type providerDef struct {
+	name     string
+	endpoint string // Default upload endpoint
+	caps     Capabilities
+	shape    requestShape // How the file is sent, for upload functions using Client.sendFile
+	// mediaTypes are the prefixes of the media types the provider takes, e.g. "image/". Any type if empty.
+	mediaTypes []string
+	// success decides whether an upload went through. Defaults to httpSuccess.
+	success successFunc
+	// upload sends a single file
+	upload func(c *Client, u *uploadRequest) (UniversalResponse, error)
+	// uploadMany sends several files in one request. Only set when caps.MultiFile is true.
+	uploadMany func(c *Client, ctx context.Context, endpoint string, files []namedReader) ([]UniversalResponse, error)
+	// delete removes an upload, given what MarshalDeleteToken kept of it. Nil if the provider can't delete uploads.
+	delete func(c *Client, u *uploadRequest, t deleteToken) error
+}
+
+// providers maps each provider constant to its definition
+var providers = map[int]*providerDef{
+	AnonFiles: {
+		name:     "AnonFiles",
+		endpoint: anonFilesURL,
+		caps:     Capabilities{MaxFileSize: 20 << 30},
+		upload:   (*Client).anonFilesUpload,
+		success:  anonFilesSuccess,
+	},
+	BayFiles: {
+		name:     "BayFiles",
+		endpoint: bayFilesURL,
+		caps:     Capabilities{MaxFileSize: 20 << 30},
+		upload:   (*Client).anonFilesUpload,
+		success:  anonFilesSuccess,
+	},
+	Filebin: {
+		name:     "Filebin",
+		endpoint: filebinURL,
+		caps:     Capabilities{Expires: true},
+		shape:    requestShape{raw: true},
+		upload:   (*Client).filebinUpload,
+		success:  filebinSuccess,
+		delete:   (*Client).filebinDelete,
+	},
+	Imgur: {
+		name:       "Imgur",
+		endpoint:   imgurURL,
+		caps:       Capabilities{MaxFileSize: imgurMaxImageSize, MaxVideoSize: imgurMaxVideoSize, MaxVideoDuration: imgurMaxVideoDuration},
+		shape:      requestShape{field: "image"},
+		mediaTypes: []string{"image/", "video/"},
+		upload:     (*Client).imgurUpload,
+		success:    imgurSuccess,
+		delete:     (*Client).imgurDelete,
+	},
+	Imagebin: {
+		name:     "Imagebin",
+		endpoint: imagebinURL,
+		upload:   (*Client).imagebinUpload,
+		success:  urlSuccess,
+	},
+	PutRe: SimpleJSONProvider{Name: "put.re", URL: putReURL, Field: "file", JSONPath: "data.link"}.def(),
+	Uguu: SimpleJSONProvider{
+		Name: "Uguu", URL: uguuURL, Field: "files[]", JSONPath: "files.0.url",
+		Capabilities: Capabilities{Expires: true, MaxFileSize: 128 << 20},
+	}.def(),
+	Pixeldrain: SimpleJSONProvider{
+		Name: "Pixeldrain", URL: pixeldrainURL, Method: "PUT", Raw: true, FilenameInPath: true, JSONPath: "id",
+		URLTemplate:  "https://pixeldrain.com/u/{token}",
+		Capabilities: Capabilities{MaxFileSize: 20 << 30},
+	}.def(),
+	Streamable: {
+		name:       "Streamable",
+		endpoint:   streamableURL,
+		caps:       Capabilities{MaxFileSize: 250 << 20},
+		mediaTypes: []string{"video/"},
+		upload:     (*Client).streamableUpload,
+		success:    streamableSuccess,
+	},
+	ImgChest: {
+		name:       "imgchest",
+		endpoint:   imgChestURL,
+		mediaTypes: []string{"image/"},
+		upload:     (*Client).imgChestUpload,
+		success:    imgChestSuccess,
+	},
+	KekSh: {
+		name:       "kek.sh",
+		endpoint:   kekShURL,
+		mediaTypes: []string{"image/"},
+		upload:     (*Client).kekShUpload,
+		success:    kekShSuccess,
+	},
+	TransferSh: {
+		name:       "transfer.sh",
+		endpoint:   transferShURL,
+		caps:       Capabilities{MultiFile: true, Expires: true, SetExpiry: true, MaxExpiry: 14 * 24 * time.Hour, MaxFileSize: 10 << 30},
+		shape:      requestShape{method: "PUT", raw: true, filenameInPath: true},
+		upload:     (*Client).transferShUpload,
+		uploadMany: (*Client).transferShUploadMany,
+		success:    urlSuccess,
+		delete:     (*Client).transferShDelete,
+	},
+	TtmSh: {
+		name:     "ttm.sh",
+		endpoint: ttmShURL,
+		shape:    requestShape{raw: true},
+		upload:   (*Client).plainTextUpload,
+		success:  urlSuccess,
+	},
+	Gofile: TwoPhaseProvider{
+		Name: "Gofile", URL: gofileURL, InitMethod: "GET",
+		TokenPath: "data.servers.0.name", UploadURL: "https://{token}.gofile.io/contents/uploadfile",
+		Method: "POST", Field: "file", JSONPath: "data.downloadPage",
+	}.def(),
+	S3: {
+		name:    "S3",
+		shape:   requestShape{method: "PUT", raw: true},
+		upload:  (*Client).s3Upload,
+		success: urlSuccess,
+	},
+}
+
+var (
+	providersMu sync.RWMutex
+	// nextProvider is the constant handed to the next provider registered at runtime
+	nextProvider = lastProvider + 1
+)
+
+// registerProvider adds def to the registry and returns its newly assigned constant
+func registerProvider(def *providerDef) int {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	provider := nextProvider
+	nextProvider++
+	providers[provider] = def
+	return provider
+}
+
+// ErrUnknownProvider is returned when a provider constant doesn't match any built-in or registered provider
+var ErrUnknownProvider = errors.New("particeps: unknown provider")
+
+// validProvider reports whether p is a built-in or registered provider.
+// The caller must hold providersMu.
+func validProvider(p int) bool {
+	if p <= 0 || p >= nextProvider {
+		return false
+	}
+	_, ok := providers[p]
+	return ok
+}
+
+// isSuccess runs the provider's success check
+func (def *providerDef) isSuccess(resp *http.Response, parsed interface{}) (bool, error) {
+	if def.success == nil {
+		return httpSuccess(resp, parsed)
+	}
+	return def.success(resp, parsed)
+}
+
+// lookupProvider returns the definition of the given provider
+func lookupProvider(provider int) (*providerDef, error) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	if !validProvider(provider) {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownProvider, provider)
+	}
+	return providers[provider], nil
+}
+
+// ProviderDetails describes a built-in or registered provider
+type ProviderDetails struct {
+	Provider     int
+	Name         string
+	Endpoint     string // Default upload endpoint
+	Capabilities Capabilities
+}
+
+// ProviderInfo returns the details of the given provider
+func ProviderInfo(provider int) (ProviderDetails, error) {
+	def, err := lookupProvider(provider)
+	if err != nil {
+		return ProviderDetails{}, err
+	}
+	return ProviderDetails{Provider: provider, Name: def.name, Endpoint: def.endpoint, Capabilities: def.caps}, nil
+}
+
+// ProviderCapabilities returns what the given provider supports
+func ProviderCapabilities(provider int) Capabilities {
+	if def, err := lookupProvider(provider); err == nil {
+		return def.caps
+	}
+	return Capabilities{}
+}
+
+// ProvidersMatching returns the constants of the registered providers whose capabilities satisfy filter, in order
+func ProvidersMatching(filter func(Capabilities) bool) []int {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	var matching []int
+	for provider, def := range providers {
+		if filter(def.caps) {
+			matching = append(matching, provider)
+		}
+	}
+	sort.Ints(matching)
+	return matching
+}
+
+// UploadFiles uploads several files to the given provider and returns one response per file, in order.
+// Providers able to take several files per request receive them all at once; the others get one request per file.
+func UploadFiles(provider int, filenames []string) ([]UniversalResponse, error) {
+	return DefaultClient.UploadFilesContext(context.Background(), provider, filenames)
+}
+
+// UploadFilesContext uploads several files to the given provider and returns one response per file, in order
+func (c *Client) UploadFilesContext(ctx context.Context, provider int, filenames []string) ([]UniversalResponse, error) {
+	def, err := lookupProvider(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	if !def.caps.MultiFile || def.uploadMany == nil {
+		results := make([]UniversalResponse, 0, len(filenames))
+		for _, filename := range filenames {
+			res, err := c.UploadContext(ctx, provider, filename)
+			if err != nil {
+				return results, err
+			}
+			results = append(results, res)
+		}
+		return results, nil
+	}
+
+	ctx, done, err := c.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	files := make([]namedReader, 0, len(filenames))
+	for _, filename := range filenames {
+		f, filename, err := c.openFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		files = append(files, namedReader{r: f, filename: c.sanitizeFilename(remoteFilename(filename))})
+	}
+	return def.uploadMany(c, ctx, c.endpoint(provider, def, uploadOptions{}), files)
+}
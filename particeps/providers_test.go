@@ -0,0 +1,177 @@
+package particeps
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// multiFileServer mocks transfer.sh's multi-file form, answering with one link per file part, and records
+// the name and contents of the parts of each request
+func multiFileServer(t *testing.T) (*httptest.Server, func() [][2]string, func() int) {
+	var mu sync.Mutex
+	var parts [][2]string
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("not a multipart form: %v", err)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		for _, fh := range r.MultipartForm.File["filedata"] {
+			f, _ := fh.Open()
+			data, _ := ioutil.ReadAll(f)
+			f.Close()
+			parts = append(parts, [2]string{fh.Filename, string(data)})
+			w.Write([]byte("https://transfer.sh/tok/" + fh.Filename + "\n"))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() [][2]string {
+			mu.Lock()
+			defer mu.Unlock()
+			return append([][2]string(nil), parts...)
+		}, func() int {
+			mu.Lock()
+			defer mu.Unlock()
+			return requests
+		}
+}
+
+func TestUploadFilesInOneRequest(t *testing.T) {
+	srv, parts, requests := multiFileServer(t)
+	c := &Client{Endpoints: map[int]string{TransferSh: srv.URL}}
+	files := []string{
+		writeTestFile(t, "a.txt", []byte("first")),
+		writeTestFile(t, "b.txt", []byte("second")),
+		writeTestFile(t, "c.txt", []byte("third")),
+	}
+
+	responses, err := c.UploadFilesContext(context.Background(), TransferSh, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests() != 1 {
+		t.Fatalf("sent %d requests, want all files in one", requests())
+	}
+	want := [][2]string{{"a.txt", "first"}, {"b.txt", "second"}, {"c.txt", "third"}}
+	got := parts()
+	if len(got) != len(want) || len(responses) != len(want) {
+		t.Fatalf("got parts %v and %d responses, want %v", got, len(responses), want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("part %d = %v, want %v", i, got[i], want[i])
+		}
+		if url := "https://transfer.sh/tok/" + want[i][0]; responses[i].FullURL != url || !responses[i].Status {
+			t.Errorf("response %d = %+v, want %s", i, responses[i], url)
+		}
+	}
+}
+
+func TestUploadFilesSequentialFallback(t *testing.T) {
+	srv := newTextServer(t, "https://ttm.sh/abc.txt")
+	c := &Client{Endpoints: map[int]string{TtmSh: srv.URL}}
+	files := []string{writeTestFile(t, "a.txt", []byte("first")), writeTestFile(t, "b.txt", []byte("second"))}
+
+	responses, err := c.UploadFilesContext(context.Background(), TtmSh, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := srv.received()
+	if len(responses) != 2 || len(got) != 2 || string(got[0].Body) != "first" || string(got[1].Body) != "second" {
+		t.Fatalf("want one request per file, in order, got %d responses and %d requests", len(responses), len(got))
+	}
+}
+
+func TestProviderRange(t *testing.T) {
+	registered := RegisterSimpleJSON("range-check", "https://files.example/", "file", "url")
+	providersMu.RLock()
+	beyond := nextProvider
+	providersMu.RUnlock()
+
+	for _, tt := range []struct {
+		provider int
+		valid    bool
+	}{
+		{0, false},
+		{-1, false},
+		{beyond, false},
+		{beyond + 100, false},
+		{AnonFiles, true},
+		{TransferSh, true},
+		{lastProvider, true},
+		{registered, true},
+	} {
+		if got := validProvider(tt.provider); got != tt.valid {
+			t.Errorf("validProvider(%d) = %v, want %v", tt.provider, got, tt.valid)
+		}
+		_, infoErr := ProviderInfo(tt.provider)
+		if tt.valid {
+			if infoErr != nil {
+				t.Errorf("ProviderInfo(%d): %v", tt.provider, infoErr)
+			}
+			continue
+		}
+		_, uploadErr := (&Client{}).UploadReaderContext(context.Background(), tt.provider, strings.NewReader("x"), "a.txt")
+		results, _ := (&Client{}).UploadToManyContext(context.Background(), []int{tt.provider}, writeTestFile(t, "a.txt", []byte("x")))
+		for name, err := range map[string]error{"ProviderInfo": infoErr, "UploadReaderContext": uploadErr, "UploadToManyContext": results[0].Err} {
+			if !errors.Is(err, ErrUnknownProvider) {
+				t.Errorf("%s(%d): err = %v, want ErrUnknownProvider", name, tt.provider, err)
+			}
+		}
+		if caps := ProviderCapabilities(tt.provider); caps != (Capabilities{}) {
+			t.Errorf("ProviderCapabilities(%d) = %+v, want none", tt.provider, caps)
+		}
+	}
+}
+
+func TestProvidersMatching(t *testing.T) {
+	contains := func(list []int, provider int) bool {
+		for _, p := range list {
+			if p == provider {
+				return true
+			}
+		}
+		return false
+	}
+	tests := []struct {
+		name   string
+		filter func(Capabilities) bool
+		in     []int
+		out    []int
+	}{
+		{"over 1 GB", func(caps Capabilities) bool { return caps.maxSize() > 1<<30 }, []int{AnonFiles, Pixeldrain, TransferSh}, []int{Imgur, Streamable, Filebin}},
+		{"expiry", func(caps Capabilities) bool { return caps.Expires }, []int{Filebin, TransferSh}, []int{AnonFiles, Imgur}},
+	}
+	for _, tc := range tests {
+		matching := ProvidersMatching(tc.filter)
+		for _, provider := range tc.in {
+			if !contains(matching, provider) {
+				t.Errorf("%s: %v is missing %s", tc.name, matching, providers[provider].name)
+			}
+		}
+		for _, provider := range tc.out {
+			if contains(matching, provider) {
+				t.Errorf("%s: %v includes %s", tc.name, matching, providers[provider].name)
+			}
+		}
+		for i := 1; i < len(matching); i++ {
+			if matching[i-1] >= matching[i] {
+				t.Errorf("%s: %v isn't in order", tc.name, matching)
+			}
+		}
+		for provider := AnonFiles; provider <= lastProvider; provider++ {
+			if tc.filter(ProviderCapabilities(provider)) != contains(matching, provider) {
+				t.Errorf("%s: %s disagrees with its capabilities", tc.name, providers[provider].name)
+			}
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package particeps
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+)
+
+// ErrQueueClosed is returned when enqueueing into an UploadQueue that has been closed
+var ErrQueueClosed = errors.New("particeps: upload queue is closed")
+
+// ErrJobNotFound is returned when cancelling a job that isn't pending or running, e.g. because it already finished
+var ErrJobNotFound = errors.New("particeps: no such pending or running job")
+
+// UploadJob describes a single file to be uploaded by an UploadQueue
+type UploadJob struct {
+	ID       string // Assigned by Enqueue
+	Provider int
+	Filename string
+}
+
+// UploadResult is delivered on UploadQueue.Results once a job has finished
+type UploadResult struct {
+	Job      UploadJob
+	Response UniversalResponse
+	Err      error
+}
+
+// UploadQueue uploads enqueued jobs with a fixed pool of background workers
+type UploadQueue struct {
+	// Results receives one UploadResult per enqueued job. It is closed by Close.
+	Results <-chan UploadResult
+
+	client  *Client
+	jobs    chan *queuedJob
+	results chan UploadResult
+	wg      sync.WaitGroup
+	mu      sync.RWMutex
+	closed  bool
+
+	activeMu sync.Mutex
+	active   map[string]*queuedJob // Pending and running jobs, by ID
+	lastID   uint64
+}
+
+// queuedJob is a job along with the context that cancels it
+type queuedJob struct {
+	UploadJob
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewUploadQueue starts an UploadQueue with the given number of workers, uploading through DefaultClient.
+// At most size jobs may be pending at once; Enqueue blocks while the queue is full.
+func NewUploadQueue(workers, size int) *UploadQueue {
+	return DefaultClient.NewUploadQueue(workers, size)
+}
+
+// NewUploadQueue starts an UploadQueue with the given number of workers, uploading through c.
+// At most size jobs may be pending at once; Enqueue blocks while the queue is full.
+func (c *Client) NewUploadQueue(workers, size int) *UploadQueue {
+	if workers < 1 {
+		workers = 1
+	}
+	q := &UploadQueue{
+		client:  c,
+		jobs:    make(chan *queuedJob, size),
+		results: make(chan UploadResult, size),
+		active:  map[string]*queuedJob{},
+	}
+	q.Results = q.results
+	q.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go q.work()
+	}
+	return q
+}
+
+func (q *UploadQueue) work() {
+	defer q.wg.Done()
+	for job := range q.jobs {
+		var res UniversalResponse
+		err := job.ctx.Err() // cancelled while pending
+		if err == nil {
+			res, err = q.client.UploadContext(job.ctx, job.Provider, job.Filename)
+		}
+		q.activeMu.Lock()
+		delete(q.active, job.ID)
+		q.activeMu.Unlock()
+		job.cancel()
+		q.results <- UploadResult{Job: job.UploadJob, Response: res, Err: err}
+	}
+}
+
+// Enqueue adds a job to the queue, blocking while the queue is full, and returns the ID assigned to it
+func (q *UploadQueue) Enqueue(job UploadJob) (string, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return "", ErrQueueClosed
+	}
+	queued := &queuedJob{UploadJob: job}
+	queued.ctx, queued.cancel = context.WithCancel(context.Background())
+	q.activeMu.Lock()
+	q.lastID++
+	queued.ID = strconv.FormatUint(q.lastID, 10)
+	q.active[queued.ID] = queued
+	q.activeMu.Unlock()
+	q.jobs <- queued
+	return queued.ID, nil
+}
+
+// Cancel cancels the job with the given ID. A pending job is skipped and a running one is aborted;
+// either way its UploadResult is still delivered, with a context.Canceled error.
+// It returns ErrJobNotFound if the job has already finished.
+func (q *UploadQueue) Cancel(id string) error {
+	q.activeMu.Lock()
+	job, ok := q.active[id]
+	q.activeMu.Unlock()
+	if !ok {
+		return ErrJobNotFound
+	}
+	job.cancel()
+	return nil
+}
+
+// Close stops accepting jobs, waits for the pending and in-flight ones to finish and then closes Results.
+// Results must keep being drained while Close runs.
+func (q *UploadQueue) Close() {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+	q.closed = true
+	close(q.jobs)
+	q.mu.Unlock()
+	q.wg.Wait()
+	close(q.results)
+}
@@ -0,0 +1,120 @@
+package particeps
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestUploadQueueCompletesAllJobs(t *testing.T) {
+	srv := newTextServer(t, "https://ttm.sh/abc.txt")
+	c := &Client{Endpoints: map[int]string{TtmSh: srv.URL}}
+	path := writeTestFile(t, "a.txt", []byte("hello"))
+
+	const jobs = 50
+	q := c.NewUploadQueue(4, 8)
+	results := make(chan []UploadResult)
+	go func() {
+		var got []UploadResult
+		for res := range q.Results {
+			got = append(got, res)
+		}
+		results <- got
+	}()
+	ids := map[string]bool{}
+	for i := 0; i < jobs; i++ {
+		id, err := q.Enqueue(UploadJob{Provider: TtmSh, Filename: path})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[id] = true
+	}
+	q.Close()
+
+	got := <-results
+	if len(got) != jobs || len(ids) != jobs {
+		t.Fatalf("got %d results for %d jobs with %d distinct ids", len(got), jobs, len(ids))
+	}
+	for _, res := range got {
+		if res.Err != nil || res.Response.FullURL != "https://ttm.sh/abc.txt" {
+			t.Fatalf("job %s: %v %+v", res.Job.ID, res.Err, res.Response)
+		}
+		delete(ids, res.Job.ID)
+	}
+	if len(ids) != 0 {
+		t.Fatalf("no result for jobs %v", ids)
+	}
+	if n := len(srv.received()); n != jobs {
+		t.Fatalf("provider got %d uploads, want %d", n, jobs)
+	}
+	if _, err := q.Enqueue(UploadJob{Provider: TtmSh, Filename: path}); !errors.Is(err, ErrQueueClosed) {
+		t.Fatalf("Enqueue after Close: err = %v, want ErrQueueClosed", err)
+	}
+}
+
+func TestUploadQueueBackpressure(t *testing.T) {
+	srv, arrived, release := blockingServer(t)
+	c := &Client{Endpoints: map[int]string{TtmSh: srv.URL}}
+	path := writeTestFile(t, "a.txt", []byte("hello"))
+
+	q := c.NewUploadQueue(1, 1)
+	go func() {
+		for range q.Results {
+		}
+	}()
+	q.Enqueue(UploadJob{Provider: TtmSh, Filename: path})
+	<-arrived                                             // the worker is busy
+	q.Enqueue(UploadJob{Provider: TtmSh, Filename: path}) // fills the queue
+
+	enqueued := make(chan struct{})
+	go func() {
+		q.Enqueue(UploadJob{Provider: TtmSh, Filename: path})
+		close(enqueued)
+	}()
+	select {
+	case <-enqueued:
+		t.Fatal("Enqueue didn't block on a full queue")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	<-enqueued
+	q.Close()
+}
+
+func TestUploadQueueCancel(t *testing.T) {
+	srv, arrived, release := blockingServer(t)
+	c := &Client{Endpoints: map[int]string{TtmSh: srv.URL}}
+	path := writeTestFile(t, "a.txt", []byte("hello"))
+
+	q := c.NewUploadQueue(1, 4)
+	running, _ := q.Enqueue(UploadJob{Provider: TtmSh, Filename: path})
+	<-arrived
+	pending, _ := q.Enqueue(UploadJob{Provider: TtmSh, Filename: path})
+	kept, _ := q.Enqueue(UploadJob{Provider: TtmSh, Filename: path})
+	if err := q.Cancel(pending); err != nil {
+		t.Fatalf("cancelling the pending job: %v", err)
+	}
+	if err := q.Cancel(running); err != nil {
+		t.Fatalf("cancelling the running job: %v", err)
+	}
+	close(release)
+	go q.Close()
+
+	results := map[string]error{}
+	for res := range q.Results {
+		results[res.Job.ID] = res.Err
+	}
+	if !errors.Is(results[running], context.Canceled) || !errors.Is(results[pending], context.Canceled) {
+		t.Fatalf("results = %v, want jobs %s and %s cancelled", results, running, pending)
+	}
+	if err, ok := results[kept]; !ok || err != nil {
+		t.Fatalf("job %s: err = %v, want it uploaded", kept, err)
+	}
+	if n := len(arrived); n != 1 {
+		t.Fatalf("provider got %d more uploads, want only job %s's", n, kept)
+	}
+	if err := q.Cancel(kept); !errors.Is(err, ErrJobNotFound) {
+		t.Fatalf("cancelling a finished job: err = %v, want ErrJobNotFound", err)
+	}
+}
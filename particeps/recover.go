@@ -0,0 +1,56 @@
+package particeps
+
+import (
+	"encoding/json"
+	"log"
+	"strings"
+)
+
+// urlKeys are the JSON keys most likely to hold a file's URL, best first
+var urlKeys = []string{"full", "url", "link", "download_url", "href"}
+
+// recoverURL looks through an arbitrary JSON response for something that looks like the uploaded file's URL.
+// It is the fallback for when a provider's response no longer matches the expected schema.
+func recoverURL(body []byte) string {
+	var parsed interface{}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return ""
+	}
+	for _, key := range urlKeys {
+		if url := findURL(parsed, key); url != "" {
+			return url
+		}
+	}
+	return ""
+}
+
+// findURL returns the first http(s) URL stored under key anywhere in value
+func findURL(value interface{}, key string) string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if s, ok := v[key].(string); ok && (strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "http://")) {
+			return s
+		}
+		for _, child := range v {
+			if url := findURL(child, key); url != "" {
+				return url
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if url := findURL(child, key); url != "" {
+				return url
+			}
+		}
+	}
+	return ""
+}
+
+// warnf logs a warning through the Client's Logger, or the standard logger if it has none
+func (c *Client) warnf(format string, args ...interface{}) {
+	if c.Logger != nil {
+		c.Logger.Printf("particeps: warning: "+format, args...)
+		return
+	}
+	log.Printf("particeps: warning: "+format, args...)
+}
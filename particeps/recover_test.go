@@ -0,0 +1,52 @@
+package particeps
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestRecoverURLFromDriftedResponses(t *testing.T) {
+	path := writeTestFile(t, "notes.txt", []byte("hello"))
+	tests := []struct {
+		provider int
+		body     string
+		want     string
+	}{
+		{AnonFiles, `{"status": true, "result": {"file": {"links": {"download_url": "https://anonfiles.com/abc/notes_txt"}}}}`,
+			"https://anonfiles.com/abc/notes_txt"},
+		{Filebin, `{"file": {"name": "notes.txt", "url": "https://filebin.net/bin1/notes.txt"}, "size": 5}`,
+			"https://filebin.net/bin1/notes.txt"},
+	}
+	for _, tc := range tests {
+		srv := newTextServer(t, tc.body)
+		var logged bytes.Buffer
+		c := &Client{Endpoints: map[int]string{tc.provider: srv.URL}, Logger: log.New(&logged, "", 0)}
+
+		res, err := c.UploadContext(context.Background(), tc.provider, path)
+		if err != nil || !res.Status || res.FullURL != tc.want {
+			t.Fatalf("provider %d: got %+v, %v, want %s recovered", tc.provider, res, err, tc.want)
+		}
+		if !strings.Contains(logged.String(), "didn't match the expected schema") {
+			t.Fatalf("provider %d: logged %q, want a warning about the schema", tc.provider, logged.String())
+		}
+	}
+}
+
+func TestRecoverURL(t *testing.T) {
+	tests := []struct {
+		body, want string
+	}{
+		{`{"data": {"href": "https://example.com/b", "link": "https://example.com/a"}}`, "https://example.com/a"},
+		{`[{"url": "not a url"}, {"url": "http://example.com/c"}]`, "http://example.com/c"},
+		{`{"message": "no URL here"}`, ""},
+		{`not JSON`, ""},
+	}
+	for _, tc := range tests {
+		if got := recoverURL([]byte(tc.body)); got != tc.want {
+			t.Errorf("recoverURL(%s) = %q, want %q", tc.body, got, tc.want)
+		}
+	}
+}
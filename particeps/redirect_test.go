@@ -0,0 +1,87 @@
+package particeps
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// redirectServer answers uploads with a redirect to /files/abc.txt, which serves a link once reached.
+// Requests to /hop/n redirect to /hop/n+1, endlessly.
+func redirectServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/files/abc.txt":
+			w.Write([]byte("https://ttm.sh/followed.txt"))
+		case strings.HasPrefix(r.URL.Path, "/hop/"):
+			n, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/hop/"))
+			http.Redirect(w, r, "/hop/"+strconv.Itoa(n+1), http.StatusFound)
+		default:
+			http.Redirect(w, r, "/files/abc.txt", http.StatusSeeOther)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRedirectFollowed(t *testing.T) {
+	srv := redirectServer(t)
+	c := &Client{Endpoints: map[int]string{TtmSh: srv.URL}}
+	res, err := c.UploadReaderContext(context.Background(), TtmSh, strings.NewReader("hello"), "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.FullURL != "https://ttm.sh/followed.txt" || res.Location != "" {
+		t.Fatalf("got %+v, want the link served at the redirect's target", res)
+	}
+}
+
+func TestRedirectCaptured(t *testing.T) {
+	srv := redirectServer(t)
+	c := &Client{Endpoints: map[int]string{TtmSh: srv.URL}, CaptureRedirects: true}
+	res, err := c.UploadReaderContext(context.Background(), TtmSh, strings.NewReader("hello"), "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := srv.URL + "/files/abc.txt"; res.FullURL != want || res.Location != want || !res.Status {
+		t.Fatalf("got %+v, want the redirect's Location %s", res, want)
+	}
+}
+
+func TestMaxRedirects(t *testing.T) {
+	srv := redirectServer(t)
+	c := &Client{Endpoints: map[int]string{TtmSh: srv.URL + "/hop/0"}, MaxRedirects: 3}
+	_, err := c.UploadReaderContext(context.Background(), TtmSh, strings.NewReader("hello"), "a.txt")
+	if err == nil || !strings.Contains(err.Error(), "stopped after 3 redirects") {
+		t.Fatalf("err = %v, want the upload stopped after 3 redirects", err)
+	}
+}
+
+func TestPooledBodyReplayedOnRedirect(t *testing.T) {
+	var replayed []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/final" {
+			// Answers before reading the body, as providers rejecting or moving an upload may
+			http.Redirect(w, r, "/final", http.StatusTemporaryRedirect)
+			return
+		}
+		replayed, _ = ioutil.ReadAll(r.Body)
+		w.Write([]byte(`{"filename": "a.png", "key": "a", "size": 8}`))
+	}))
+	t.Cleanup(srv.Close)
+	c := &Client{Endpoints: map[int]string{KekSh: srv.URL}}
+
+	for i := 0; i < 20; i++ {
+		res, err := c.UploadReaderContext(context.Background(), KekSh, strings.NewReader("\x89PNG\r\n\x1a\n"), "a.png")
+		if err != nil || !res.Status {
+			t.Fatalf("got %+v, %v", res, err)
+		}
+		if !strings.Contains(string(replayed), "\x89PNG\r\n\x1a\n") || !strings.HasSuffix(string(replayed), "--\r\n") {
+			t.Fatalf("the redirect's target got %q, want the whole multipart body", replayed)
+		}
+	}
+}
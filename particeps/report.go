@@ -0,0 +1,97 @@
+package particeps
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// reportRow is one upload in a report written by WriteReport
+type reportRow struct {
+	Filename string  `json:"filename"`
+	Provider string  `json:"provider"`
+	URL      string  `json:"url"`
+	Status   bool    `json:"status"`
+	Size     int64   `json:"size"`
+	Duration float64 `json:"duration"` // Seconds
+	Error    string  `json:"error,omitempty"`
+
+	Labels  map[string]string `json:"labels,omitempty"`
+	ModTime string            `json:"mod_time,omitempty"` // RFC 3339, for files uploaded from disk
+}
+
+func newReportRow(res ProviderResult) reportRow {
+	row := reportRow{
+		Filename: res.Filename,
+		Provider: strconv.Itoa(res.Provider),
+		URL:      res.Response.FullURL,
+		Status:   !res.failed(),
+		Size:     res.Response.Size,
+		Duration: res.Response.Duration.Seconds(),
+		Labels:   res.Response.Labels,
+	}
+	if info, err := ProviderInfo(res.Provider); err == nil {
+		row.Provider = info.Name
+	}
+	if res.Err != nil {
+		row.Error = res.Err.Error()
+	}
+	if !res.Response.ModTime.IsZero() {
+		row.ModTime = res.Response.ModTime.Format(time.RFC3339)
+	}
+	return row
+}
+
+// WriteReport writes a report of the given uploads to w, in "csv" or "json" format.
+// Each upload's filename, provider, URL, status, size in bytes, duration in seconds, error, labels and
+// the modification time of the uploaded file are reported. CSV reports list labels as "key=value" pairs separated by semicolons, sorted by key.
+func WriteReport(w io.Writer, results []ProviderResult, format string) error {
+	rows := make([]reportRow, len(results))
+	for i, res := range results {
+		rows[i] = newReportRow(res)
+	}
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"filename", "provider", "url", "status", "size", "duration", "error", "labels", "mod_time"})
+		for _, row := range rows {
+			cw.Write([]string{
+				row.Filename,
+				row.Provider,
+				row.URL,
+				strconv.FormatBool(row.Status),
+				strconv.FormatInt(row.Size, 10),
+				strconv.FormatFloat(row.Duration, 'f', 3, 64),
+				row.Error,
+				formatLabels(row.Labels),
+				row.ModTime,
+			})
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	return fmt.Errorf("particeps: unknown report format %q", format)
+}
+
+// formatLabels lists labels as "key=value" pairs separated by semicolons, sorted by key
+func formatLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + "=" + labels[key]
+	}
+	return strings.Join(pairs, ";")
+}
@@ -0,0 +1,108 @@
+package particeps
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// reportResults are a successful upload and a failed one, with names and errors that need escaping in CSV
+var reportResults = []ProviderResult{
+	{Provider: TtmSh, Filename: `notes, "final".txt`, Response: UniversalResponse{
+		Status: true, FullURL: "https://ttm.sh/abc.txt", Size: 5, Duration: 1500 * time.Millisecond,
+	}},
+	{Provider: Uguu, Filename: "photo.jpg", Err: errors.New("particeps: provider answered 500\nInternal Server Error")},
+}
+
+func TestWriteReportCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, reportResults, "csv"); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"filename", "provider", "url", "status", "size", "duration", "error", "labels", "mod_time"},
+		{`notes, "final".txt`, "ttm.sh", "https://ttm.sh/abc.txt", "true", "5", "1.500", "", "", ""},
+		{"photo.jpg", "Uguu", "", "false", "0", "0.000", "particeps: provider answered 500\nInternal Server Error", "", ""},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Fatalf("got %q, want %q", records, want)
+	}
+}
+
+func TestWriteReportJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, reportResults, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var rows []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &rows); err != nil {
+		t.Fatal(err)
+	}
+	want := []map[string]interface{}{
+		{"filename": `notes, "final".txt`, "provider": "ttm.sh", "url": "https://ttm.sh/abc.txt", "status": true, "size": 5.0, "duration": 1.5},
+		{"filename": "photo.jpg", "provider": "Uguu", "url": "", "status": false, "size": 0.0, "duration": 0.0,
+			"error": "particeps: provider answered 500\nInternal Server Error"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("got %v, want %v", rows, want)
+	}
+}
+
+func TestWriteReportUnknownFormat(t *testing.T) {
+	if err := WriteReport(&bytes.Buffer{}, reportResults, "xml"); err == nil {
+		t.Fatal("no error for an unknown format")
+	}
+}
+
+func TestLabelsReported(t *testing.T) {
+	srv := newTextServer(t, "https://ttm.sh/abc.txt")
+	c := &Client{Endpoints: map[int]string{TtmSh: srv.URL}}
+	path := writeTestFile(t, "notes.txt", []byte("hello"))
+
+	res, err := c.UploadContext(context.Background(), TtmSh, path,
+		WithLabels(map[string]string{"project": "thesis"}), WithLabels(map[string]string{"category": "drafts"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	labels := map[string]string{"project": "thesis", "category": "drafts"}
+	if !reflect.DeepEqual(res.Labels, labels) {
+		t.Fatalf("Labels = %v, want %v", res.Labels, labels)
+	}
+	got := srv.received()[0]
+	for name, values := range got.Header {
+		if strings.Contains(strings.Join(values, " "), "thesis") {
+			t.Errorf("label sent to the provider in %s", name)
+		}
+	}
+
+	results := []ProviderResult{{Provider: TtmSh, Filename: path, Response: res}}
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, results, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var rows []struct {
+		Labels map[string]string `json:"labels"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &rows); err != nil || len(rows) != 1 || !reflect.DeepEqual(rows[0].Labels, labels) {
+		t.Fatalf("JSON report %s, %v, want the labels %v", buf.Bytes(), err, labels)
+	}
+
+	buf.Reset()
+	if err := WriteReport(&buf, results, "csv"); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil || len(records) != 2 || records[1][7] != "category=drafts;project=thesis" {
+		t.Fatalf("CSV report %q, %v, want the labels sorted by key", records, err)
+	}
+}
@@ -0,0 +1,75 @@
+package particeps
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// requestShape is how a provider expects the file to be sent, as part of its definition
+type requestShape struct {
+	method string // HTTP method, POST if empty
+	raw    bool   // The file's bytes are the whole body, rather than a part of a multipart form
+	field  string // Form field holding the file in multipart bodies, "file" if empty
+	// filenameInPath appends the file's name to the endpoint's path
+	filenameInPath bool
+}
+
+// sendFile sends the upload's file shaped as its provider's definition describes, along with the given
+// form fields, for multipart bodies, and headers. It returns the provider's response, whose body has been
+// read into body and closed. Raw bodies are sent as the Content-Type in header, if any, or the one given
+// by WithContentType, or application/octet-stream.
+func (c *Client) sendFile(u *uploadRequest, fields map[string]string, header http.Header) (resp *http.Response, body []byte, err error) {
+	shape := u.shape
+	method := shape.method
+	if method == "" {
+		method = "POST"
+	}
+
+	var r io.Reader
+	var contentType string
+	if shape.raw {
+		r = u.r
+		contentType = header.Get("Content-Type")
+		if contentType == "" {
+			contentType = u.opts.contentType
+		}
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+	} else {
+		field := shape.field
+		if field == "" {
+			field = "file"
+		}
+		var release func()
+		if r, contentType, release, err = newMultipartFormBody(u.r, field, u.filename, fields); err != nil {
+			return nil, nil, err
+		}
+		defer release()
+	}
+
+	endpoint := u.endpoint
+	if shape.filenameInPath {
+		endpoint = strings.TrimSuffix(endpoint, "/") + "/" + url.PathEscape(u.filename)
+	}
+	req, err := http.NewRequestWithContext(u.ctx, method, endpoint, r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if size := readerSize(u.r); shape.raw && size >= 0 {
+		req.ContentLength = size
+	}
+	withPooledBody(req)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", contentType)
+	if resp, err = c.send(u, req); err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err = readBody(resp)
+	return resp, body, err
+}
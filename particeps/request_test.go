@@ -0,0 +1,50 @@
+package particeps
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"testing"
+)
+
+func TestRequestShapes(t *testing.T) {
+	srv := newTextServer(t, `{"url": "https://files.example/abc"}`)
+	multipartPost := SimpleJSONProvider{Name: "shape-multipart", URL: srv.URL + "/upload", Field: "upload", JSONPath: "url"}.Register()
+	rawPut := SimpleJSONProvider{Name: "shape-raw", URL: srv.URL + "/files", Method: "PUT", Raw: true, FilenameInPath: true, JSONPath: "url"}.Register()
+	path := writeTestFile(t, "notes.txt", []byte("hello"))
+
+	for _, provider := range []int{multipartPost, rawPut} {
+		res, err := (&Client{}).UploadContext(context.Background(), provider, path)
+		if err != nil || res.FullURL != "https://files.example/abc" {
+			t.Fatalf("provider %d: got %+v, %v", provider, res, err)
+		}
+	}
+	got := srv.received()
+	if len(got) != 2 {
+		t.Fatalf("got %d requests, want 2", len(got))
+	}
+
+	post := got[0]
+	mediaType, params, _ := mime.ParseMediaType(post.Header.Get("Content-Type"))
+	if post.Method != "POST" || post.Path != "/upload" || mediaType != "multipart/form-data" {
+		t.Fatalf("multipart provider sent %s %s as %s", post.Method, post.Path, mediaType)
+	}
+	part, err := multipart.NewReader(bytes.NewReader(post.Body), params["boundary"]).NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadAll(part)
+	if part.FormName() != "upload" || part.FileName() != "notes.txt" || string(data) != "hello" {
+		t.Fatalf("multipart provider sent field %q, file %q holding %q", part.FormName(), part.FileName(), data)
+	}
+
+	put := got[1]
+	if put.Method != "PUT" || put.Path != "/files/notes.txt" || string(put.Body) != "hello" {
+		t.Fatalf("raw provider sent %s %s holding %q", put.Method, put.Path, put.Body)
+	}
+	if put.Header.Get("Content-Type") != "application/octet-stream" || put.ContentLength != 5 {
+		t.Fatalf("raw provider sent %d bytes as %s", put.ContentLength, put.Header.Get("Content-Type"))
+	}
+}
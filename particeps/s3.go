@@ -0,0 +1,57 @@
+package particeps
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// S3PresignedUpload uploads the given file to an S3-compatible bucket, such as MinIO, through a presigned PUT URL
+// generated beforehand, and returns the object's URL: the presigned URL without its query string
+func S3PresignedUpload(presignedURL, filename string) (UniversalResponse, error) {
+	return DefaultClient.S3PresignedUploadContext(context.Background(), presignedURL, filename)
+}
+
+// S3PresignedUploadContext uploads the given file through a presigned PUT URL. The file is sent with the
+// Content-Type given by WithContentType, if the URL was signed for a specific one, or else its detected type.
+func (c *Client) S3PresignedUploadContext(ctx context.Context, presignedURL, filename string, opts ...Option) (UniversalResponse, error) {
+	return c.UploadContext(ctx, S3, filename, append(opts[:len(opts):len(opts)], WithEndpoint(presignedURL))...)
+}
+
+func (c *Client) s3Upload(u *uploadRequest) (UniversalResponse, error) {
+	var returnValue UniversalResponse
+	returnValue.Status = false
+	if u.endpoint == "" {
+		return returnValue, fmt.Errorf("particeps: S3 uploads need a presigned URL, see S3PresignedUpload")
+	}
+	object, err := url.Parse(u.endpoint)
+	if err != nil {
+		return returnValue, err
+	}
+	object.RawQuery, object.Fragment = "", ""
+
+	contentType := u.opts.contentType
+	if contentType == "" {
+		size := readerSize(u.r)
+		detected, r, err := sniffContentType(u.r, u.filename)
+		if err != nil {
+			return returnValue, err
+		}
+		contentType, u.r = detected, withSize(r, size)
+	}
+	// S3 answers a successful PUT with an empty body, so the response only matters through its status
+	resp, _, err := c.sendFile(u, nil, http.Header{"Content-Type": {contentType}})
+	if resp != nil {
+		if res, ok := c.capturedRedirect(resp); ok {
+			return res, nil
+		}
+	}
+	if err != nil {
+		return returnValue, err
+	}
+
+	u.parsed = object.String()
+	returnValue.FullURL = object.String()
+	return returnValue, nil
+}
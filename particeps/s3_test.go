@@ -0,0 +1,62 @@
+package particeps
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestS3PresignedUpload(t *testing.T) {
+	srv := newTextServer(t, "")
+	c := &Client{}
+	path := writeTestFile(t, "notes.txt", []byte("hello"))
+	presigned := srv.URL + "/bucket/notes.txt?X-Amz-Signature=abc&X-Amz-Expires=900"
+
+	res, err := c.S3PresignedUploadContext(context.Background(), presigned, path)
+	if err != nil || !res.Status {
+		t.Fatalf("upload failed: %v %+v", err, res)
+	}
+	if res.FullURL != srv.URL+"/bucket/notes.txt" {
+		t.Fatalf("FullURL = %q, want the object URL without the signature", res.FullURL)
+	}
+	got := srv.received()
+	if len(got) != 1 || got[0].Method != "PUT" || string(got[0].Body) != "hello" {
+		t.Fatalf("requests = %+v, want the file as the body of one PUT", got)
+	}
+	if ct := got[0].Header.Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+}
+
+func TestS3PresignedUploadRefused(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("<Error><Code>SignatureDoesNotMatch</Code></Error>"))
+	}))
+	defer srv.Close()
+	path := writeTestFile(t, "notes.txt", []byte("hello"))
+
+	_, err := (&Client{}).S3PresignedUploadContext(context.Background(), srv.URL+"/bucket/notes.txt?X-Amz-Signature=abc", path)
+	var status *StatusError
+	if !errors.As(err, &status) || status.StatusCode != http.StatusForbidden {
+		t.Fatalf("err = %v, want a 403 StatusError", err)
+	}
+}
+
+func TestS3PresignedUploadKeepsOptions(t *testing.T) {
+	srv := newTextServer(t, "")
+	path := writeTestFile(t, "notes.txt", []byte("hello"))
+	opts := make([]Option, 1, 2)
+	opts[0] = WithContentType("text/markdown")
+	spare := opts[:2]
+	spare[1] = WithRemoteName("kept.txt")
+
+	if _, err := (&Client{}).S3PresignedUploadContext(context.Background(), srv.URL+"/bucket/notes.txt?sig=1", path, opts...); err != nil {
+		t.Fatal(err)
+	}
+	if o := newUploadOptions(spare[1:]); o.remoteName != "kept.txt" || o.endpoint != "" {
+		t.Fatal("the caller's options were overwritten")
+	}
+}
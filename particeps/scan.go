@@ -0,0 +1,51 @@
+package particeps
+
+import "strings"
+
+// ScanResult is a provider's verdict on whether an uploaded file is safe, e.g. from a virus scan
+type ScanResult int
+
+const (
+	// ScanUnknown is for providers that don't scan uploads, or didn't report a verdict
+	ScanUnknown ScanResult = iota
+	// ScanClean means the provider found nothing wrong with the file
+	ScanClean
+	// ScanFlagged means the provider flagged the file, e.g. as malware
+	ScanFlagged
+)
+
+func (s ScanResult) String() string {
+	switch s {
+	case ScanClean:
+		return "clean"
+	case ScanFlagged:
+		return "flagged"
+	}
+	return "unknown"
+}
+
+// cleanVerdicts are the verdicts providers use for files found to be safe
+var cleanVerdicts = map[string]bool{"clean": true, "ok": true, "safe": true, "none": true, "passed": true}
+
+// parseScanVerdict interprets a verdict found in a provider's JSON response. A boolean is whether the
+// file was flagged; a string is clean if it reads like "clean", "ok" or "safe" and flagged otherwise,
+// e.g. "infected" or the name of the malware found.
+func parseScanVerdict(verdict interface{}) ScanResult {
+	switch v := verdict.(type) {
+	case bool:
+		if v {
+			return ScanFlagged
+		}
+		return ScanClean
+	case string:
+		v = strings.ToLower(strings.TrimSpace(v))
+		if v == "" {
+			return ScanUnknown
+		}
+		if cleanVerdicts[v] {
+			return ScanClean
+		}
+		return ScanFlagged
+	}
+	return ScanUnknown
+}
@@ -0,0 +1,46 @@
+package particeps
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestScanResult(t *testing.T) {
+	tests := []struct {
+		response string
+		want     ScanResult
+	}{
+		{`{"file": {"url": "https://files.example/a", "scan": {"verdict": "Win.Trojan.Agent-123"}}}`, ScanFlagged},
+		{`{"file": {"url": "https://files.example/a", "scan": {"verdict": true}}}`, ScanFlagged},
+		{`{"file": {"url": "https://files.example/a", "scan": {"verdict": " Clean "}}}`, ScanClean},
+		{`{"file": {"url": "https://files.example/a", "scan": {"verdict": false}}}`, ScanClean},
+		{`{"file": {"url": "https://files.example/a", "scan": {"verdict": ""}}}`, ScanUnknown},
+		{`{"file": {"url": "https://files.example/a"}}`, ScanUnknown},
+	}
+	provider := SimpleJSONProvider{Name: "scanning", URL: "https://files.example/", JSONPath: "file.url", ScanPath: "file.scan.verdict"}.Register()
+	for _, tc := range tests {
+		srv := newTextServer(t, tc.response)
+		res, err := (&Client{Endpoints: map[int]string{provider: srv.URL}}).UploadReaderContext(context.Background(), provider, strings.NewReader("hello"), "a.exe")
+		if err != nil || !res.Status {
+			t.Fatalf("%s: upload failed: %v", tc.response, err)
+		}
+		if res.ScanResult != tc.want {
+			t.Errorf("%s: ScanResult = %s, want %s", tc.response, res.ScanResult, tc.want)
+		}
+	}
+
+	srv := newTextServer(t, `{"success": true, "files": [{"url": "https://a.uguu.se/abc.exe"}]}`)
+	res, err := (&Client{Endpoints: map[int]string{Uguu: srv.URL}}).UploadReaderContext(context.Background(), Uguu, strings.NewReader("hello"), "a.exe")
+	if err != nil || res.ScanResult != ScanUnknown {
+		t.Fatalf("provider without scanning: got %s, %v, want unknown", res.ScanResult, err)
+	}
+}
+
+func TestInvalidScanPath(t *testing.T) {
+	_, err := RegisterProvider(SimpleJSONProvider{Name: "bad-scan", URL: "https://files.example/", JSONPath: "url", ScanPath: "scan..verdict"})
+	if !errors.Is(err, ErrInvalidSelector) {
+		t.Fatalf("err = %v, want ErrInvalidSelector", err)
+	}
+}
@@ -0,0 +1,52 @@
+package particeps
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const isGdURL = "https://is.gd/create.php"
+
+// ShortenIsGd shortens a URL with is.gd. It can be used as Client.ShortenWith.
+func ShortenIsGd(ctx context.Context, longURL string) (string, error) {
+	return DefaultClient.shortenIsGd(ctx, longURL)
+}
+
+func (c *Client) shortenIsGd(ctx context.Context, longURL string) (string, error) {
+	query := url.Values{"format": {"simple"}, "url": {longURL}}
+	req, err := http.NewRequestWithContext(ctx, "GET", isGdURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := readBody(resp)
+	if err != nil {
+		return "", err
+	}
+	short := strings.TrimSpace(string(body))
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(short, "http") {
+		return "", fmt.Errorf("particeps: is.gd could not shorten %s: %s", longURL, short)
+	}
+	return short, nil
+}
+
+// shorten fills in res.ShortenedURL with the Client's ShortenWith. Since the upload itself went through,
+// a failure is only logged.
+func (c *Client) shorten(ctx context.Context, res *UniversalResponse) {
+	if c.ShortenWith == nil || res.FullURL == "" {
+		return
+	}
+	short, err := c.ShortenWith(ctx, res.FullURL)
+	if err != nil {
+		c.warnf("could not shorten %s: %v", res.FullURL, err)
+		return
+	}
+	res.ShortenedURL = short
+}
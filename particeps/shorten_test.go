@@ -0,0 +1,91 @@
+package particeps
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestShortenWith(t *testing.T) {
+	srv := newTextServer(t, "https://ttm.sh/a-rather-long-name.txt")
+	var logged bytes.Buffer
+	var shortened []string
+	c := &Client{
+		Endpoints: map[int]string{TtmSh: srv.URL},
+		Logger:    log.New(&logged, "", 0),
+		ShortenWith: func(ctx context.Context, url string) (string, error) {
+			shortened = append(shortened, url)
+			return "https://is.gd/abc", nil
+		},
+	}
+	path := writeTestFile(t, "notes.txt", []byte("hello"))
+
+	res, err := c.UploadContext(context.Background(), TtmSh, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ShortenedURL != "https://is.gd/abc" || res.FullURL != "https://ttm.sh/a-rather-long-name.txt" {
+		t.Fatalf("got ShortenedURL %s and FullURL %s", res.ShortenedURL, res.FullURL)
+	}
+	if len(shortened) != 1 || shortened[0] != res.FullURL {
+		t.Fatalf("shortened %v, want the upload's URL", shortened)
+	}
+
+	// A failing shortener doesn't fail the upload
+	c.ShortenWith = func(context.Context, string) (string, error) { return "", errors.New("shortener down") }
+	res, err = c.UploadContext(context.Background(), TtmSh, path)
+	if err != nil || !res.Status || res.ShortenedURL != "" {
+		t.Fatalf("got %+v, %v, want the upload without a shortened URL", res, err)
+	}
+	if !strings.Contains(logged.String(), "shortener down") {
+		t.Fatalf("logged %q, want the shortener's failure", logged.String())
+	}
+}
+
+// rewriteTransport sends every request to target instead, keeping its path and query
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (rt rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host = rt.target.Scheme, rt.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestShortenIsGd(t *testing.T) {
+	var query url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		if r.URL.Path != "/create.php" || query.Get("format") != "simple" {
+			http.Error(w, "Error: bad request", http.StatusBadRequest)
+			return
+		}
+		if !strings.HasPrefix(query.Get("url"), "https://") {
+			w.Write([]byte("Error: Please enter a valid URL to shorten"))
+			return
+		}
+		w.Write([]byte("https://is.gd/abc\n"))
+	}))
+	t.Cleanup(srv.Close)
+	target, _ := url.Parse(srv.URL)
+	c := &Client{HTTPClient: &http.Client{Transport: rewriteTransport{target}}}
+
+	short, err := c.shortenIsGd(context.Background(), "https://ttm.sh/abc.txt?x=1&y=2")
+	if err != nil || short != "https://is.gd/abc" {
+		t.Fatalf("got %q, %v, want https://is.gd/abc", short, err)
+	}
+	if query.Get("url") != "https://ttm.sh/abc.txt?x=1&y=2" {
+		t.Fatalf("sent url=%q", query.Get("url"))
+	}
+
+	if _, err := c.shortenIsGd(context.Background(), "not a url"); err == nil {
+		t.Fatal("is.gd's error answer was taken for a short link")
+	}
+}
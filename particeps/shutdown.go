@@ -0,0 +1,90 @@
+package particeps
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// ErrClientClosed is returned when uploading with a Client that Close was called on
+var ErrClientClosed = errors.New("particeps: client is closed")
+
+// clientState tracks a Client's in-flight uploads so that Close can wait for them, and holds what the Client
+// keeps between uploads: the transport it built for its connection settings, if any, and its availability checks
+type clientState struct {
+	mu       sync.Mutex
+	closed   bool
+	nextID   int
+	inFlight map[int]context.CancelFunc
+	wg       sync.WaitGroup
+
+	transport         *http.Transport
+	transportSettings transportSettings
+	statuses          map[string]availability // By the URL that was queried, see ProviderAvailable
+}
+
+// clientStateMu guards the lazy creation of every Client's state, since Clients are usable as zero values
+var clientStateMu sync.Mutex
+
+func (c *Client) state() *clientState {
+	clientStateMu.Lock()
+	defer clientStateMu.Unlock()
+	if c.lifecycle == nil {
+		c.lifecycle = &clientState{inFlight: map[int]context.CancelFunc{}}
+	}
+	return c.lifecycle
+}
+
+// begin registers an upload as in flight, returning its context, which Close cancels if it gives up waiting,
+// and the function to call once the upload is over
+func (c *Client) begin(ctx context.Context) (context.Context, func(), error) {
+	s := c.state()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, nil, ErrClientClosed
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	id := s.nextID
+	s.nextID++
+	s.inFlight[id] = cancel
+	s.wg.Add(1)
+	return ctx, func() {
+		s.mu.Lock()
+		delete(s.inFlight, id)
+		s.mu.Unlock()
+		cancel()
+		s.wg.Done()
+	}, nil
+}
+
+// Close stops c from starting new uploads, which fail with ErrClientClosed, and waits for those in flight
+// to finish. If ctx is done first, the remaining uploads are cancelled and ctx's error is returned once
+// they have returned. Idle connections are closed afterwards.
+func (c *Client) Close(ctx context.Context) error {
+	s := c.state()
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(finished)
+	}()
+	var err error
+	select {
+	case <-finished:
+	case <-ctx.Done():
+		err = ctx.Err()
+		s.mu.Lock()
+		for _, cancel := range s.inFlight {
+			cancel()
+		}
+		s.mu.Unlock()
+		<-finished
+	}
+	c.Reset()
+	return err
+}
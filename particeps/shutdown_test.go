@@ -0,0 +1,129 @@
+package particeps
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// blockingServer answers uploads with a link only once release is closed, signalling arrived for each request
+func blockingServer(t *testing.T) (srv *httptest.Server, arrived chan struct{}, release chan struct{}) {
+	arrived, release = make(chan struct{}, 16), make(chan struct{})
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		arrived <- struct{}{}
+		select {
+		case <-release:
+			w.Write([]byte("https://ttm.sh/abc.txt"))
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, arrived, release
+}
+
+func TestCloseWaitsForInFlightUploads(t *testing.T) {
+	srv, arrived, release := blockingServer(t)
+	c := &Client{Endpoints: map[int]string{TtmSh: srv.URL}}
+
+	uploaded := make(chan error, 1)
+	go func() {
+		_, err := c.UploadReaderContext(context.Background(), TtmSh, strings.NewReader("hello"), "a.txt")
+		uploaded <- err
+	}()
+	<-arrived
+	closed := make(chan error, 1)
+	go func() { closed <- c.Close(context.Background()) }()
+
+	select {
+	case err := <-closed:
+		t.Fatalf("Close returned %v while an upload was in flight", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	if err := <-uploaded; err != nil {
+		t.Fatalf("in-flight upload: %v", err)
+	}
+	if err := <-closed; err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := c.UploadReaderContext(context.Background(), TtmSh, strings.NewReader("hello"), "a.txt"); !errors.Is(err, ErrClientClosed) {
+		t.Fatalf("upload after Close: err = %v, want ErrClientClosed", err)
+	}
+}
+
+func TestCloseCancelsUploadsAtDeadline(t *testing.T) {
+	srv, arrived, _ := blockingServer(t)
+	c := &Client{Endpoints: map[int]string{TtmSh: srv.URL}}
+
+	uploaded := make(chan error, 1)
+	go func() {
+		_, err := c.UploadReaderContext(context.Background(), TtmSh, strings.NewReader("hello"), "a.txt")
+		uploaded <- err
+	}()
+	<-arrived
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := c.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Close: err = %v, want context.DeadlineExceeded", err)
+	}
+	select {
+	case err := <-uploaded:
+		if err == nil {
+			t.Fatal("cancelled upload reported success")
+		}
+	default:
+		t.Fatal("Close returned before the cancelled upload did")
+	}
+}
+
+func TestCloseWaitsForBulkUploads(t *testing.T) {
+	srv, arrived, release := blockingServer(t)
+	c := &Client{Endpoints: map[int]string{TransferSh: srv.URL}}
+	files := []string{writeTestFile(t, "a.txt", []byte("a")), writeTestFile(t, "b.txt", []byte("b"))}
+
+	uploaded := make(chan error, 1)
+	go func() {
+		_, err := c.UploadFilesContext(context.Background(), TransferSh, files)
+		uploaded <- err
+	}()
+	<-arrived
+	closed := make(chan error, 1)
+	go func() { closed <- c.Close(context.Background()) }()
+	select {
+	case <-closed:
+		t.Fatal("Close returned while a bulk upload was in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	<-uploaded
+	if err := <-closed; err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+func TestClosedClientRefusesEveryRequest(t *testing.T) {
+	c := &Client{Credentials: map[int]ProviderCredentials{ImgChest: {Token: "t"}, Imgur: {APIKey: "id"}}}
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	path := writeTestFile(t, "a.png", []byte("not really"))
+	ctx := context.Background()
+	token := []byte(`{"provider": "Filebin", "url": "https://filebin.net/bin/a.txt"}`)
+
+	for name, err := range map[string]error{
+		"ImgChestUploadContext": func() error { _, err := c.ImgChestUploadContext(ctx, []string{path}, ""); return err }(),
+		"UploadFilesContext":    func() error { _, err := c.UploadFilesContext(ctx, TransferSh, []string{path}); return err }(),
+		"Delete":                c.Delete(ctx, token),
+		"ImgurUpdateContext":    c.ImgurUpdateContext(ctx, "hash", "title", ""),
+	} {
+		if !errors.Is(err, ErrClientClosed) {
+			t.Errorf("%s: err = %v, want ErrClientClosed", name, err)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package particeps
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// UnsignedPayload is the body hash given to a RequestSigner when the body is streamed and can't be hashed up front
+const UnsignedPayload = "UNSIGNED-PAYLOAD"
+
+// RequestSigner signs upload requests for hosts that authenticate them with an HMAC or a similar scheme.
+// Signers are set per provider in Client.Signers.
+type RequestSigner interface {
+	// Sign adds the signature headers to req just before it's sent. bodyHash is the hex SHA-256 of the
+	// request body, or UnsignedPayload for streamed bodies, and timestamp is the time of signing.
+	Sign(req *http.Request, bodyHash string, timestamp time.Time) error
+}
+
+// HMACSigner signs requests with an HMAC-SHA256 of the method, path, body hash and Unix timestamp,
+// joined by newlines. The timestamp and hex signature are sent in the TimestampHeader and SignatureHeader headers.
+type HMACSigner struct {
+	Secret          []byte
+	TimestampHeader string // Defaults to X-Timestamp
+	SignatureHeader string // Defaults to X-Signature
+}
+
+// Sign implements RequestSigner
+func (s HMACSigner) Sign(req *http.Request, bodyHash string, timestamp time.Time) error {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, s.Secret)
+	io.WriteString(mac, req.Method+"\n"+req.URL.EscapedPath()+"\n"+bodyHash+"\n"+ts)
+
+	timestampHeader, signatureHeader := s.TimestampHeader, s.SignatureHeader
+	if timestampHeader == "" {
+		timestampHeader = "X-Timestamp"
+	}
+	if signatureHeader == "" {
+		signatureHeader = "X-Signature"
+	}
+	req.Header.Set(timestampHeader, ts)
+	req.Header.Set(signatureHeader, hex.EncodeToString(mac.Sum(nil)))
+	return nil
+}
+
+// sign applies the provider's RequestSigner to req, if it has one
+func (c *Client) sign(provider int, req *http.Request) error {
+	signer, ok := c.Signers[provider]
+	if !ok || signer == nil {
+		return nil
+	}
+	bodyHash := UnsignedPayload
+	if req.Body == nil || req.Body == http.NoBody {
+		sum := sha256.Sum256(nil)
+		bodyHash = hex.EncodeToString(sum[:])
+	} else if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return err
+		}
+		defer body.Close()
+		h := sha256.New()
+		if _, err := io.Copy(h, body); err != nil {
+			return err
+		}
+		bodyHash = hex.EncodeToString(h.Sum(nil))
+	}
+	return signer.Sign(req, bodyHash, c.clock().Now())
+}
@@ -0,0 +1,51 @@
+package particeps
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"testing"
+)
+
+// expectedSignature computes HMACSigner's signature the way a verifying server would
+func expectedSignature(secret []byte, req recordedRequest, bodyHash string) string {
+	mac := hmac.New(sha256.New, secret)
+	io.WriteString(mac, req.Method+"\n"+req.Path+"\n"+bodyHash+"\n"+req.Header.Get("X-Timestamp"))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestHMACSigner(t *testing.T) {
+	secret := []byte("s3cr3t")
+	uguu := newTextServer(t, `{"success": true, "files": [{"url": "https://a.uguu.se/abc.txt"}]}`)
+	transfer := newTextServer(t, "https://transfer.sh/abc/notes.txt")
+	c := &Client{
+		Endpoints: map[int]string{Uguu: uguu.URL + "/upload", TransferSh: transfer.URL},
+		Signers:   map[int]RequestSigner{Uguu: HMACSigner{Secret: secret}, TransferSh: HMACSigner{Secret: secret, SignatureHeader: "X-Sig"}},
+		Clock:     newFakeClock(),
+	}
+	path := writeTestFile(t, "notes.txt", []byte("hello"))
+	for _, provider := range []int{Uguu, TransferSh} {
+		if _, err := c.UploadContext(context.Background(), provider, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The multipart body is built in memory, so it is hashed
+	req := uguu.received()[0]
+	sum := sha256.Sum256(req.Body)
+	want := expectedSignature(secret, req, hex.EncodeToString(sum[:]))
+	if req.Header.Get("X-Timestamp") != "1609459200" || req.Header.Get("X-Signature") != want {
+		t.Fatalf("signed with timestamp %s and %s, want 1609459200 and %s",
+			req.Header.Get("X-Timestamp"), req.Header.Get("X-Signature"), want)
+	}
+	// The file itself is streamed
+	req = transfer.received()[0]
+	if want := expectedSignature(secret, req, UnsignedPayload); req.Header.Get("X-Sig") != want {
+		t.Fatalf("signed the streamed body with %s, want %s", req.Header.Get("X-Sig"), want)
+	}
+	if req.Header.Get("X-Signature") != "" {
+		t.Fatal("the default signature header was sent along with the configured one")
+	}
+}
@@ -0,0 +1,178 @@
+package particeps
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidSelector is returned when registering a provider whose JSONPath or URLPattern can't be used
+var ErrInvalidSelector = errors.New("particeps: invalid URL selector")
+
+// SimpleJSONProvider describes a host that takes a multipart POST with the file in a single field, or the file
+// as the raw request body, and answers with JSON holding the file's URL, or with text the URL can be matched in
+type SimpleJSONProvider struct {
+	Name   string
+	URL    string // Default upload endpoint
+	Method string // HTTP method the file is sent with, POST if empty
+	Raw    bool   // Send the file as the whole request body instead of as a multipart form
+	Field  string // Form field holding the file, for multipart forms
+	// FilenameInPath appends the file's name to the endpoint's path, as hosts taking a raw PUT often expect
+	FilenameInPath bool
+	JSONPath       string // Dot-separated path to the URL in the response, e.g. "link", "data.url" or "files.0.url"
+	// URLPattern, if set, is used instead of JSONPath for hosts answering with text rather than JSON.
+	// It is a regular expression whose first capture group matches the URL, e.g. `Download: (\S+)`.
+	URLPattern string
+	// URLTemplate, if set, makes JSONPath point to a token instead of a URL.
+	// The public URL is then built by replacing "{token}" in the template, e.g. "https://host/d/{token}".
+	URLTemplate string
+	// ScanPath, if set, is the dot-separated path to the host's safety verdict in JSON responses, e.g.
+	// "file.virus". It is reported as UniversalResponse.ScanResult: a boolean verdict is whether the file was
+	// flagged, and a string one is clean if it is "clean", "ok", "safe", "none" or "passed".
+	ScanPath string
+	// PasswordField, if set, is the form field that protects the upload with the password given by WithPassword
+	PasswordField string
+	// Capabilities describes what the host supports. Password is implied by PasswordField.
+	Capabilities Capabilities
+
+	urlPattern *regexp.Regexp // URLPattern, compiled by RegisterProvider
+}
+
+// Register registers p and returns the constant to upload to it with.
+// It panics if p's JSONPath or URLPattern is invalid; RegisterProvider returns an error instead.
+func (p SimpleJSONProvider) Register() int {
+	provider, err := RegisterProvider(p)
+	if err != nil {
+		panic(err)
+	}
+	return provider
+}
+
+// RegisterProvider checks p's JSONPath or URLPattern and its ScanPath, then registers p and returns the constant to upload to it with
+func RegisterProvider(p SimpleJSONProvider) (int, error) {
+	if p.URLPattern != "" {
+		re, err := regexp.Compile(p.URLPattern)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %v", ErrInvalidSelector, err)
+		}
+		if re.NumSubexp() < 1 {
+			return 0, fmt.Errorf("%w: %q has no capture group for the URL", ErrInvalidSelector, p.URLPattern)
+		}
+		p.urlPattern = re
+	} else if err := checkJSONPath(p.JSONPath); err != nil {
+		return 0, err
+	}
+	if err := checkJSONPath(p.ScanPath); err != nil {
+		return 0, err
+	}
+	return registerProvider(p.def()), nil
+}
+
+// checkJSONPath checks that a dot-separated JSON path has no empty components
+func checkJSONPath(path string) error {
+	if path == "" {
+		return nil
+	}
+	for _, key := range strings.Split(path, ".") {
+		if key == "" {
+			return fmt.Errorf("%w: empty component in JSON path %q", ErrInvalidSelector, path)
+		}
+	}
+	return nil
+}
+
+// def returns the provider definition of p
+func (p SimpleJSONProvider) def() *providerDef {
+	caps := p.Capabilities
+	caps.Password = caps.Password || p.PasswordField != ""
+	return &providerDef{
+		name:     p.Name,
+		endpoint: p.URL,
+		caps:     caps,
+		shape:    requestShape{method: p.Method, raw: p.Raw, field: p.Field, filenameInPath: p.FilenameInPath},
+		upload:   p.upload,
+		success:  urlSuccess,
+	}
+}
+
+// RegisterSimpleJSON registers a SimpleJSONProvider and returns the constant to upload to it with.
+// Like Register, it panics if jsonPath is invalid.
+func RegisterSimpleJSON(name, url, field, jsonPath string) int {
+	return SimpleJSONProvider{Name: name, URL: url, Field: field, JSONPath: jsonPath}.Register()
+}
+
+// RegisterTokenJSON registers a SimpleJSONProvider for a host that answers with a token rather than a URL,
+// and returns the constant to upload to it with. Like Register, it panics if jsonPath is invalid.
+func RegisterTokenJSON(name, url, field, jsonPath, urlTemplate string) int {
+	return SimpleJSONProvider{Name: name, URL: url, Field: field, JSONPath: jsonPath, URLTemplate: urlTemplate}.Register()
+}
+
+func (p SimpleJSONProvider) upload(c *Client, u *uploadRequest) (UniversalResponse, error) {
+	var returnValue UniversalResponse
+	returnValue.Status = false
+
+	// Multi-part Body
+	var fields map[string]string
+	if p.PasswordField != "" && u.opts.password != "" {
+		fields = map[string]string{p.PasswordField: u.opts.password}
+		returnValue.PasswordProtected = true
+	}
+	resp, body, err := c.sendFile(u, fields, nil)
+	if resp != nil {
+		if res, ok := c.capturedRedirect(resp); ok {
+			return res, nil
+		}
+	}
+	if err != nil {
+		return returnValue, err
+	}
+
+	var value string
+	if p.urlPattern != nil {
+		if match := p.urlPattern.FindSubmatch(body); match != nil {
+			value = strings.TrimSpace(string(match[1]))
+		}
+	} else {
+		var parsed interface{}
+		if err := json.Unmarshal(body, &parsed); err != nil {
+			return returnValue, err
+		}
+		value, _ = lookupJSONPath(parsed, p.JSONPath).(string)
+		if p.ScanPath != "" {
+			returnValue.ScanResult = parseScanVerdict(lookupJSONPath(parsed, p.ScanPath))
+		}
+	}
+	if value != "" && p.URLTemplate != "" {
+		value = strings.Replace(p.URLTemplate, "{token}", url.PathEscape(value), -1)
+	}
+	u.parsed = value
+	returnValue.FullURL = value
+	return returnValue, nil
+}
+
+// lookupJSONPath follows a dot-separated path through decoded JSON.
+// Numeric components index into arrays. Returns nil if the path doesn't exist.
+func lookupJSONPath(value interface{}, path string) interface{} {
+	if path == "" {
+		return value
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			value = v[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil
+			}
+			value = v[i]
+		default:
+			return nil
+		}
+	}
+	return value
+}
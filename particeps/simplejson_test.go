@@ -0,0 +1,172 @@
+package particeps
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRegisterProviderJSONSelector(t *testing.T) {
+	srv := newTextServer(t, `{"files": [{"name": "a.txt", "url": "https://files.example/a.txt"}]}`)
+	provider, err := RegisterProvider(SimpleJSONProvider{Name: "json-selector", URL: srv.URL, Field: "file", JSONPath: "files.0.url"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := (&Client{}).UploadReaderContext(context.Background(), provider, strings.NewReader("hello"), "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.FullURL != "https://files.example/a.txt" {
+		t.Fatalf("FullURL = %q", res.FullURL)
+	}
+}
+
+func TestRegisterProviderRegexSelector(t *testing.T) {
+	srv := newTextServer(t, "Upload complete!\nDownload: https://files.example/b.txt\n")
+	provider, err := RegisterProvider(SimpleJSONProvider{Name: "regex-selector", URL: srv.URL, Field: "file", URLPattern: `Download: (\S+)`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := (&Client{}).UploadReaderContext(context.Background(), provider, strings.NewReader("hello"), "b.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.FullURL != "https://files.example/b.txt" {
+		t.Fatalf("FullURL = %q", res.FullURL)
+	}
+}
+
+func TestRegisterProviderInvalidSelectors(t *testing.T) {
+	for _, p := range []SimpleJSONProvider{
+		{Name: "bad-path", JSONPath: "data..url"},
+		{Name: "bad-scan-path", JSONPath: "url", ScanPath: ".virus"},
+		{Name: "bad-regex", URLPattern: `Download: (\S+`},
+		{Name: "no-group", URLPattern: `https://\S+`},
+	} {
+		if _, err := RegisterProvider(p); !errors.Is(err, ErrInvalidSelector) {
+			t.Errorf("%s: err = %v, want ErrInvalidSelector", p.Name, err)
+		}
+	}
+}
+
+func TestRegisterSimpleJSONChecksPath(t *testing.T) {
+	for name, register := range map[string]func(){
+		"RegisterSimpleJSON": func() { RegisterSimpleJSON("bad-simple", "https://files.example/", "file", "data.") },
+		"RegisterTokenJSON": func() {
+			RegisterTokenJSON("bad-token", "https://files.example/", "file", ".id", "https://files.example/{token}")
+		},
+	} {
+		func() {
+			defer func() {
+				err, _ := recover().(error)
+				if !errors.Is(err, ErrInvalidSelector) {
+					t.Errorf("%s: recovered %v, want a panic with ErrInvalidSelector", name, err)
+				}
+			}()
+			register()
+		}()
+	}
+}
+
+func TestRegisterSimpleJSONPathShapes(t *testing.T) {
+	for i, tt := range []struct {
+		path, response string
+	}{
+		{"link", `{"link": "https://files.example/a.txt"}`},
+		{"data.url", `{"status": 200, "data": {"url": "https://files.example/a.txt"}}`},
+		{"files.0.url", `{"files": [{"url": "https://files.example/a.txt"}, {"url": "https://files.example/other"}]}`},
+	} {
+		srv := newTextServer(t, tt.response)
+		provider := RegisterSimpleJSON("simple-json-"+strconv.Itoa(i), srv.URL, "upload", tt.path)
+		res, err := (&Client{}).UploadReaderContext(context.Background(), provider, strings.NewReader("hello"), "a.txt")
+		if err != nil || res.FullURL != "https://files.example/a.txt" {
+			t.Errorf("%s: got %+v, %v", tt.path, res, err)
+			continue
+		}
+		if body := srv.received()[0].Body; !strings.Contains(string(body), `name="upload"; filename="a.txt"`) {
+			t.Errorf("%s: the file wasn't sent in the configured field:\n%s", tt.path, body)
+		}
+	}
+}
+
+func TestSimpleJSONBuiltins(t *testing.T) {
+	putRe := newTextServer(t, `{"status": "success", "data": {"link": "https://s.put.re/abc.txt"}}`)
+	uguu := newTextServer(t, `{"success": true, "files": [{"url": "https://a.uguu.se/abc.txt"}]}`)
+	c := &Client{Endpoints: map[int]string{PutRe: putRe.URL, Uguu: uguu.URL}}
+	for provider, want := range map[int]string{PutRe: "https://s.put.re/abc.txt", Uguu: "https://a.uguu.se/abc.txt"} {
+		res, err := c.UploadReaderContext(context.Background(), provider, strings.NewReader("hello"), "a.txt")
+		if err != nil || res.FullURL != want {
+			t.Errorf("provider %d: got %+v, %v", provider, res, err)
+		}
+	}
+	if body := uguu.received()[0].Body; !strings.Contains(string(body), `name="files[]"`) {
+		t.Errorf("Uguu's field is not files[]:\n%s", body)
+	}
+}
+
+func TestSimpleJSONMissingURL(t *testing.T) {
+	srv := newTextServer(t, `{"data": {}}`)
+	provider := RegisterSimpleJSON("simple-json-missing", srv.URL, "file", "data.url")
+	res, err := (&Client{}).UploadReaderContext(context.Background(), provider, strings.NewReader("hello"), "a.txt")
+	if err == nil || res.Status {
+		t.Fatalf("got %+v, want the upload reported as failed", res)
+	}
+}
+
+func TestTokenURLTemplate(t *testing.T) {
+	srv := newTextServer(t, `{"success": true, "id": "Xy12ab"}`)
+	c := &Client{Endpoints: map[int]string{Pixeldrain: srv.URL}}
+	res, err := c.UploadReaderContext(context.Background(), Pixeldrain, strings.NewReader("hello"), "a.txt")
+	if err != nil || res.FullURL != "https://pixeldrain.com/u/Xy12ab" {
+		t.Fatalf("got %+v, %v", res, err)
+	}
+	if got := srv.received()[0]; got.Method != "PUT" || got.Path != "/a.txt" || string(got.Body) != "hello" {
+		t.Fatalf("Pixeldrain request = %s %s %q", got.Method, got.Path, got.Body)
+	}
+
+	odd := newTextServer(t, `{"file": {"token": "a/b c"}}`)
+	provider := RegisterTokenJSON("token-json", odd.URL, "file", "file.token", "https://files.example/d/{token}")
+	res, err = (&Client{}).UploadReaderContext(context.Background(), provider, strings.NewReader("hello"), "a.txt")
+	if err != nil || res.FullURL != "https://files.example/d/a%2Fb%20c" {
+		t.Fatalf("got %+v, %v, want the token escaped into the template", res, err)
+	}
+}
+
+func TestWithPassword(t *testing.T) {
+	var password string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		password = r.FormValue("pass")
+		w.Write([]byte(`{"files": [{"url": "https://files.example/a.txt"}]}`))
+	}))
+	t.Cleanup(srv.Close)
+	provider, err := RegisterProvider(SimpleJSONProvider{Name: "password-host", URL: srv.URL, Field: "file", JSONPath: "files.0.url", PasswordField: "pass"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ProviderCapabilities(provider).Password {
+		t.Fatal("PasswordField doesn't imply the Password capability")
+	}
+	c := &Client{}
+
+	res, err := c.UploadReaderContext(context.Background(), provider, strings.NewReader("hello"), "a.txt", WithPassword("hunter2"))
+	if err != nil || !res.PasswordProtected || password != "hunter2" {
+		t.Fatalf("got %+v, %v with password field %q, want a protected upload", res, err, password)
+	}
+	res, err = c.UploadReaderContext(context.Background(), provider, strings.NewReader("hello"), "a.txt")
+	if err != nil || res.PasswordProtected || password != "" {
+		t.Fatalf("got %+v, %v with password field %q, want an unprotected upload", res, err, password)
+	}
+
+	ttm := newTextServer(t, "https://ttm.sh/abc.txt")
+	c.Endpoints = map[int]string{TtmSh: ttm.URL}
+	if _, err := c.UploadReaderContext(context.Background(), TtmSh, strings.NewReader("hello"), "a.txt", WithPassword("hunter2")); !errors.Is(err, ErrPasswordUnsupported) {
+		t.Fatalf("err = %v, want ErrPasswordUnsupported", err)
+	}
+	if len(ttm.received()) != 0 {
+		t.Fatal("the upload was sent without its password")
+	}
+}
@@ -0,0 +1,84 @@
+package particeps
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SmokeTestTimeout bounds how long SmokeTest waits for a provider to answer
+const SmokeTestTimeout = 30 * time.Second
+
+// smokePayload is the few bytes sent by SmokeTest to file hosts
+var smokePayload = []byte("particeps\n")
+
+// smokeImage is a 1x1 transparent GIF, sent by SmokeTest to image-only hosts
+var smokeImage = []byte{
+	0x47, 0x49, 0x46, 0x38, 0x39, 0x61, 0x01, 0x00, 0x01, 0x00, 0x80, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0xff, 0xff, 0xff, 0x21, 0xf9, 0x04, 0x01, 0x00,
+	0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01, 0x00,
+	0x00, 0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
+// ErrSmokeTestUnsupported is returned by SmokeTest for providers it has nothing to send to,
+// such as video-only hosts, or S3 without an endpoint set in Client.Endpoints
+var ErrSmokeTestUnsupported = errors.New("particeps: provider can't be smoke tested")
+
+// SmokeTest checks that a provider still accepts uploads by sending it a tiny in-memory payload
+// and verifying that a URL comes back. Providers that allow deleting uploads (Filebin, transfer.sh
+// and Imgur) have the payload deleted right away, and a failed deletion fails the test; on the
+// others it is left until it expires on its own.
+func SmokeTest(provider int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), SmokeTestTimeout)
+	defer cancel()
+	return SmokeTestContext(ctx, provider)
+}
+
+// SmokeTestContext is like SmokeTest, but uses ctx instead of SmokeTestTimeout
+func SmokeTestContext(ctx context.Context, provider int) error {
+	return DefaultClient.SmokeTestContext(ctx, provider)
+}
+
+// SmokeTestContext is like SmokeTest, but uses ctx instead of SmokeTestTimeout
+func (c *Client) SmokeTestContext(ctx context.Context, provider int) error {
+	def, err := lookupProvider(provider)
+	if err != nil {
+		return err
+	}
+	payload, filename, ok := smokeProbe(provider, def)
+	if !ok || c.endpoint(provider, def, uploadOptions{}) == "" {
+		return fmt.Errorf("%w: %s", ErrSmokeTestUnsupported, def.name)
+	}
+	res, err := c.UploadReaderContext(ctx, provider, bytes.NewReader(payload), filename)
+	if err != nil {
+		return err
+	}
+	if !res.Status || res.FullURL == "" {
+		return fmt.Errorf("particeps: smoke test for provider %d returned no URL", provider)
+	}
+	if def.delete == nil {
+		return nil
+	}
+	token, err := MarshalDeleteToken(res)
+	if err != nil {
+		return err
+	}
+	if err := c.Delete(ctx, token); err != nil {
+		return fmt.Errorf("particeps: smoke test couldn't delete its upload from %s: %w", def.name, err)
+	}
+	return nil
+}
+
+// smokeProbe picks the payload SmokeTest sends to a provider, if the provider takes either one
+func smokeProbe(provider int, def *providerDef) ([]byte, string, bool) {
+	// Imagebin only takes images, though it doesn't restrict its media types
+	if provider != Imagebin && def.accepts(int64(len(smokePayload)), "text/plain") {
+		return smokePayload, "particeps-smoke.txt", true
+	}
+	if def.accepts(int64(len(smokeImage)), "image/gif") {
+		return smokeImage, "particeps-smoke.gif", true
+	}
+	return nil, "", false
+}
@@ -0,0 +1,98 @@
+package particeps
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestSmokeTestDeletesProbe(t *testing.T) {
+	var mu sync.Mutex
+	var deleted []string
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			mu.Lock()
+			deleted = append(deleted, r.URL.Path)
+			mu.Unlock()
+			return
+		}
+		w.Header().Set("X-Url-Delete", srv.URL+"/abc/particeps-smoke.txt/secret")
+		w.Write([]byte(srv.URL + "/abc/particeps-smoke.txt"))
+	}))
+	defer srv.Close()
+
+	c := &Client{Endpoints: map[int]string{TransferSh: srv.URL}}
+	if err := c.SmokeTestContext(context.Background(), TransferSh); err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted) != 1 || deleted[0] != "/abc/particeps-smoke.txt/secret" {
+		t.Fatalf("deleted %v, want the probe deleted once through its deletion link", deleted)
+	}
+}
+
+func TestSmokeTestFailedDeletion(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("X-Url-Delete", srv.URL+"/abc/particeps-smoke.txt/secret")
+		w.Write([]byte(srv.URL + "/abc/particeps-smoke.txt"))
+	}))
+	defer srv.Close()
+
+	c := &Client{Endpoints: map[int]string{TransferSh: srv.URL}}
+	if err := c.SmokeTestContext(context.Background(), TransferSh); err == nil {
+		t.Fatal("want a refused deletion reported")
+	}
+}
+
+func TestSmokeTestKeepsProbeWithoutDeletion(t *testing.T) {
+	srv := newTextServer(t, "https://ttm.sh/abc.txt")
+	c := &Client{Endpoints: map[int]string{TtmSh: srv.URL}}
+	if err := c.SmokeTestContext(context.Background(), TtmSh); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range srv.received() {
+		if r.Method == "DELETE" {
+			t.Fatal("tried deleting from a provider that doesn't support it")
+		}
+	}
+}
+
+func TestSmokeTestUnsupportedProviders(t *testing.T) {
+	srv := newTextServer(t, "https://example.com/abc")
+	c := &Client{Endpoints: map[int]string{Streamable: srv.URL}}
+	for _, provider := range []int{Streamable, S3} {
+		if err := c.SmokeTestContext(context.Background(), provider); !errors.Is(err, ErrSmokeTestUnsupported) {
+			t.Errorf("provider %d: err = %v, want ErrSmokeTestUnsupported", provider, err)
+		}
+	}
+	if n := len(srv.received()); n != 0 {
+		t.Fatalf("%d requests sent to providers that can't take the payload", n)
+	}
+}
+
+func TestSmokeTestPayloads(t *testing.T) {
+	tests := []struct {
+		provider int
+		filename string
+	}{
+		{TtmSh, "particeps-smoke.txt"},
+		{TransferSh, "particeps-smoke.txt"},
+		{Imgur, "particeps-smoke.gif"},
+		{Imagebin, "particeps-smoke.gif"},
+		{KekSh, "particeps-smoke.gif"},
+	}
+	for _, tc := range tests {
+		def, _ := lookupProvider(tc.provider)
+		if _, filename, ok := smokeProbe(tc.provider, def); !ok || filename != tc.filename {
+			t.Errorf("provider %d: got %s, %v, want %s", tc.provider, filename, ok, tc.filename)
+		}
+	}
+}
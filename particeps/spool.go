@@ -0,0 +1,65 @@
+package particeps
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// DefaultMaxMemoryBuffer is used when Client.MaxMemoryBuffer is zero
+const DefaultMaxMemoryBuffer = 32 << 20
+
+// spool holds data that has to be read more than once, either in memory or in a temporary file
+type spool struct {
+	data    []byte // set when the data fit in memory
+	file    *os.File
+	cleanup func()
+}
+
+// open returns a new reader over the spooled data
+func (s *spool) open() (io.ReadCloser, error) {
+	if s.file == nil {
+		return ioutil.NopCloser(bytes.NewReader(s.data)), nil
+	}
+	return os.Open(s.file.Name())
+}
+
+// close releases the spooled data, removing the temporary file if there is one
+func (s *spool) close() {
+	if s.cleanup != nil {
+		s.cleanup()
+	}
+}
+
+// maxMemoryBuffer returns the largest amount of data the Client keeps in memory before spilling to TempDir
+func (c *Client) maxMemoryBuffer() int64 {
+	if c.MaxMemoryBuffer > 0 {
+		return c.MaxMemoryBuffer
+	}
+	return DefaultMaxMemoryBuffer
+}
+
+// spool reads r in full so it can be replayed. Up to MaxMemoryBuffer bytes are kept in memory;
+// anything larger is written to a temporary file in TempDir.
+func (c *Client) spool(r io.Reader) (*spool, error) {
+	limit := c.maxMemoryBuffer()
+	var buf bytes.Buffer
+	n, err := io.CopyN(&buf, r, limit+1)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if n <= limit {
+		return &spool{data: buf.Bytes()}, nil
+	}
+
+	f, cleanup, err := c.tempFile()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(f, io.MultiReader(&buf, r)); err != nil {
+		cleanup()
+		return nil, err
+	}
+	return &spool{file: f, cleanup: cleanup}, nil
+}
@@ -0,0 +1,113 @@
+package particeps
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// dirEntries returns the names of the files in dir
+func dirEntries(t *testing.T, dir string) []string {
+	t.Helper()
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, len(infos))
+	for i, info := range infos {
+		names[i] = info.Name()
+	}
+	return names
+}
+
+func TestTempDirUsedAndCleaned(t *testing.T) {
+	dir := tempDir(t)
+	var during [][]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		during = append(during, dirEntries(t, dir))
+		w.Write([]byte("https://ttm.sh/abc.txt"))
+	}))
+	defer srv.Close()
+	c := &Client{Endpoints: map[int]string{TtmSh: srv.URL}, TempDir: dir, MaxMemoryBuffer: 16}
+
+	data := bytes.Repeat([]byte("x"), 1000)
+	results, err := c.UploadReaderToManyContext(context.Background(), []int{TtmSh}, bytes.NewReader(data), "a.txt")
+	if err != nil || results[0].Err != nil {
+		t.Fatalf("upload failed: %v %+v", err, results)
+	}
+	if len(during) != 1 || len(during[0]) != 1 {
+		t.Fatalf("files in TempDir during the upload: %v, want the spooled upload", during)
+	}
+	if left := dirEntries(t, dir); len(left) != 0 {
+		t.Fatalf("files left in TempDir: %v", left)
+	}
+}
+
+func TestTempDirCleanedOnError(t *testing.T) {
+	dir := tempDir(t)
+	c := &Client{TempDir: dir, MaxMemoryBuffer: 16}
+	_, err := c.UploadReaderToManyContext(context.Background(), []int{TtmSh}, &failingReader{n: 100}, "a.txt")
+	if !errors.Is(err, errReadFailed) {
+		t.Fatalf("err = %v, want the read error", err)
+	}
+	if left := dirEntries(t, dir); len(left) != 0 {
+		t.Fatalf("files left in TempDir: %v", left)
+	}
+}
+
+func TestTempDirCleanedOnCancel(t *testing.T) {
+	dir := tempDir(t)
+	srv, arrived, _ := blockingServer(t)
+	c := &Client{Endpoints: map[int]string{TtmSh: srv.URL}, TempDir: dir, MaxMemoryBuffer: 16}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-arrived
+		cancel()
+	}()
+	results, _ := c.UploadReaderToManyContext(ctx, []int{TtmSh}, bytes.NewReader(make([]byte, 1000)), "a.txt")
+	if results[0].Err == nil {
+		t.Fatal("cancelled upload reported success")
+	}
+	if left := dirEntries(t, dir); len(left) != 0 {
+		t.Fatalf("files left in TempDir: %v", left)
+	}
+}
+
+func TestSpoolThreshold(t *testing.T) {
+	dir := tempDir(t)
+	c := &Client{TempDir: dir, MaxMemoryBuffer: 100}
+	for _, size := range []int{0, 99, 100, 101, 1000} {
+		data := bytes.Repeat([]byte("y"), size)
+		s, err := c.spool(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		onDisk := len(dirEntries(t, dir)) == 1
+		if want := size > 100; onDisk != want || (s.file != nil) != want {
+			t.Errorf("%d bytes: on disk = %v, want %v", size, onDisk, want)
+		}
+		for i := 0; i < 2; i++ {
+			r, err := s.open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			replayed, _ := ioutil.ReadAll(r)
+			r.Close()
+			if !bytes.Equal(replayed, data) {
+				t.Errorf("%d bytes: replay %d differs", size, i)
+			}
+		}
+		s.close()
+		if left := dirEntries(t, dir); len(left) != 0 {
+			t.Fatalf("%d bytes: files left after close: %v", size, left)
+		}
+	}
+	if got := (&Client{}).maxMemoryBuffer(); got != DefaultMaxMemoryBuffer {
+		t.Fatalf("default threshold = %d, want %d", got, DefaultMaxMemoryBuffer)
+	}
+}
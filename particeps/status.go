@@ -0,0 +1,125 @@
+package particeps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrProviderUnavailable is reported for providers skipped because they are down, see Client.SkipUnavailable
+var ErrProviderUnavailable = errors.New("particeps: provider is unavailable")
+
+// statusCacheTTL is how long the outcome of a ProviderAvailable check is reused for
+const statusCacheTTL = 30 * time.Second
+
+// availability is a cached ProviderAvailable outcome
+type availability struct {
+	up      bool
+	err     error
+	checked time.Time
+}
+
+// cachedAvailability returns the Client's recent availability check of the given key, if it hasn't expired
+func (c *Client) cachedAvailability(key string) (availability, bool) {
+	s := c.state()
+	s.mu.Lock()
+	a, ok := s.statuses[key]
+	s.mu.Unlock()
+	return a, ok && c.clock().Now().Sub(a.checked) < statusCacheTTL
+}
+
+// storeAvailability caches an availability check, by the URL that was queried, until the Client is Reset
+func (c *Client) storeAvailability(key string, a availability) {
+	s := c.state()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.statuses == nil {
+		s.statuses = map[string]availability{}
+	}
+	s.statuses[key] = a
+}
+
+// PingProvider checks that the provider's upload endpoint answers. Any answer short of a server error counts,
+// since endpoints commonly refuse the bodyless HEAD request sent.
+func PingProvider(ctx context.Context, provider int) error {
+	return DefaultClient.PingProvider(ctx, provider)
+}
+
+// PingProvider checks that the provider's upload endpoint answers
+func (c *Client) PingProvider(ctx context.Context, provider int) error {
+	def, err := lookupProvider(provider)
+	if err != nil {
+		return err
+	}
+	resp, err := c.probe(ctx, "HEAD", c.endpoint(provider, def, uploadOptions{}))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 500 {
+		return &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	return nil
+}
+
+// ProviderAvailable reports whether the provider is up. It queries the provider's status page from the
+// Client's StatusURLs, or pings it with PingProvider if it has none. Outcomes are cached for 30 seconds.
+func ProviderAvailable(ctx context.Context, provider int) (bool, error) {
+	return DefaultClient.ProviderAvailable(ctx, provider)
+}
+
+// ProviderAvailable reports whether the provider is up, from its status page or by pinging it.
+// A provider that answers with a server error is down; the error is only set when that couldn't be determined.
+func (c *Client) ProviderAvailable(ctx context.Context, provider int) (bool, error) {
+	def, err := lookupProvider(provider)
+	if err != nil {
+		return false, err
+	}
+	statusURL := c.StatusURLs[provider]
+	key := statusURL
+	if key == "" {
+		key = "ping " + c.endpoint(provider, def, uploadOptions{})
+	}
+	if a, ok := c.cachedAvailability(key); ok {
+		return a.up, a.err
+	}
+
+	var a availability
+	if statusURL != "" {
+		a.up, a.err = c.checkStatusPage(ctx, statusURL)
+	} else {
+		a.err = c.PingProvider(ctx, provider)
+		var statusErr *StatusError
+		if errors.As(a.err, &statusErr) {
+			a.err = nil // answered, with a server error: down
+		} else {
+			a.up = a.err == nil
+		}
+	}
+	if ctx.Err() == nil {
+		a.checked = c.clock().Now()
+		c.storeAvailability(key, a)
+	}
+	return a.up, a.err
+}
+
+// checkStatusPage queries a status page: a 2xx answer means up and a server error means down
+func (c *Client) checkStatusPage(ctx context.Context, url string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return true, nil
+	case resp.StatusCode >= 500:
+		return false, nil
+	}
+	return false, fmt.Errorf("particeps: status page answered %s", resp.Status)
+}
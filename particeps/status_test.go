@@ -0,0 +1,108 @@
+package particeps
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestProviderAvailable(t *testing.T) {
+	clock := newFakeClock()
+	up := newTextServer(t, "All systems operational")
+	down := statusServer(t, http.StatusServiceUnavailable)
+	c := &Client{StatusURLs: map[int]string{TtmSh: up.URL + "/status", Uguu: down.URL + "/status"}, Clock: clock}
+
+	tests := []struct {
+		provider int
+		up       bool
+	}{
+		{TtmSh, true},
+		{Uguu, false},
+	}
+	for _, tc := range tests {
+		if available, err := c.ProviderAvailable(context.Background(), tc.provider); err != nil || available != tc.up {
+			t.Errorf("provider %d: got %v, %v, want %v", tc.provider, available, err, tc.up)
+		}
+	}
+	if n := len(up.received()); n != 1 {
+		t.Fatalf("status page queried %d times, want once", n)
+	}
+
+	c.ProviderAvailable(context.Background(), TtmSh)
+	if n := len(up.received()); n != 1 {
+		t.Fatalf("status page queried again within %s", statusCacheTTL)
+	}
+	clock.advance(statusCacheTTL + time.Second)
+	c.ProviderAvailable(context.Background(), TtmSh)
+	if n := len(up.received()); n != 2 {
+		t.Fatalf("status page queried %d times, want a fresh check once the cached one expired", n)
+	}
+}
+
+func TestStatusCacheScopedToClient(t *testing.T) {
+	up := newTextServer(t, "All systems operational")
+	c := &Client{StatusURLs: map[int]string{TtmSh: up.URL + "/status"}}
+	for i := 0; i < 2; i++ {
+		c.ProviderAvailable(context.Background(), TtmSh)
+	}
+	if n := len(up.received()); n != 1 {
+		t.Fatalf("status page queried %d times, want the second check cached", n)
+	}
+
+	other := &Client{StatusURLs: c.StatusURLs}
+	other.ProviderAvailable(context.Background(), TtmSh)
+	if n := len(up.received()); n != 2 {
+		t.Fatalf("status page queried %d times, want another Client to check for itself", n)
+	}
+	c.Reset()
+	c.ProviderAvailable(context.Background(), TtmSh)
+	if n := len(up.received()); n != 3 {
+		t.Fatalf("status page queried %d times, want a fresh check after Reset", n)
+	}
+}
+
+func TestProviderAvailablePingFallback(t *testing.T) {
+	tests := []struct {
+		code int
+		up   bool
+	}{
+		{http.StatusOK, true},
+		{http.StatusMethodNotAllowed, true},
+		{http.StatusBadGateway, false},
+	}
+	for _, tc := range tests {
+		srv := statusServer(t, tc.code)
+		c := &Client{Endpoints: map[int]string{TtmSh: srv.URL}}
+		if available, err := c.ProviderAvailable(context.Background(), TtmSh); err != nil || available != tc.up {
+			t.Errorf("endpoint answering %d: got %v, %v, want %v", tc.code, available, err, tc.up)
+		}
+	}
+}
+
+func TestUploadFallbackSkipsUnavailable(t *testing.T) {
+	down := newTextServer(t, "https://a.uguu.se/abc.txt")
+	status := statusServer(t, http.StatusServiceUnavailable)
+	up := newTextServer(t, "https://ttm.sh/abc.txt")
+	c := &Client{
+		Endpoints:       map[int]string{Uguu: down.URL, TtmSh: up.URL},
+		StatusURLs:      map[int]string{Uguu: status.URL},
+		SkipUnavailable: true,
+	}
+	path := writeTestFile(t, "a.txt", []byte("hello"))
+
+	result, err := c.UploadFallbackContext(context.Background(), []int{Uguu, TtmSh}, path)
+	if err != nil || result.Provider != TtmSh || result.Response.FullURL != "https://ttm.sh/abc.txt" {
+		t.Fatalf("got %+v, %v, want the upload on ttm.sh", result, err)
+	}
+	if n := len(down.received()); n != 0 {
+		t.Fatalf("%d uploads sent to the provider that is down", n)
+	}
+
+	c.StatusURLs[TtmSh] = status.URL
+	result, err = c.UploadFallbackContext(context.Background(), []int{Uguu, TtmSh}, path)
+	if !errors.Is(result.Err, ErrProviderUnavailable) {
+		t.Fatalf("with every provider down, got %+v, %v, want ErrProviderUnavailable", result, err)
+	}
+}
@@ -0,0 +1,123 @@
+package particeps
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const streamableURL = "https://api.streamable.com/upload"
+
+// Video status codes reported by Streamable
+const (
+	streamableProcessing = 1
+	streamableReady      = 2
+	streamableError      = 3
+)
+
+// StreamableUpload uploads a video to streamable.com and waits for it to be processed.
+// The account is the Streamable User and Password in the Client's Credentials,
+// or the STREAMABLE_USER and STREAMABLE_PASSWORD environment variables.
+func StreamableUpload(filename string) (UniversalResponse, error) {
+	return Upload(Streamable, filename)
+}
+
+func (c *Client) streamableUpload(u *uploadRequest) (UniversalResponse, error) {
+	var returnValue UniversalResponse
+	returnValue.Status = false
+
+	creds := c.credentials(Streamable)
+	user, password := creds.User, creds.Password
+	if user == "" || password == "" {
+		return returnValue, fmt.Errorf("particeps: no Streamable credentials set")
+	}
+	if err := requireMediaType(u, "video/"); err != nil {
+		return returnValue, err
+	}
+
+	auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password))
+	resp, body, err := c.sendFile(u, nil, http.Header{"Authorization": {auth}})
+	if err != nil {
+		return returnValue, err
+	}
+	var uploaded StreamableVideo
+	if err := json.Unmarshal(body, &uploaded); err != nil {
+		return returnValue, err
+	}
+	u.parsed = &uploaded
+	if ok, err := streamableSuccess(resp, u.parsed); !ok {
+		return returnValue, err
+	}
+
+	returnValue.FullURL = streamablePageURL(u.endpoint, uploaded.Shortcode)
+	if err := c.streamableWait(u.ctx, streamableVideoURL(u.endpoint, uploaded.Shortcode), user, password); err != nil {
+		return returnValue, err
+	}
+	return returnValue, nil
+}
+
+// streamableVideoURL returns the API URL of the video with the given shortcode, under the same root as the
+// upload endpoint, e.g. https://api.streamable.com/videos/abc for https://api.streamable.com/upload
+func streamableVideoURL(endpoint, shortcode string) string {
+	root := strings.TrimSuffix(strings.TrimSuffix(endpoint, "/"), "/upload")
+	return root + "/videos/" + url.PathEscape(shortcode)
+}
+
+// streamablePageURL returns the public page of the video with the given shortcode, on the site whose API the
+// upload endpoint belongs to, e.g. https://streamable.com/abc for https://api.streamable.com/upload
+func streamablePageURL(endpoint, shortcode string) string {
+	root, err := url.Parse(strings.TrimSuffix(strings.TrimSuffix(endpoint, "/"), "/upload"))
+	if err != nil {
+		return "https://streamable.com/" + url.PathEscape(shortcode)
+	}
+	root.Host = strings.TrimPrefix(root.Host, "api.")
+	return strings.TrimSuffix(root.String(), "/") + "/" + url.PathEscape(shortcode)
+}
+
+// streamableWait polls Streamable's video URL until the video is ready, fails to process, or the Client's
+// PollTimeout elapses
+func (c *Client) streamableWait(ctx context.Context, videoURL, user, password string) error {
+	_, err := c.poll(ctx, func() (bool, UniversalResponse, error) {
+		status, err := c.streamableStatus(ctx, videoURL, user, password)
+		if err != nil {
+			return false, UniversalResponse{}, err
+		}
+		switch status {
+		case streamableReady:
+			return true, UniversalResponse{}, nil
+		case streamableError:
+			return false, UniversalResponse{}, fmt.Errorf("particeps: Streamable could not process video %s", videoURL)
+		}
+		return false, UniversalResponse{}, nil
+	})
+	return err
+}
+
+func (c *Client) streamableStatus(ctx context.Context, videoURL, user, password string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", videoURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.SetBasicAuth(user, password)
+	resp, err := c.do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if ok, err := httpSuccess(resp, nil); !ok {
+		return 0, err
+	}
+	body, err := readBody(resp)
+	if err != nil {
+		return 0, err
+	}
+	var video StreamableVideo
+	if err := json.Unmarshal(body, &video); err != nil {
+		return 0, err
+	}
+	return video.Status, nil
+}
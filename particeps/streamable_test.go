@@ -0,0 +1,149 @@
+package particeps
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// smallMP4 is the start of an MP4 file, enough to be sniffed as video/mp4
+var smallMP4 = []byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom")
+
+// streamableServer mocks Streamable, answering status polls with the given statuses in turn
+func streamableServer(t *testing.T, statuses ...int) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	var polls []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "me" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == "POST" && strings.HasSuffix(r.URL.Path, "/upload"):
+			w.Write([]byte(`{"shortcode": "abc12", "status": 1}`))
+		case r.Method == "GET" && strings.HasSuffix(r.URL.Path, "/videos/abc12"):
+			mu.Lock()
+			status := statuses[0]
+			if len(statuses) > 1 {
+				statuses = statuses[1:]
+			}
+			polls = append(polls, r.URL.Path)
+			mu.Unlock()
+			w.Write([]byte(`{"shortcode": "abc12", "status": ` + strconv.Itoa(status) + `}`))
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), polls...)
+	}
+}
+
+func streamableClient() *Client {
+	return &Client{
+		Credentials: map[int]ProviderCredentials{Streamable: {User: "me", Password: "secret"}},
+		Clock:       newFakeClock(),
+	}
+}
+
+func TestStreamableUploadThenPoll(t *testing.T) {
+	srv, polls := streamableServer(t, streamableProcessing, streamableProcessing, streamableReady)
+	c := streamableClient()
+	c.Endpoints = map[int]string{Streamable: srv.URL + "/api/upload"}
+	path := writeTestFile(t, "clip.mp4", smallMP4)
+
+	res, err := c.UploadContext(context.Background(), Streamable, path)
+	if err != nil || !res.Status {
+		t.Fatalf("upload failed: %v %+v", err, res)
+	}
+	if res.FullURL != srv.URL+"/api/abc12" {
+		t.Fatalf("FullURL = %q, want the page under the endpoint's root", res.FullURL)
+	}
+	got := polls()
+	if len(got) != 3 || got[0] != "/api/videos/abc12" {
+		t.Fatalf("polls = %v, want 3 under the endpoint's root", got)
+	}
+}
+
+func TestStreamablePollsUnderWithEndpoint(t *testing.T) {
+	srv, polls := streamableServer(t, streamableReady)
+	path := writeTestFile(t, "clip.mp4", smallMP4)
+
+	if _, err := streamableClient().UploadContext(context.Background(), Streamable, path, WithEndpoint(srv.URL+"/upload")); err != nil {
+		t.Fatal(err)
+	}
+	if got := polls(); len(got) != 1 || got[0] != "/videos/abc12" {
+		t.Fatalf("polls = %v", got)
+	}
+}
+
+func TestStreamablePageURL(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     string
+	}{
+		{streamableURL, "https://streamable.com/abc12"},
+		{"http://127.0.0.1:8080/api/upload/", "http://127.0.0.1:8080/api/abc12"},
+		{"https://api.example.com/v1/upload", "https://example.com/v1/abc12"},
+	}
+	for _, tc := range tests {
+		if got := streamablePageURL(tc.endpoint, "abc12"); got != tc.want {
+			t.Errorf("streamablePageURL(%q) = %q, want %q", tc.endpoint, got, tc.want)
+		}
+	}
+}
+
+func TestStreamableStatusError(t *testing.T) {
+	var polls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			w.Write([]byte(`{"shortcode": "abc12", "status": 1}`))
+			return
+		}
+		polls++
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"status": 2}`)) // must not be taken for a ready video
+	}))
+	t.Cleanup(srv.Close)
+	c := streamableClient()
+	c.Endpoints = map[int]string{Streamable: srv.URL + "/upload"}
+	path := writeTestFile(t, "clip.mp4", smallMP4)
+
+	_, err := c.UploadContext(context.Background(), Streamable, path)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusForbidden {
+		t.Fatalf("err = %v, want the 403 reported", err)
+	}
+	if polls != 1 {
+		t.Fatalf("polled %d times, want to give up on the first error", polls)
+	}
+}
+
+func TestStreamableProcessingError(t *testing.T) {
+	srv, _ := streamableServer(t, streamableProcessing, streamableError)
+	c := streamableClient()
+	c.Endpoints = map[int]string{Streamable: srv.URL + "/upload"}
+	path := writeTestFile(t, "clip.mp4", smallMP4)
+
+	if _, err := c.UploadContext(context.Background(), Streamable, path); err == nil {
+		t.Fatal("want the processing error reported")
+	}
+}
+
+func TestStreamableRefusesNonVideo(t *testing.T) {
+	c := streamableClient()
+	c.Endpoints = map[int]string{Streamable: "http://127.0.0.1:1/upload"}
+	path := writeTestFile(t, "notes.txt", []byte("hello"))
+	if _, err := c.UploadContext(context.Background(), Streamable, path); err == nil {
+		t.Fatal("want a text file refused before being sent")
+	}
+}
@@ -0,0 +1,121 @@
+package particeps
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrProviderRejected is returned when a provider answered but refused the upload
+var ErrProviderRejected = errors.New("particeps: upload rejected by provider")
+
+// StatusError is returned when a provider answers with a non-2xx HTTP status
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return "particeps: provider answered " + e.Status
+}
+
+// successFunc decides whether an upload went through, given the provider's last response
+// and the body as parsed by the provider's upload function. Parsers that had to fall back on recoverURL
+// hand over the recovered URL as a string instead of their usual struct.
+type successFunc func(resp *http.Response, parsed interface{}) (bool, error)
+
+// httpSuccess is the success check shared by every provider: the response must have a 2xx status
+func httpSuccess(resp *http.Response, parsed interface{}) (bool, error) {
+	if resp == nil {
+		return false, fmt.Errorf("%w: no response", ErrProviderRejected)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return false, &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	return true, nil
+}
+
+// urlSuccess is for providers whose parsed body is the URL itself, which must not be empty
+func urlSuccess(resp *http.Response, parsed interface{}) (bool, error) {
+	if ok, err := httpSuccess(resp, parsed); !ok {
+		return false, err
+	}
+	if url, _ := parsed.(string); url == "" {
+		return false, fmt.Errorf("%w: no URL in response", ErrProviderRejected)
+	}
+	return true, nil
+}
+
+func anonFilesSuccess(resp *http.Response, parsed interface{}) (bool, error) {
+	if _, recovered := parsed.(string); recovered {
+		return urlSuccess(resp, parsed)
+	}
+	if failure, ok := parsed.(*AnonFilesFailure); ok {
+		return false, fmt.Errorf("%w: %s", ErrProviderRejected, failure.Error.Message)
+	}
+	if ok, err := httpSuccess(resp, parsed); !ok {
+		return false, err
+	}
+	if success, ok := parsed.(*AnonFilesSuccess); !ok || !success.Status || success.Data.File.URL.Full == "" {
+		return false, fmt.Errorf("%w: no URL in response", ErrProviderRejected)
+	}
+	return true, nil
+}
+
+func filebinSuccess(resp *http.Response, parsed interface{}) (bool, error) {
+	if _, recovered := parsed.(string); recovered {
+		return urlSuccess(resp, parsed)
+	}
+	if ok, err := httpSuccess(resp, parsed); !ok {
+		return false, err
+	}
+	if success, ok := parsed.(*FilebinSuccess); !ok || len(success.Links) < 2 || success.Links[1].Href == "" {
+		return false, fmt.Errorf("%w: no file link in response", ErrProviderRejected)
+	}
+	return true, nil
+}
+
+func kekShSuccess(resp *http.Response, parsed interface{}) (bool, error) {
+	if ok, err := httpSuccess(resp, parsed); !ok {
+		return false, err
+	}
+	if post, ok := parsed.(*KekShPost); !ok || post.Filename == "" && post.Key == "" {
+		return false, fmt.Errorf("%w: no filename in response", ErrProviderRejected)
+	}
+	return true, nil
+}
+
+func imgurSuccess(resp *http.Response, parsed interface{}) (bool, error) {
+	if ok, err := httpSuccess(resp, parsed); !ok {
+		return false, err
+	}
+	success, ok := parsed.(*ImgurSuccess)
+	if ok && !success.Success && success.Data.Error != "" {
+		// Imgur sometimes refuses an upload with a 200 status, explaining why in the body
+		return false, fmt.Errorf("%w: %s", ErrProviderRejected, success.Data.Error)
+	}
+	if !ok || !success.Success || success.Data.Link == "" {
+		return false, fmt.Errorf("%w: no link in response", ErrProviderRejected)
+	}
+	return true, nil
+}
+
+func streamableSuccess(resp *http.Response, parsed interface{}) (bool, error) {
+	if ok, err := httpSuccess(resp, parsed); !ok {
+		return false, err
+	}
+	if video, ok := parsed.(*StreamableVideo); !ok || video.Shortcode == "" {
+		return false, fmt.Errorf("%w: no shortcode in response", ErrProviderRejected)
+	}
+	return true, nil
+}
+
+func imgChestSuccess(resp *http.Response, parsed interface{}) (bool, error) {
+	if ok, err := httpSuccess(resp, parsed); !ok {
+		return false, err
+	}
+	if post, ok := parsed.(*ImgChestPost); !ok || post.Data.Link == "" {
+		return false, fmt.Errorf("%w: no post link in response", ErrProviderRejected)
+	}
+	return true, nil
+}
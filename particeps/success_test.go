@@ -0,0 +1,72 @@
+package particeps
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// successCases holds, for each provider, a response accepting an upload and one refusing it with a 200 status.
+// "{size}" is replaced with the number of bytes received.
+var successCases = []struct {
+	provider         int
+	accept, rejected string
+}{
+	{AnonFiles, `{"status": true, "data": {"file": {"url": {"full": "https://anonfiles.com/abc/a_png"}}}}`,
+		`{"status": false, "error": {"message": "File too large", "type": "ERROR_FILE_SIZE", "code": 31}}`},
+	{BayFiles, `{"status": true, "data": {"file": {"url": {"full": "https://bayfiles.com/abc/a_png"}}}}`,
+		`{"status": false, "error": {"message": "File too large", "type": "ERROR_FILE_SIZE", "code": 31}}`},
+	{Filebin, `{"filename": "a.png", "bytes": {size}, "links": [{"rel": "bin", "href": "https://filebin.net/bin1"}, {"rel": "file", "href": "https://filebin.net/bin1/a.png"}]}`,
+		`{"bin": {"id": "bin1", "readonly": true}, "links": []}`},
+	{Imgur, imgurImageResponse, `{"data": {"error": "File type invalid"}, "success": false, "status": 400}`},
+	{Imagebin, "status:success\nurl:https://ibin.co/abc.png", "status:error\nmessage:bad file"},
+	{PutRe, `{"status": "success", "data": {"link": "https://s.put.re/abc.png"}}`, `{"status": "error", "message": "bad file"}`},
+	{Uguu, `{"success": true, "files": [{"url": "https://a.uguu.se/abc.png"}]}`, `{"success": false, "errorcode": 400}`},
+	{Pixeldrain, `{"success": true, "id": "abc"}`, `{"success": false, "value": "file_too_large"}`},
+	{KekSh, `{"filename": "abc-a.png", "key": "abc", "size": {size}}`, `{"message": "unauthorized"}`},
+	{TransferSh, "https://transfer.sh/abc/a.png", ""},
+	{TtmSh, "https://ttm.sh/abc.png", ""},
+}
+
+func TestSuccessDetection(t *testing.T) {
+	png, _ := pngWithText(t)
+	path := writeTestFile(t, "a.png", png)
+	for _, tc := range successCases {
+		tc := tc
+		t.Run(providers[tc.provider].name, func(t *testing.T) {
+			var answer string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				data, _ := ioutil.ReadAll(r.Body)
+				w.Write([]byte(strings.Replace(answer, "{size}", strconv.Itoa(len(data)), 1)))
+			}))
+			defer srv.Close()
+			c := &Client{
+				Endpoints:   map[int]string{tc.provider: srv.URL},
+				Credentials: map[int]ProviderCredentials{Imgur: {APIKey: "client-id"}},
+			}
+
+			answer = tc.accept
+			res, err := c.UploadContext(context.Background(), tc.provider, path)
+			if err != nil || !res.Status || res.FullURL == "" {
+				t.Fatalf("accepted upload: got %+v, %v", res, err)
+			}
+
+			answer = tc.rejected
+			res, err = c.UploadContext(context.Background(), tc.provider, path)
+			if !errors.Is(err, ErrProviderRejected) || res.Status {
+				t.Fatalf("refused upload: got %+v, %v, want ErrProviderRejected", res, err)
+			}
+
+			srv.Close()
+			res, err = c.UploadContext(context.Background(), tc.provider, path)
+			if err == nil || res.Status {
+				t.Fatalf("unreachable provider: got %+v, %v", res, err)
+			}
+		})
+	}
+}
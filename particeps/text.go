@@ -0,0 +1,71 @@
+package particeps
+
+import (
+	"context"
+	"strings"
+)
+
+// languageExtensions maps language names to the file extensions paste hosts use to pick a syntax highlighter
+var languageExtensions = map[string]string{
+	"":           "txt",
+	"text":       "txt",
+	"plain":      "txt",
+	"log":        "log",
+	"bash":       "sh",
+	"shell":      "sh",
+	"sh":         "sh",
+	"c":          "c",
+	"c++":        "cpp",
+	"cpp":        "cpp",
+	"c#":         "cs",
+	"csharp":     "cs",
+	"css":        "css",
+	"diff":       "diff",
+	"go":         "go",
+	"golang":     "go",
+	"html":       "html",
+	"java":       "java",
+	"javascript": "js",
+	"js":         "js",
+	"json":       "json",
+	"kotlin":     "kt",
+	"lua":        "lua",
+	"markdown":   "md",
+	"php":        "php",
+	"python":     "py",
+	"ruby":       "rb",
+	"rust":       "rs",
+	"sql":        "sql",
+	"swift":      "swift",
+	"toml":       "toml",
+	"typescript": "ts",
+	"xml":        "xml",
+	"yaml":       "yaml",
+}
+
+// textFilename returns the name a snippet in the given language is uploaded under.
+// Unknown languages are taken to be an extension already, such as "zig".
+func textFilename(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	ext, ok := languageExtensions[language]
+	if !ok {
+		ext = strings.TrimPrefix(language, ".")
+	}
+	return "paste." + ext
+}
+
+// UploadText uploads a text or code snippet, named after language so paste hosts highlight it
+func UploadText(provider int, text, language string, opts ...Option) (UniversalResponse, error) {
+	return UploadTextContext(context.Background(), provider, text, language, opts...)
+}
+
+// UploadTextContext is like UploadText, but the upload is bound to ctx
+func UploadTextContext(ctx context.Context, provider int, text, language string, opts ...Option) (UniversalResponse, error) {
+	return DefaultClient.UploadTextContext(ctx, provider, text, language, opts...)
+}
+
+// UploadTextContext uploads a text or code snippet, named after language so paste hosts highlight it
+func (c *Client) UploadTextContext(ctx context.Context, provider int, text, language string, opts ...Option) (UniversalResponse, error) {
+	opts = append([]Option{WithContentType("text/plain; charset=utf-8")}, opts...)
+	return c.UploadReaderContext(ctx, provider, strings.NewReader(text), textFilename(language), opts...)
+}
@@ -0,0 +1,30 @@
+package particeps
+
+import (
+	"context"
+	"testing"
+)
+
+func TestUploadTextLanguage(t *testing.T) {
+	srv := newTextServer(t, "https://transfer.sh/abc/paste.txt")
+	c := &Client{Endpoints: map[int]string{TransferSh: srv.URL}}
+
+	tests := []struct {
+		language, path string
+	}{
+		{"Go", "/paste.go"},
+		{" python ", "/paste.py"},
+		{"c++", "/paste.cpp"},
+		{"", "/paste.txt"},
+		{".zig", "/paste.zig"},
+	}
+	for i, tc := range tests {
+		if _, err := c.UploadTextContext(context.Background(), TransferSh, "fmt.Println(42)", tc.language); err != nil {
+			t.Fatal(err)
+		}
+		req := srv.received()[i]
+		if req.Path != tc.path || req.Header.Get("Content-Type") != "text/plain; charset=utf-8" || string(req.Body) != "fmt.Println(42)" {
+			t.Errorf("language %q: sent %s as %s, want %s as text/plain; charset=utf-8", tc.language, req.Path, req.Header.Get("Content-Type"), tc.path)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package particeps
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const transferShURL = "https://transfer.sh/"
+
+// TransferShUploadBulk uploads several files to transfer.sh in a single request and returns one link
+// to a .tar.gz archive holding all of them
+func TransferShUploadBulk(files []string) (UniversalResponse, error) {
+	return DefaultClient.TransferShUploadBulkContext(context.Background(), files)
+}
+
+// TransferShUploadBulkContext uploads several files to transfer.sh and returns one link to an archive of them
+func (c *Client) TransferShUploadBulkContext(ctx context.Context, files []string) (UniversalResponse, error) {
+	responses, err := c.UploadFilesContext(ctx, TransferSh, files)
+	if err != nil {
+		return UniversalResponse{}, err
+	}
+	archive, err := transferShArchive(responses, ".tar.gz")
+	if err != nil {
+		return UniversalResponse{}, err
+	}
+	return UniversalResponse{Status: true, Provider: TransferSh, FullURL: archive}, nil
+}
+
+// transferShArchive builds the link under which transfer.sh serves the given uploads as one archive,
+// e.g. https://transfer.sh/(token/a.txt,token/b.txt).tar.gz
+func transferShArchive(responses []UniversalResponse, ext string) (string, error) {
+	var base *url.URL
+	paths := make([]string, 0, len(responses))
+	for _, res := range responses {
+		u, err := url.Parse(res.FullURL)
+		if err != nil || u.Host == "" {
+			return "", fmt.Errorf("%w: unexpected transfer.sh link %q", ErrUnexpectedResponse, res.FullURL)
+		}
+		if base == nil {
+			base = u
+		}
+		paths = append(paths, strings.TrimPrefix(u.EscapedPath(), "/"))
+	}
+	if base == nil {
+		return "", fmt.Errorf("particeps: no files to archive")
+	}
+	return base.Scheme + "://" + base.Host + "/(" + strings.Join(paths, ",") + ")" + ext, nil
+}
+
+func (c *Client) transferShUpload(u *uploadRequest) (UniversalResponse, error) {
+	resp, body, err := c.sendFile(u, nil, c.transferShHeader(u))
+	if err != nil {
+		return UniversalResponse{}, err
+	}
+	link := plainTextURL(string(body))
+	u.parsed = link
+	return UniversalResponse{FullURL: link, DeleteURL: resp.Header.Get("X-Url-Delete")}, nil
+}
+
+// transferShHeader returns the headers carrying an upload's options
+func (c *Client) transferShHeader(u *uploadRequest) http.Header {
+	header := http.Header{}
+	if !u.opts.expiresAt.IsZero() {
+		header.Set("Max-Days", strconv.Itoa(expiryDays(u.opts.expiresAt, c.clock().Now())))
+	}
+	return header
+}
+
+func (c *Client) transferShUploadMany(ctx context.Context, endpoint string, files []namedReader) ([]UniversalResponse, error) {
+	u := &uploadRequest{provider: TransferSh, ctx: ctx, endpoint: endpoint}
+	urls, err := c.transferShPost(u, files)
+	if err != nil {
+		return nil, err
+	}
+	if ok, err := httpSuccess(u.resp, nil); !ok {
+		return nil, err
+	}
+	if len(urls) != len(files) {
+		return nil, fmt.Errorf("%w: transfer.sh returned %d links for %d files", ErrUnexpectedResponse, len(urls), len(files))
+	}
+	responses := make([]UniversalResponse, len(urls))
+	for i, link := range urls {
+		responses[i] = UniversalResponse{Status: true, Provider: TransferSh, FullURL: link}
+	}
+	return responses, nil
+}
+
+// transferShPost sends the files in one multipart request and returns their links, one per file, in order
+func (c *Client) transferShPost(u *uploadRequest, files []namedReader) ([]string, error) {
+	// Multi-part Body
+	mpb, contentType, release, err := newMultipartFilesBody("filedata", files)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	req, err := http.NewRequestWithContext(u.ctx, "POST", u.endpoint, mpb)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = c.transferShHeader(u)
+	req.Header.Set("Content-Type", contentType)
+	resp, err := c.send(u, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := readBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	var urls []string
+	for _, line := range strings.Split(string(body), "\n") {
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, "http") {
+			urls = append(urls, line)
+		}
+	}
+	return urls, nil
+}
+
+// expiryDays converts an expiry into the whole number of days, rounded up, that day-based APIs expect
+func expiryDays(expiresAt, now time.Time) int {
+	days := int((expiresAt.Sub(now) + 24*time.Hour - 1) / (24 * time.Hour))
+	if days < 1 {
+		days = 1
+	}
+	return days
+}
@@ -0,0 +1,41 @@
+package particeps
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestTransferShUploadBulk(t *testing.T) {
+	srv, parts, requests := multiFileServer(t)
+	c := &Client{Endpoints: map[int]string{TransferSh: srv.URL}}
+	files := []string{
+		writeTestFile(t, "a.txt", []byte("first")),
+		writeTestFile(t, "b c.txt", []byte("second")),
+	}
+
+	res, err := c.TransferShUploadBulkContext(context.Background(), files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests() != 1 || len(parts()) != 2 {
+		t.Fatalf("sent %d requests with %d parts, want both files in one", requests(), len(parts()))
+	}
+	if want := "https://transfer.sh/(tok/a.txt,tok/b%20c.txt).tar.gz"; !res.Status || res.FullURL != want {
+		t.Fatalf("got %+v, want %s", res, want)
+	}
+}
+
+func TestTransferShArchive(t *testing.T) {
+	if _, err := transferShArchive(nil, ".zip"); err == nil {
+		t.Fatal("archived no files")
+	}
+	responses := []UniversalResponse{{FullURL: "https://transfer.sh/tok/a.txt"}, {FullURL: "not a link"}}
+	if _, err := transferShArchive(responses, ".zip"); !errors.Is(err, ErrUnexpectedResponse) {
+		t.Fatalf("err = %v, want ErrUnexpectedResponse", err)
+	}
+	link, err := transferShArchive(responses[:1], ".zip")
+	if err != nil || link != "https://transfer.sh/(tok/a.txt).zip" {
+		t.Fatalf("got %q, %v", link, err)
+	}
+}
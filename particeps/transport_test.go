@@ -0,0 +1,166 @@
+package particeps
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// connCountingServer is a mock ttm.sh counting the connections made to it
+func connCountingServer(tb testing.TB) (*httptest.Server, *int32) {
+	var conns int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("https://ttm.sh/abc.txt"))
+	}))
+	srv.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	srv.Start()
+	tb.Cleanup(srv.Close)
+	return srv, &conns
+}
+
+func TestSequentialUploadsReuseConnection(t *testing.T) {
+	srv, conns := connCountingServer(t)
+	path := writeTestFile(t, "notes.txt", []byte("hello"))
+	c := &Client{Endpoints: map[int]string{TtmSh: srv.URL}, MaxIdleConnsPerHost: 4}
+	for i := 0; i < 20; i++ {
+		if _, err := c.UploadContext(context.Background(), TtmSh, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := atomic.LoadInt32(conns); n != 1 {
+		t.Fatalf("20 sequential uploads used %d connections, want 1", n)
+	}
+}
+
+func TestMaxIdleConnsPerHost(t *testing.T) {
+	const workers, rounds = 8, 5
+	path := writeTestFile(t, "notes.txt", []byte("hello"))
+	upload := func(c *Client) int32 {
+		srv, conns := connCountingServer(t)
+		c.Endpoints = map[int]string{TtmSh: srv.URL}
+		for round := 0; round < rounds; round++ {
+			var wg sync.WaitGroup
+			for i := 0; i < workers; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if _, err := c.UploadContext(context.Background(), TtmSh, path); err != nil {
+						t.Error(err)
+					}
+				}()
+			}
+			wg.Wait()
+		}
+		return atomic.LoadInt32(conns)
+	}
+
+	// net/http keeps only 2 idle connections per host by default, so most of each round dials again
+	if n := upload(&Client{HTTPClient: &http.Client{Transport: &http.Transport{}}}); n <= workers {
+		t.Fatalf("%d connections with the default idle limit, want more than %d", n, workers)
+	}
+	if n := upload(&Client{MaxIdleConnsPerHost: workers}); n > workers {
+		t.Fatalf("%d connections for %d rounds of %d concurrent uploads, want at most %d", n, rounds, workers, workers)
+	}
+}
+
+func TestClientOwnsTunedTransport(t *testing.T) {
+	a, b := &Client{MaxIdleConnsPerHost: 8}, &Client{MaxIdleConnsPerHost: 8}
+	transport := a.httpClient().Transport
+	if a.httpClient().Transport != transport {
+		t.Fatal("a Client built a new transport for every call")
+	}
+	if b.httpClient().Transport == transport || a.Clone().httpClient().Transport == transport {
+		t.Fatal("another Client shares the transport, so resetting one would drop the other's connections")
+	}
+	a.MaxIdleConnsPerHost = 16
+	if tuned := a.httpClient().Transport.(*http.Transport); tuned == transport || tuned.MaxIdleConnsPerHost != 16 {
+		t.Fatal("the transport wasn't rebuilt when the settings changed")
+	}
+	if (&Client{}).httpClient().Transport != nil {
+		t.Fatal("an untuned Client doesn't use http.DefaultClient as is")
+	}
+}
+
+func benchmarkSmallUploads(b *testing.B, newClient func(endpoint string) *Client) {
+	srv, _ := connCountingServer(b)
+	dir, err := ioutil.TempDir("", "particeps-bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "notes.txt")
+	if err := ioutil.WriteFile(path, []byte("hello"), 0644); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := newClient(srv.URL).UploadContext(context.Background(), TtmSh, path); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkSmallUploadsSharedTransport sends every upload through the same Client, and so the same transport
+func BenchmarkSmallUploadsSharedTransport(b *testing.B) {
+	var c *Client
+	benchmarkSmallUploads(b, func(endpoint string) *Client {
+		if c == nil {
+			c = &Client{Endpoints: map[int]string{TtmSh: endpoint}, MaxIdleConnsPerHost: 4}
+			b.Cleanup(c.Reset)
+		}
+		return c
+	})
+}
+
+// BenchmarkSmallUploadsNewTransport gives every upload its own transport, so each one dials a new connection
+func BenchmarkSmallUploadsNewTransport(b *testing.B) {
+	benchmarkSmallUploads(b, func(endpoint string) *Client {
+		transport := &http.Transport{}
+		b.Cleanup(transport.CloseIdleConnections)
+		return &Client{Endpoints: map[int]string{TtmSh: endpoint}, HTTPClient: &http.Client{Transport: transport}}
+	})
+}
+
+func TestResetClosesOnlyOwnConnections(t *testing.T) {
+	srv, conns := connCountingServer(t)
+	path := writeTestFile(t, "notes.txt", []byte("hello"))
+	get := func() {
+		resp, err := http.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+
+	get()
+	(&Client{}).Reset()
+	(&Client{MaxIdleConnsPerHost: 4}).Reset()
+	get()
+	if n := atomic.LoadInt32(conns); n != 1 {
+		t.Fatalf("%d connections, want http.DefaultClient's kept across other Clients' Reset", n)
+	}
+
+	c := &Client{Endpoints: map[int]string{TtmSh: srv.URL}, MaxIdleConnsPerHost: 4}
+	for i := 0; i < 2; i++ {
+		if _, err := c.UploadContext(context.Background(), TtmSh, path); err != nil {
+			t.Fatal(err)
+		}
+		c.Reset()
+	}
+	if n := atomic.LoadInt32(conns); n != 3 {
+		t.Fatalf("%d connections, want a new one for each upload after Reset", n)
+	}
+}
@@ -0,0 +1,42 @@
+package particeps
+
+import (
+	"strings"
+)
+
+const ttmShURL = "https://ttm.sh/"
+
+// TtmShUpload uploads a file to ttm.sh and returns an UniversalResponse with the upload's data.
+// ttm.sh suits text and logs; the file's contents are sent as the raw request body.
+func TtmShUpload(filename string) (UniversalResponse, error) {
+	return Upload(TtmSh, filename)
+}
+
+// plainTextUpload sends the file, for termbin-style providers that answer with the file's URL as plain text
+func (c *Client) plainTextUpload(u *uploadRequest) (UniversalResponse, error) {
+	var returnValue UniversalResponse
+	returnValue.Status = false
+	resp, body, err := c.sendFile(u, nil, nil)
+	if resp != nil {
+		if res, ok := c.capturedRedirect(resp); ok {
+			return res, nil
+		}
+	}
+	if err != nil {
+		return returnValue, err
+	}
+
+	returnValue.FullURL = plainTextURL(string(body))
+	u.parsed = returnValue.FullURL
+	return returnValue, nil
+}
+
+// plainTextURL returns the first line of a plain-text response that holds a URL, trimmed
+func plainTextURL(body string) string {
+	for _, line := range strings.Split(body, "\n") {
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, "http://") || strings.HasPrefix(line, "https://") {
+			return line
+		}
+	}
+	return ""
+}
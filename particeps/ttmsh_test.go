@@ -0,0 +1,43 @@
+package particeps
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestTtmShUpload(t *testing.T) {
+	srv := newTextServer(t, "https://ttm.sh/aBc.txt\r\n")
+	c := &Client{Endpoints: map[int]string{TtmSh: srv.URL}}
+	path := writeTestFile(t, "build.log", []byte("line one\nline two\n"))
+
+	res, err := c.UploadContext(context.Background(), TtmSh, path)
+	if err != nil || !res.Status || res.FullURL != "https://ttm.sh/aBc.txt" {
+		t.Fatalf("got %+v, %v, want https://ttm.sh/aBc.txt", res, err)
+	}
+	got := srv.received()
+	if len(got) != 1 || got[0].Method != "POST" || string(got[0].Body) != "line one\nline two\n" {
+		t.Fatalf("want the raw file POSTed, got %+v", got)
+	}
+	if ct := got[0].Header.Get("Content-Type"); strings.HasPrefix(ct, "multipart/") {
+		t.Fatalf("sent as %s, want a raw body", ct)
+	}
+}
+
+func TestPlainTextURL(t *testing.T) {
+	tests := []struct {
+		body string
+		url  string
+	}{
+		{"https://ttm.sh/abc.txt", "https://ttm.sh/abc.txt"},
+		{"  http://ttm.sh/abc.txt  \n", "http://ttm.sh/abc.txt"},
+		{"Uploaded!\nhttps://ttm.sh/abc.txt\nhttps://ttm.sh/other\n", "https://ttm.sh/abc.txt"},
+		{"error: file too large\n", ""},
+		{"", ""},
+	}
+	for _, tc := range tests {
+		if got := plainTextURL(tc.body); got != tc.url {
+			t.Errorf("plainTextURL(%q) = %q, want %q", tc.body, got, tc.url)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package particeps
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const gofileURL = "https://api.gofile.io/servers"
+
+// TwoPhaseProvider describes a host that hands out an upload URL, or a token to build one from, in answer to an
+// initial request, and takes the file at that URL. The initial request is a JSON POST of
+// {"filename", "size", "content_type"} to the endpoint, or a plain GET if InitMethod is "GET".
+// The file's URL is then read from the initial response, from the upload's response or built from the token.
+// All paths are dot-separated paths into JSON responses, as in SimpleJSONProvider.
+type TwoPhaseProvider struct {
+	Name       string
+	URL        string // Endpoint of the initial request
+	InitMethod string // HTTP method of the initial request, POST if empty
+	// UploadURLPath is the path to the URL the file is sent to, in the initial response.
+	// Relative URLs are resolved against the endpoint.
+	UploadURLPath string
+	// TokenPath is the path to a token in the initial response, which replaces "{token}" in UploadURL and URLTemplate
+	TokenPath string
+	// UploadURL builds the URL the file is sent to from the token when there is no UploadURLPath,
+	// e.g. "https://{token}.host/upload"
+	UploadURL string
+	Method    string // HTTP method the file is sent with, PUT if empty
+	Raw       bool   // Send the file as the whole request body, as presigned URLs expect, instead of as a multipart form
+	Field     string // Form field holding the file, for multipart forms
+	// FileURLPath is the path to the file's URL in the initial response, for hosts announcing it up front
+	FileURLPath string
+	// JSONPath is the path to the file's URL in the upload's response, when there is no FileURLPath
+	JSONPath string
+	// URLTemplate builds the file's URL from the token when neither FileURLPath nor JSONPath is set,
+	// e.g. "https://host/d/{token}"
+	URLTemplate string
+	// Capabilities describes what the host supports
+	Capabilities Capabilities
+}
+
+// Register registers p and returns the constant to upload to it with
+func (p TwoPhaseProvider) Register() int {
+	return registerProvider(p.def())
+}
+
+// def returns the provider definition of p
+func (p TwoPhaseProvider) def() *providerDef {
+	method := p.Method
+	if method == "" {
+		method = "PUT"
+	}
+	return &providerDef{
+		name:     p.Name,
+		endpoint: p.URL,
+		caps:     p.Capabilities,
+		shape:    requestShape{method: method, raw: p.Raw, field: p.Field},
+		upload:   p.upload,
+		success:  urlSuccess,
+	}
+}
+
+func (p TwoPhaseProvider) upload(c *Client, u *uploadRequest) (UniversalResponse, error) {
+	var returnValue UniversalResponse
+	returnValue.Status = false
+
+	contentType := u.opts.contentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(u.filename))
+	}
+	initial, err := p.init(c, u, contentType)
+	if err != nil {
+		return returnValue, err
+	}
+	token := jsonPathString(initial, p.TokenPath)
+	uploadURL := jsonPathString(initial, p.UploadURLPath)
+	if uploadURL == "" && p.UploadURL != "" && token != "" {
+		uploadURL = strings.Replace(p.UploadURL, "{token}", url.PathEscape(token), -1)
+	}
+	if uploadURL == "" {
+		return returnValue, fmt.Errorf("%w: no upload URL in response", ErrUnexpectedResponse)
+	}
+	base, err := url.Parse(u.endpoint)
+	if err != nil {
+		return returnValue, err
+	}
+	ref, err := url.Parse(uploadURL)
+	if err != nil {
+		return returnValue, fmt.Errorf("%w: upload URL %q: %v", ErrUnexpectedResponse, uploadURL, err)
+	}
+	u.endpoint = base.ResolveReference(ref).String()
+
+	var header http.Header
+	if p.Raw && contentType != "" {
+		header = http.Header{"Content-Type": {contentType}} // presigned URLs are often signed for it
+	}
+	resp, body, err := c.sendFile(u, nil, header)
+	if resp != nil {
+		if res, ok := c.capturedRedirect(resp); ok {
+			return res, nil
+		}
+	}
+	if err != nil {
+		return returnValue, err
+	}
+
+	switch {
+	case p.FileURLPath != "":
+		returnValue.FullURL = jsonPathString(initial, p.FileURLPath)
+	case p.JSONPath != "":
+		var parsed interface{}
+		if err := json.Unmarshal(body, &parsed); err != nil {
+			return returnValue, err
+		}
+		returnValue.FullURL = jsonPathString(parsed, p.JSONPath)
+	case p.URLTemplate != "" && token != "":
+		returnValue.FullURL = strings.Replace(p.URLTemplate, "{token}", url.PathEscape(token), -1)
+	}
+	u.parsed = returnValue.FullURL
+	return returnValue, nil
+}
+
+// init sends the initial request and returns its decoded JSON response
+func (p TwoPhaseProvider) init(c *Client, u *uploadRequest, contentType string) (interface{}, error) {
+	method := p.InitMethod
+	if method == "" {
+		method = "POST"
+	}
+	var req *http.Request
+	var err error
+	if method == "GET" {
+		req, err = http.NewRequestWithContext(u.ctx, method, u.endpoint, nil)
+	} else {
+		var data []byte
+		data, err = json.Marshal(map[string]interface{}{"filename": u.filename, "size": readerSize(u.r), "content_type": contentType})
+		if err != nil {
+			return nil, err
+		}
+		req, err = http.NewRequestWithContext(u.ctx, method, u.endpoint, bytes.NewReader(data))
+		if req != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.send(u, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := readBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	if ok, err := httpSuccess(resp, nil); !ok {
+		return nil, err
+	}
+	var parsed interface{}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, err
+	}
+	return parsed, nil
+}
+
+// jsonPathString returns the string or number found at path in decoded JSON, as a string
+func jsonPathString(value interface{}, path string) string {
+	if path == "" {
+		return ""
+	}
+	switch v := lookupJSONPath(value, path).(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
@@ -0,0 +1,91 @@
+package particeps
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTwoPhaseUploadURL(t *testing.T) {
+	var initial map[string]interface{}
+	var put recordedRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/init":
+			if r.Method != "POST" {
+				t.Errorf("initial request sent with %s", r.Method)
+			}
+			json.NewDecoder(r.Body).Decode(&initial)
+			w.Write([]byte(`{"upload": {"url": "put/xyz?sig=abc"}, "file": {"url": "https://files.example/xyz"}}`))
+		case "/put/xyz":
+			body, _ := ioutil.ReadAll(r.Body)
+			put = recordedRequest{r.Method, r.URL.RequestURI(), r.Header.Clone(), body, r.ContentLength}
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+	provider := TwoPhaseProvider{Name: "presigned", URL: srv.URL + "/init", UploadURLPath: "upload.url", Raw: true,
+		FileURLPath: "file.url"}.Register()
+
+	res, err := (&Client{}).UploadReaderContext(context.Background(), provider, strings.NewReader("hello"), "notes.txt")
+	if err != nil || !res.Status || res.FullURL != "https://files.example/xyz" {
+		t.Fatalf("got %+v, %v", res, err)
+	}
+	if initial["filename"] != "notes.txt" || initial["size"] != 5.0 || !strings.HasPrefix(initial["content_type"].(string), "text/plain") {
+		t.Fatalf("initial request = %v", initial)
+	}
+	if put.Method != "PUT" || put.Path != "/put/xyz?sig=abc" || string(put.Body) != "hello" {
+		t.Fatalf("file sent as %s %s holding %q", put.Method, put.Path, put.Body)
+	}
+	if ct := put.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Fatalf("file sent as %s, want the type the initial request announced", ct)
+	}
+}
+
+func TestTwoPhaseToken(t *testing.T) {
+	var initMethod string
+	var uploaded []string
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("no file part: %v", err)
+			return
+		}
+		data, _ := ioutil.ReadAll(file)
+		uploaded = append(uploaded, r.Method+" "+r.URL.Path+" "+header.Filename+" "+string(data))
+		w.Write([]byte(`{"status": "ok", "data": {"downloadPage": "https://gofile.example/d/Ab12"}}`))
+	}))
+	defer storage.Close()
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		initMethod = r.Method
+		w.Write([]byte(`{"status": "ok", "data": {"servers": [{"name": "store7"}]}}`))
+	}))
+	defer api.Close()
+	provider := TwoPhaseProvider{Name: "gofile-like", URL: api.URL, InitMethod: "GET", TokenPath: "data.servers.0.name",
+		UploadURL: storage.URL + "/{token}/uploadfile", Method: "POST", Field: "file", JSONPath: "data.downloadPage"}.Register()
+
+	res, err := (&Client{}).UploadReaderContext(context.Background(), provider, strings.NewReader("hello"), "notes.txt")
+	if err != nil || res.FullURL != "https://gofile.example/d/Ab12" {
+		t.Fatalf("got %+v, %v", res, err)
+	}
+	if initMethod != "GET" || len(uploaded) != 1 || uploaded[0] != "POST /store7/uploadfile notes.txt hello" {
+		t.Fatalf("initial request sent with %s, then %v", initMethod, uploaded)
+	}
+}
+
+func TestTwoPhaseNoUploadURL(t *testing.T) {
+	srv := newTextServer(t, `{"status": "error"}`)
+	provider := TwoPhaseProvider{Name: "two-phase-broken", URL: srv.URL, UploadURLPath: "upload.url", FileURLPath: "file.url"}.Register()
+	if _, err := (&Client{}).UploadReaderContext(context.Background(), provider, strings.NewReader("hello"), "a.txt"); !errors.Is(err, ErrUnexpectedResponse) {
+		t.Fatalf("err = %v, want ErrUnexpectedResponse", err)
+	}
+	if n := len(srv.received()); n != 1 {
+		t.Fatalf("%d requests, want only the initial one", n)
+	}
+}
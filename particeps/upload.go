@@ -0,0 +1,382 @@
+package particeps
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// uploadRequest is a single upload, as handed to a provider's upload function
+type uploadRequest struct {
+	provider int
+	ctx      context.Context
+	r        io.Reader
+	filename string       // Name sent to the provider, without any directory
+	endpoint string       // URL the file is sent to
+	shape    requestShape // How the provider expects the file to be sent
+	opts     uploadOptions
+	resp     *http.Response // Last response received from the provider, set by Client.send
+	parsed   interface{}    // Response body as parsed by the provider, handed to its success check
+}
+
+// endpoint picks the URL to upload to: the per-call WithEndpoint override,
+// then the Client's Endpoints, then the provider's default
+func (c *Client) endpoint(provider int, def *providerDef, o uploadOptions) string {
+	if o.endpoint != "" {
+		return o.endpoint
+	}
+	if endpoint, ok := c.Endpoints[provider]; ok && endpoint != "" {
+		return endpoint
+	}
+	return def.endpoint
+}
+
+// UploadReader uploads the contents of r to the given provider under the given filename
+func UploadReader(provider int, r io.Reader, filename string, opts ...Option) (UniversalResponse, error) {
+	return DefaultClient.UploadReaderContext(context.Background(), provider, r, filename, opts...)
+}
+
+// UploadBytes uploads data to the given provider under the given filename
+func UploadBytes(provider int, data []byte, filename string, opts ...Option) (UniversalResponse, error) {
+	return DefaultClient.UploadBytes(provider, data, filename, opts...)
+}
+
+// UploadBytes uploads data to the given provider under the given filename
+func (c *Client) UploadBytes(provider int, data []byte, filename string, opts ...Option) (UniversalResponse, error) {
+	return c.UploadReaderContext(context.Background(), provider, bytes.NewReader(data), filename, opts...)
+}
+
+// UploadReaderContext is like UploadReader, but the upload is bound to ctx
+func UploadReaderContext(ctx context.Context, provider int, r io.Reader, filename string, opts ...Option) (UniversalResponse, error) {
+	return DefaultClient.UploadReaderContext(ctx, provider, r, filename, opts...)
+}
+
+// UploadReaderContext uploads the contents of r to the given provider under the given filename
+func (c *Client) UploadReaderContext(ctx context.Context, provider int, r io.Reader, filename string, opts ...Option) (UniversalResponse, error) {
+	res, _, err := c.upload(ctx, provider, r, filename, opts)
+	return res, err
+}
+
+// upload runs a single upload, also returning the provider's last HTTP response if one was received.
+// The response's body has already been consumed and closed.
+func (c *Client) upload(ctx context.Context, provider int, r io.Reader, filename string, opts []Option) (UniversalResponse, *http.Response, error) {
+	def, err := lookupProvider(provider)
+	if err != nil {
+		return UniversalResponse{}, nil, err
+	}
+	ctx, done, err := c.begin(ctx)
+	if err != nil {
+		return UniversalResponse{}, nil, err
+	}
+	defer done()
+	if size, min := readerSize(r), c.minFileSize(); size >= 0 && size < min {
+		return UniversalResponse{}, nil, fmt.Errorf("%w: %s is %d bytes", ErrFileTooSmall, filename, size)
+	}
+	if size, max := readerSize(r), def.caps.maxSize(); size >= 0 && max > 0 && size > max {
+		return UniversalResponse{}, nil, fmt.Errorf("%w: %s is %d bytes, %s takes up to %d", ErrFileTooLarge, filename, size, def.name, max)
+	}
+	if err := checkVideoDuration(r, filename, def); err != nil {
+		return UniversalResponse{}, nil, err
+	}
+	o := newUploadOptions(opts)
+	if o.sha256 != "" && !validSHA256(o.sha256) {
+		return UniversalResponse{}, nil, fmt.Errorf("particeps: invalid SHA-256 %q", o.sha256)
+	}
+	if o.password != "" && !def.caps.Password {
+		return UniversalResponse{}, nil, fmt.Errorf("%w: %s", ErrPasswordUnsupported, def.name)
+	}
+	if err := resolveExpiry(def, &o, c.clock().Now()); err != nil {
+		return UniversalResponse{}, nil, err
+	}
+	if o.remoteName != "" {
+		filename = o.remoteName
+	}
+	if tmpl := c.filenameTemplate(provider, o); tmpl != "" {
+		if filename, err = expandFilenameTemplate(tmpl, filename, r, o.sha256, c.clock().Now()); err != nil {
+			return UniversalResponse{}, nil, err
+		}
+	}
+	if o.idempotent && o.idempotencyKey == "" {
+		if o.idempotencyKey, err = newUUID(); err != nil {
+			return UniversalResponse{}, nil, err
+		}
+	}
+	ctx, cancel := o.context(ctx)
+	defer cancel()
+	u := &uploadRequest{
+		provider: provider,
+		ctx:      ctx,
+		r:        r,
+		filename: c.sanitizeFilename(remoteFilename(filename)),
+		endpoint: c.endpoint(provider, def, o),
+		shape:    def.shape,
+		opts:     o,
+	}
+	if err := c.stripMetadata(u); err != nil {
+		return UniversalResponse{}, nil, err
+	}
+	// Both digests are computed while the provider reads the body, so the data is only read once.
+	// A SHA-256 given by WithSHA256 is trusted unless it is to be verified, or the file was altered.
+	md5Hash, sha256Hash, counted := md5.New(), sha256.New(), &countingWriter{}
+	hashes := []io.Writer{md5Hash, counted}
+	knownSHA256 := o.sha256 != "" && !o.verifySHA256 && !c.StripMetadata
+	if !knownSHA256 {
+		hashes = append(hashes, sha256Hash)
+	}
+	u.r = withSize(io.TeeReader(u.r, io.MultiWriter(hashes...)), readerSize(u.r))
+	if o.progress != nil {
+		size := readerSize(u.r)
+		u.r = withSize(newProgressReader(u.r, size, o.progress, o.progressInterval, c.clock()), size)
+	}
+	start := c.clock().Now()
+	var compressed io.Closer
+	if o.gzip {
+		if compressed, err = gzipUpload(u); err != nil {
+			return UniversalResponse{}, nil, err
+		}
+		if compressed != nil {
+			defer compressed.Close()
+		}
+	}
+	res, err := def.upload(c, u)
+	res.Provider = provider
+	res.Labels = o.labels
+	res.Compressed = compressed != nil
+	res.Size, res.Duration = counted.n, c.clock().Now().Sub(start)
+	res.IdempotencyKey = o.idempotencyKey
+	if u.resp != nil && u.resp.StatusCode == http.StatusTooManyRequests {
+		limited := rateLimited(u.resp, c.clock().Now())
+		var reported *ErrRateLimited
+		if errors.As(err, &reported) && limited.Reset.IsZero() {
+			limited.Reset = reported.Reset // e.g. from Imgur's X-RateLimit-UserReset
+		}
+		res.Status, err = false, limited
+	} else if err == nil && res.Location == "" {
+		res.Status, err = def.isSuccess(u.resp, u.parsed)
+	}
+	if res.Status && res.ExpiresAt.IsZero() {
+		res.ExpiresAt = o.expiresAt
+	}
+	if res.Status {
+		res.MD5 = hex.EncodeToString(md5Hash.Sum(nil))
+		res.SHA256 = hex.EncodeToString(sha256Hash.Sum(nil))
+		if knownSHA256 {
+			res.SHA256 = o.sha256
+		}
+		if err = resolveResponseURLs(u.endpoint, &res); err != nil {
+			res.Status = false
+		} else {
+			err = c.transformURLs(provider, &res)
+		}
+	}
+	if err == nil && res.Status && o.verifySHA256 && o.sha256 != "" && res.SHA256 != o.sha256 {
+		err = fmt.Errorf("%w: sent %s, expected %s", ErrDigestMismatch, res.SHA256, o.sha256)
+	}
+	if err == nil && res.Status && res.StoredSize > 0 && !res.Compressed && res.StoredSize != res.Size {
+		// The upload is kept as a success, so the caller still gets the URL along with the error
+		err = fmt.Errorf("%w: sent %d bytes, %s stored %d", ErrSizeMismatch, res.Size, def.name, res.StoredSize)
+	}
+	if err == nil && res.Status {
+		c.shorten(ctx, &res)
+	}
+	return res, u.resp, err
+}
+
+// ErrFileTooSmall is returned when uploading a file smaller than the Client's MinFileSize, e.g. an empty file
+var ErrFileTooSmall = errors.New("particeps: file is too small to upload")
+
+// ErrFileTooLarge is returned when uploading a file larger than the provider's MaxFileSize
+var ErrFileTooLarge = errors.New("particeps: file is too large for provider")
+
+// ErrVideoTooLong is returned when uploading a video longer than the provider's MaxVideoDuration
+var ErrVideoTooLong = errors.New("particeps: video is too long for provider")
+
+// ErrSizeMismatch is returned when a provider reports storing a different number of bytes than were sent,
+// meaning the upload was truncated or corrupted. The UniversalResponse still holds the upload's URL.
+var ErrSizeMismatch = errors.New("particeps: provider stored a different size than was sent")
+
+// ErrDigestMismatch is returned when the contents sent don't match the SHA-256 given by WithSHA256
+var ErrDigestMismatch = errors.New("particeps: contents don't match the given SHA-256")
+
+// validSHA256 reports whether digest is a lowercase hex SHA-256
+func validSHA256(digest string) bool {
+	if len(digest) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(digest)
+	return err == nil
+}
+
+// newUUID returns a random (version 4) UUID
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// ErrPasswordUnsupported is returned when asking for a password on a provider that can't protect uploads
+var ErrPasswordUnsupported = errors.New("particeps: provider doesn't support password protection")
+
+// ErrExpiryUnsupported is returned when asking for an expiry on a provider that can't set one
+var ErrExpiryUnsupported = errors.New("particeps: provider doesn't support setting an expiry")
+
+// ErrInvalidExpiry is returned when the requested expiry is in the past or beyond what the provider allows
+var ErrInvalidExpiry = errors.New("particeps: invalid expiry")
+
+// resolveExpiry turns a relative WithExpiry into an absolute time and checks it against the provider's limits
+func resolveExpiry(def *providerDef, o *uploadOptions, now time.Time) error {
+	if o.expiry == 0 && o.expiresAt.IsZero() {
+		return nil
+	}
+	if !def.caps.SetExpiry {
+		return fmt.Errorf("%w: %s", ErrExpiryUnsupported, def.name)
+	}
+	if o.expiresAt.IsZero() {
+		o.expiresAt = now.Add(o.expiry)
+	}
+	if !o.expiresAt.After(now) {
+		return fmt.Errorf("%w: %s is not in the future", ErrInvalidExpiry, o.expiresAt.Format(time.RFC3339))
+	}
+	if max := def.caps.MaxExpiry; max > 0 && o.expiresAt.Sub(now) > max {
+		return fmt.Errorf("%w: %s keeps uploads for at most %s", ErrInvalidExpiry, def.name, max)
+	}
+	return nil
+}
+
+// minFileSize returns the size, in bytes, below which uploads are refused
+func (c *Client) minFileSize() int64 {
+	if c.MinFileSize == 0 {
+		return 1
+	}
+	return c.MinFileSize
+}
+
+// ErrInvalidResponseURL is returned when a provider reports a link that isn't a valid URL
+var ErrInvalidResponseURL = errors.New("particeps: provider returned an invalid URL")
+
+// resolveResponseURLs checks that res's links are well-formed, absolute http(s) URLs,
+// resolving relative ones, such as "/file/abc.png", against the endpoint the file was uploaded to
+func resolveResponseURLs(endpoint string, res *UniversalResponse) error {
+	base, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+	for _, link := range []*string{&res.FullURL, &res.ShortURL} {
+		if *link == "" {
+			continue
+		}
+		ref, err := url.Parse(strings.TrimSpace(*link))
+		if err != nil {
+			return fmt.Errorf("%w: %q", ErrInvalidResponseURL, *link)
+		}
+		resolved := base.ResolveReference(ref)
+		if (resolved.Scheme != "http" && resolved.Scheme != "https") || resolved.Host == "" {
+			return fmt.Errorf("%w: %q", ErrInvalidResponseURL, *link)
+		}
+		*link = resolved.String()
+	}
+	return nil
+}
+
+// transformURLs rewrites res's URLs with the Client's URLTransform, keeping the original FullURL in RawURL
+func (c *Client) transformURLs(provider int, res *UniversalResponse) error {
+	if c.URLTransform == nil {
+		return nil
+	}
+	res.RawURL = res.FullURL
+	full, err := c.URLTransform(provider, res.FullURL)
+	if err != nil {
+		return err
+	}
+	res.FullURL = full
+	if res.ShortURL != "" {
+		if res.ShortURL, err = c.URLTransform(provider, res.ShortURL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// countingWriter counts the bytes written to it
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// Upload uploads the given file to the given provider
+func Upload(provider int, filename string, opts ...Option) (UniversalResponse, error) {
+	return UploadContext(context.Background(), provider, filename, opts...)
+}
+
+// UploadContext is like Upload, but the upload is bound to ctx
+func UploadContext(ctx context.Context, provider int, filename string, opts ...Option) (UniversalResponse, error) {
+	return DefaultClient.UploadContext(ctx, provider, filename, opts...)
+}
+
+// UploadContext uploads the given file to the given provider
+func (c *Client) UploadContext(ctx context.Context, provider int, filename string, opts ...Option) (UniversalResponse, error) {
+	res, _, err := c.uploadFile(ctx, provider, filename, opts)
+	return res, err
+}
+
+// uploadFile is like upload, but reads from the given file
+func (c *Client) uploadFile(ctx context.Context, provider int, filename string, opts []Option) (UniversalResponse, *http.Response, error) {
+	f, filename, err := c.openFile(filename)
+	if err != nil {
+		return UniversalResponse{}, nil, err
+	}
+	defer f.Close()
+
+	var digest string
+	if c.CacheDir != "" {
+		// A digest that is to be verified can't be trusted to look up a previous upload
+		if o := newUploadOptions(opts); !o.verifySHA256 {
+			digest = o.sha256
+		}
+		if !validSHA256(digest) {
+			if digest, err = hashFile(f); err != nil {
+				return UniversalResponse{}, nil, err
+			}
+		}
+		if c.ReuseIfUploaded {
+			res, ok, err := c.cachedUpload(ctx, provider, digest)
+			if err != nil {
+				return UniversalResponse{}, nil, err
+			}
+			if ok {
+				res.Labels = newUploadOptions(opts).labels
+				return res, nil, nil
+			}
+		}
+	}
+
+	res, resp, err := c.upload(ctx, provider, f, filename, opts)
+	if info, statErr := f.Stat(); statErr == nil {
+		res.ModTime = info.ModTime()
+	}
+	if err == nil && res.Status && c.SpotCheckVerify && !c.StripMetadata && !res.Compressed {
+		err = c.spotCheck(ctx, res.FullURL, f)
+	}
+	if err == nil && res.Status && digest != "" {
+		c.storeUpload(provider, digest, res) // the upload itself went through, so a cache write failure isn't fatal
+	}
+	return res, resp, err
+}
@@ -0,0 +1,404 @@
+package particeps
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithSHA256SkipsHashing(t *testing.T) {
+	data := []byte(strings.Repeat("particeps ", 1000))
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+	path := writeTestFile(t, "notes.txt", data)
+	srv := newTextServer(t, "https://ttm.sh/abc.txt")
+
+	fs := &countingFS{}
+	c := &Client{
+		FS:                fs,
+		CacheDir:          tempDir(t),
+		Endpoints:         map[int]string{TtmSh: srv.URL},
+		FilenameTemplates: map[int]string{TtmSh: "{hash}{ext}"},
+	}
+	res, err := c.UploadContext(context.Background(), TtmSh, path, WithSHA256(strings.ToUpper(digest)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read := fs.bytesRead(); read != int64(len(data)) {
+		t.Fatalf("read %d bytes of a %d byte file, want it read only once, to upload it", read, len(data))
+	}
+	if res.SHA256 != digest {
+		t.Fatalf("SHA256 = %s, want %s", res.SHA256, digest)
+	}
+
+	// Without the digest, the file is hashed for the cache and the template before being uploaded
+	fs2 := &countingFS{}
+	c.FS = fs2
+	if _, err := c.UploadContext(context.Background(), TtmSh, path); err != nil {
+		t.Fatal(err)
+	}
+	if read := fs2.bytesRead(); read <= int64(len(data)) {
+		t.Fatalf("read %d bytes without a digest, want more than the %d uploaded", read, len(data))
+	}
+}
+
+func TestWithVerifySHA256(t *testing.T) {
+	srv := newTextServer(t, "https://ttm.sh/abc.txt")
+	c := &Client{Endpoints: map[int]string{TtmSh: srv.URL}}
+	sum := sha256.Sum256([]byte("hello"))
+	digest := hex.EncodeToString(sum[:])
+
+	res, err := c.UploadReaderContext(context.Background(), TtmSh, strings.NewReader("hello"), "a.txt",
+		WithSHA256(strings.Repeat("0", 64)), WithVerifySHA256())
+	if !errors.Is(err, ErrDigestMismatch) {
+		t.Fatalf("err = %v, want ErrDigestMismatch", err)
+	}
+	if res.FullURL == "" || res.SHA256 != digest {
+		t.Fatalf("want the URL and actual digest still reported, got %+v", res)
+	}
+	if _, err := c.UploadReaderContext(context.Background(), TtmSh, strings.NewReader("hello"), "a.txt",
+		WithSHA256(digest), WithVerifySHA256()); err != nil {
+		t.Fatalf("matching digest: %v", err)
+	}
+	if _, err := c.UploadReaderContext(context.Background(), TtmSh, strings.NewReader("hello"), "a.txt",
+		WithSHA256("not-a-digest")); err == nil {
+		t.Fatal("want an invalid digest refused")
+	}
+}
+
+func TestVerifiedDigestNotTrustedForCache(t *testing.T) {
+	srv := newTextServer(t, "https://ttm.sh/first.txt")
+	c := &Client{Endpoints: map[int]string{TtmSh: srv.URL}, CacheDir: tempDir(t), ReuseIfUploaded: true}
+	first := writeTestFile(t, "first.txt", []byte("first file"))
+	if _, err := c.UploadContext(context.Background(), TtmSh, first); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte("first file"))
+	wrong := hex.EncodeToString(sum[:]) // the first file's digest, claimed for another file
+
+	second := writeTestFile(t, "second.txt", []byte("second file"))
+	res, err := c.UploadContext(context.Background(), TtmSh, second, WithSHA256(wrong), WithVerifySHA256())
+	if res.Cached {
+		t.Fatal("the first file's cached upload was returned for the second file")
+	}
+	if !errors.Is(err, ErrDigestMismatch) {
+		t.Fatalf("err = %v, want ErrDigestMismatch", err)
+	}
+	if n := len(srv.received()); n != 2 {
+		t.Fatalf("provider got %d uploads, want 2", n)
+	}
+}
+
+func TestModTimeRecorded(t *testing.T) {
+	srv := newTextServer(t, "https://ttm.sh/abc.txt")
+	c := &Client{Endpoints: map[int]string{TtmSh: srv.URL}}
+	path := writeTestFile(t, "a.txt", []byte("hello"))
+	modTime := time.Date(2020, 7, 14, 9, 30, 0, 0, time.UTC)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := c.UploadContext(context.Background(), TtmSh, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.ModTime.Equal(modTime) {
+		t.Fatalf("ModTime = %v, want %v", res.ModTime, modTime)
+	}
+	var report bytes.Buffer
+	if err := WriteReport(&report, []ProviderResult{{Provider: TtmSh, Filename: path, Response: res}}, "json"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(report.String(), `"mod_time": "2020-07-14T09:30:00Z"`) {
+		t.Fatalf("report lacks the modification time:\n%s", report.String())
+	}
+
+	res, err = c.UploadReaderContext(context.Background(), TtmSh, strings.NewReader("hello"), "a.txt")
+	if err != nil || !res.ModTime.IsZero() {
+		t.Fatalf("ModTime = %v for an upload not read from disk", res.ModTime)
+	}
+}
+
+func TestWithEndpoint(t *testing.T) {
+	mirror := newTextServer(t, `{"success": true, "files": [{"url": "https://mirror.example/abc.txt"}]}`)
+	configured := newTextServer(t, `{"success": true, "files": [{"url": "https://a.uguu.se/abc.txt"}]}`)
+	c := &Client{Endpoints: map[int]string{Uguu: configured.URL}}
+
+	res, err := c.UploadReaderContext(context.Background(), Uguu, strings.NewReader("hello"), "a.txt", WithEndpoint(mirror.URL))
+	if err != nil || res.FullURL != "https://mirror.example/abc.txt" {
+		t.Fatalf("got %+v, %v, want the mirror's answer parsed as Uguu's", res, err)
+	}
+	if len(mirror.received()) != 1 || len(configured.received()) != 0 {
+		t.Fatal("the upload didn't go to the mirror only")
+	}
+
+	res, err = c.UploadReaderContext(context.Background(), Uguu, strings.NewReader("hello"), "a.txt")
+	if err != nil || res.FullURL != "https://a.uguu.se/abc.txt" || len(configured.received()) != 1 {
+		t.Fatalf("got %+v, %v, want the next upload to use the Client's endpoint again", res, err)
+	}
+	if c.Endpoints[Uguu] != configured.URL {
+		t.Fatal("WithEndpoint changed the Client's endpoints")
+	}
+}
+
+func TestUploadDigests(t *testing.T) {
+	const (
+		helloMD5    = "5d41402abc4b2a76b9719d911017c592"
+		helloSHA256 = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	)
+	path := writeTestFile(t, "hello.txt", []byte("hello"))
+	ttm := newTextServer(t, "https://ttm.sh/abc.txt")
+	uguu := newTextServer(t, `{"success": true, "files": [{"url": "https://a.uguu.se/abc.txt"}]}`)
+	fs := &countingFS{}
+	c := &Client{FS: fs, Endpoints: map[int]string{TtmSh: ttm.URL, Uguu: uguu.URL}}
+
+	for _, provider := range []int{TtmSh, Uguu} {
+		before := fs.bytesRead()
+		res, err := c.UploadContext(context.Background(), provider, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.MD5 != helloMD5 || res.SHA256 != helloSHA256 {
+			t.Fatalf("provider %d: MD5 = %s, SHA256 = %s, want %s and %s", provider, res.MD5, res.SHA256, helloMD5, helloSHA256)
+		}
+		if read := fs.bytesRead() - before; read != 5 {
+			t.Fatalf("provider %d: read %d bytes of a 5 byte file, want both digests computed while uploading", provider, read)
+		}
+	}
+}
+
+func TestPerUploadTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(150 * time.Millisecond)
+		w.Write([]byte("https://ttm.sh/abc.txt"))
+	}))
+	t.Cleanup(srv.Close)
+	c := &Client{HTTPClient: &http.Client{Timeout: 50 * time.Millisecond}, Endpoints: map[int]string{TtmSh: srv.URL}}
+	path := writeTestFile(t, "notes.txt", []byte("hello"))
+
+	if _, err := c.UploadContext(context.Background(), TtmSh, path); err == nil {
+		t.Fatal("the slow upload beat the Client's timeout")
+	}
+	if _, err := c.UploadContext(context.Background(), TtmSh, path, WithTimeout(5*time.Second)); err != nil {
+		t.Fatalf("err = %v, want the longer per-upload timeout to allow the slow upload", err)
+	}
+	if _, err := c.UploadContext(context.Background(), TtmSh, path, WithDeadline(time.Now().Add(5*time.Second))); err != nil {
+		t.Fatalf("err = %v, want the per-upload deadline to allow the slow upload", err)
+	}
+
+	c.HTTPClient = nil
+	if _, err := c.UploadContext(context.Background(), TtmSh, path, WithTimeout(20*time.Millisecond)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want the per-upload timeout exceeded", err)
+	}
+}
+
+func TestURLTransform(t *testing.T) {
+	srv := newTextServer(t, `{"status": true, "data": {"file": {"url": {"full": "https://anonfiles.com/abc/notes_txt", "short": "https://anonfiles.com/abc"}}}}`)
+	errRefused := errors.New("refused")
+	var providers []int
+	c := &Client{
+		Endpoints: map[int]string{AnonFiles: srv.URL},
+		URLTransform: func(provider int, url string) (string, error) {
+			providers = append(providers, provider)
+			if strings.HasSuffix(url, "/abc") {
+				return url + "?download", nil
+			}
+			return strings.Replace(url, "https://anonfiles.com/", "https://cdn.anonfiles.com/", 1), nil
+		},
+	}
+	path := writeTestFile(t, "notes.txt", []byte("hello"))
+
+	res, err := c.UploadContext(context.Background(), AnonFiles, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.FullURL != "https://cdn.anonfiles.com/abc/notes_txt" || res.ShortURL != "https://anonfiles.com/abc?download" ||
+		res.RawURL != "https://anonfiles.com/abc/notes_txt" {
+		t.Fatalf("got FullURL %s, ShortURL %s and RawURL %s", res.FullURL, res.ShortURL, res.RawURL)
+	}
+	if len(providers) != 2 || providers[0] != AnonFiles || providers[1] != AnonFiles {
+		t.Fatalf("URLTransform called for providers %v", providers)
+	}
+
+	c.URLTransform = func(int, string) (string, error) { return "", errRefused }
+	if _, err := c.UploadContext(context.Background(), AnonFiles, path); !errors.Is(err, errRefused) {
+		t.Fatalf("err = %v, want URLTransform's error", err)
+	}
+}
+
+func TestMinFileSize(t *testing.T) {
+	srv := newTextServer(t, "https://ttm.sh/abc.txt")
+	empty := writeTestFile(t, "empty.txt", nil)
+	four := writeTestFile(t, "four.txt", []byte("four"))
+	tests := []struct {
+		min     int64
+		path    string
+		refused bool
+	}{
+		{0, empty, true}, // the default refuses empty files
+		{0, four, false},
+		{4, four, false},
+		{5, four, true},
+		{-1, empty, false},
+	}
+	for _, tc := range tests {
+		c := &Client{Endpoints: map[int]string{TtmSh: srv.URL}, MinFileSize: tc.min}
+		before := len(srv.received())
+		_, err := c.UploadContext(context.Background(), TtmSh, tc.path)
+		if refused := errors.Is(err, ErrFileTooSmall); refused != tc.refused || !refused && err != nil {
+			t.Errorf("MinFileSize %d, %s: err = %v, want refused: %v", tc.min, tc.path, err, tc.refused)
+		}
+		if sent := len(srv.received()) > before; sent == tc.refused {
+			t.Errorf("MinFileSize %d, %s: sent = %v", tc.min, tc.path, sent)
+		}
+	}
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	var keys []string
+	fail := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		if fail {
+			// The upload may or may not have been stored; the client can't tell
+			w.WriteHeader(http.StatusGatewayTimeout)
+			return
+		}
+		w.Write([]byte("https://ttm.sh/abc.txt"))
+	}))
+	t.Cleanup(srv.Close)
+	c := &Client{Endpoints: map[int]string{TtmSh: srv.URL}}
+	path := writeTestFile(t, "notes.txt", []byte("hello"))
+
+	res, err := c.UploadContext(context.Background(), TtmSh, path, WithIdempotencyKey(""))
+	if err == nil {
+		t.Fatal("the first attempt succeeded")
+	}
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	if !uuid.MatchString(res.IdempotencyKey) {
+		t.Fatalf("IdempotencyKey = %q, want a random UUID", res.IdempotencyKey)
+	}
+
+	// Retrying with the reported key sends it again
+	fail = false
+	retried, err := c.UploadContext(context.Background(), TtmSh, path, WithIdempotencyKey(res.IdempotencyKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0] != res.IdempotencyKey || keys[1] != res.IdempotencyKey || retried.IdempotencyKey != res.IdempotencyKey {
+		t.Fatalf("sent keys %v, want %s for both attempts", keys, res.IdempotencyKey)
+	}
+
+	// Another upload gets its own key, and none is sent without the option
+	other, _ := c.UploadContext(context.Background(), TtmSh, path, WithIdempotencyKey(""))
+	c.UploadContext(context.Background(), TtmSh, path)
+	if other.IdempotencyKey == res.IdempotencyKey || keys[2] != other.IdempotencyKey || keys[3] != "" {
+		t.Fatalf("sent keys %v", keys)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	clock := newFakeClock()
+	now := clock.now
+	srv := newTextServer(t, "https://transfer.sh/abc/a.txt")
+	c := &Client{Endpoints: map[int]string{TransferSh: srv.URL, TtmSh: srv.URL}, Clock: clock}
+
+	tests := []struct {
+		provider int
+		opt      Option
+		maxDays  string
+		err      error
+	}{
+		{TransferSh, WithExpiry(36 * time.Hour), "2", nil},
+		{TransferSh, WithExpiresAt(now.Add(72 * time.Hour)), "3", nil},
+		{TransferSh, WithExpiresAt(now.Add(time.Minute)), "1", nil},
+		{TransferSh, WithExpiresAt(now.Add(-time.Hour)), "", ErrInvalidExpiry},
+		{TransferSh, WithExpiry(30 * 24 * time.Hour), "", ErrInvalidExpiry},
+		{TtmSh, WithExpiry(time.Hour), "", ErrExpiryUnsupported},
+	}
+	for i, tc := range tests {
+		before := len(srv.received())
+		res, err := c.UploadReaderContext(context.Background(), tc.provider, strings.NewReader("hello"), "a.txt", tc.opt)
+		if tc.err != nil {
+			if !errors.Is(err, tc.err) || len(srv.received()) != before {
+				t.Errorf("case %d: err = %v, want %v and nothing sent", i, err, tc.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		got := srv.received()[before]
+		if days := got.Header.Get("Max-Days"); days != tc.maxDays {
+			t.Errorf("case %d: Max-Days = %q, want %q", i, days, tc.maxDays)
+		}
+		if res.ExpiresAt.IsZero() || !res.ExpiresAt.After(now) {
+			t.Errorf("case %d: ExpiresAt = %v", i, res.ExpiresAt)
+		}
+	}
+}
+
+func TestResponseURLsResolved(t *testing.T) {
+	provider := SimpleJSONProvider{Name: "telegraph-like", URL: "https://files.example/upload", JSONPath: "0.src"}.Register()
+	tests := []struct {
+		src  string
+		want string
+		err  error
+	}{
+		{"/file/abc.png", "/file/abc.png", nil},
+		{"file/abc.png", "/file/abc.png", nil},
+		{"https://cdn.example/abc.png", "https://cdn.example/abc.png", nil},
+		{"http://[::1", "", ErrInvalidResponseURL},
+		{"ftp://files.example/abc.png", "", ErrInvalidResponseURL},
+		{"javascript:alert(1)", "", ErrInvalidResponseURL},
+	}
+	for _, tc := range tests {
+		srv := newTextServer(t, `[{"src": "`+tc.src+`"}]`)
+		c := &Client{Endpoints: map[int]string{provider: srv.URL + "/upload"}}
+		res, err := c.UploadReaderContext(context.Background(), provider, strings.NewReader("hello"), "abc.png")
+		if tc.err != nil {
+			if !errors.Is(err, tc.err) || res.Status {
+				t.Errorf("%q: got %+v, %v, want %v", tc.src, res, err, tc.err)
+			}
+			continue
+		}
+		want := tc.want
+		if strings.HasPrefix(want, "/") {
+			want = srv.URL + want
+		}
+		if err != nil || !res.Status || res.FullURL != want {
+			t.Errorf("%q: got %q, %v, want %q", tc.src, res.FullURL, err, want)
+		}
+	}
+}
+
+func TestStoredSizeMismatch(t *testing.T) {
+	filebin := newTextServer(t, filebinResponse)
+	anonFiles := newTextServer(t, `{"status": true, "data": {"file": {"url": {"full": "https://anonfiles.com/abc/a_txt",
+		"short": "https://anonfiles.com/abc"}, "metadata": {"id": "abc", "name": "a.txt", "size": {"bytes": 5, "readable": "5 B"}}}}}`)
+	c := &Client{Endpoints: map[int]string{Filebin: filebin.URL, AnonFiles: anonFiles.URL}}
+
+	for _, provider := range []int{Filebin, AnonFiles} {
+		res, err := c.UploadReaderContext(context.Background(), provider, strings.NewReader("hello"), "a.txt")
+		if err != nil || res.StoredSize != 5 {
+			t.Fatalf("provider %d, matching size: got stored size %d, %v", provider, res.StoredSize, err)
+		}
+
+		res, err = c.UploadReaderContext(context.Background(), provider, strings.NewReader("hello, world"), "a.txt")
+		if !errors.Is(err, ErrSizeMismatch) {
+			t.Fatalf("provider %d: err = %v, want ErrSizeMismatch", provider, err)
+		}
+		if !res.Status || res.FullURL == "" || res.Size != 12 || res.StoredSize != 5 {
+			t.Fatalf("provider %d: got %+v, want the URL kept along with both sizes", provider, res)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+//go:build go1.16
+// +build go1.16
+
+package particeps
+
+import (
+	"context"
+	"io/fs"
+)
+
+// UploadFS uploads the named file from fsys, e.g. an embed.FS, to the given provider
+func UploadFS(provider int, fsys fs.FS, name string, opts ...Option) (UniversalResponse, error) {
+	return UploadFSContext(context.Background(), provider, fsys, name, opts...)
+}
+
+// UploadFSContext is like UploadFS, but the upload is bound to ctx
+func UploadFSContext(ctx context.Context, provider int, fsys fs.FS, name string, opts ...Option) (UniversalResponse, error) {
+	return DefaultClient.UploadFSContext(ctx, provider, fsys, name, opts...)
+}
+
+// UploadFSContext uploads the named file from fsys to the given provider
+func (c *Client) UploadFSContext(ctx context.Context, provider int, fsys fs.FS, name string, opts ...Option) (UniversalResponse, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return UniversalResponse{}, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return UniversalResponse{}, err
+	}
+	if info.IsDir() {
+		return UniversalResponse{}, &fs.PathError{Op: "upload", Path: name, Err: fs.ErrInvalid}
+	}
+	res, _, err := c.upload(ctx, provider, withSize(f, info.Size()), name, opts)
+	res.ModTime = info.ModTime()
+	return res, err
+}
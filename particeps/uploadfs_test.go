@@ -0,0 +1,41 @@
+//go:build go1.16
+// +build go1.16
+
+package particeps
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestUploadFS(t *testing.T) {
+	modTime := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"assets/notes.txt": {Data: []byte("hello"), ModTime: modTime},
+	}
+	srv := newTextServer(t, "https://transfer.sh/abc/notes.txt")
+	c := &Client{Endpoints: map[int]string{TransferSh: srv.URL}}
+
+	res, err := c.UploadFSContext(context.Background(), TransferSh, fsys, "assets/notes.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Status || res.Size != 5 || !res.ModTime.Equal(modTime) {
+		t.Fatalf("got %+v, want a 5 byte upload modified at %v", res, modTime)
+	}
+	req := srv.received()[0]
+	if req.Path != "/notes.txt" || string(req.Body) != "hello" || req.ContentLength != 5 {
+		t.Fatalf("sent %s with %q (%d bytes), want /notes.txt with its content", req.Path, req.Body, req.ContentLength)
+	}
+
+	if _, err := c.UploadFSContext(context.Background(), TransferSh, fsys, "missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("err = %v for a missing file, want fs.ErrNotExist", err)
+	}
+	if _, err := c.UploadFSContext(context.Background(), TransferSh, fsys, "assets"); !errors.Is(err, fs.ErrInvalid) {
+		t.Fatalf("err = %v for a directory, want fs.ErrInvalid", err)
+	}
+}
@@ -0,0 +1,101 @@
+package particeps
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// spotCheckSample is how many bytes are compared at each sampled offset
+const spotCheckSample = 64 << 10
+
+// ErrVerifyFailed is returned when the uploaded file, as served by the provider, doesn't match the local file
+var ErrVerifyFailed = errors.New("particeps: uploaded file doesn't match the local file")
+
+// SpotCheck compares the start, middle and end of the file served at url with the local file,
+// using range requests, and checks that the sizes match. It's far cheaper than downloading
+// large files again, and only works for URLs that serve the file itself rather than a download page.
+func SpotCheck(ctx context.Context, url, filename string) error {
+	return DefaultClient.SpotCheck(ctx, url, filename)
+}
+
+// SpotCheck compares samples of the file served at url with the local file. See SpotCheck.
+func (c *Client) SpotCheck(ctx context.Context, url, filename string) error {
+	f, err := c.fileSystem().Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return c.spotCheck(ctx, url, f)
+}
+
+// spotCheck compares samples of the file served at url with f
+func (c *Client) spotCheck(ctx context.Context, url string, f File) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	size := info.Size()
+	if size == 0 {
+		return nil
+	}
+	sample := int64(spotCheckSample)
+	if sample > size {
+		sample = size
+	}
+	for _, offset := range []int64{0, (size - sample) / 2, size - sample} {
+		local := make([]byte, sample)
+		if _, err := f.ReadAt(local, offset); err != nil {
+			return err
+		}
+		remote, err := c.fetchRange(ctx, url, offset, sample, size)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(local, remote) {
+			return fmt.Errorf("%w: bytes %d-%d differ", ErrVerifyFailed, offset, offset+sample-1)
+		}
+	}
+	return nil
+}
+
+// fetchRange downloads length bytes of url starting at offset, checking that the remote file is size bytes long
+func (c *Client) fetchRange(ctx context.Context, url string, offset, length, size int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent {
+		return nil, fmt.Errorf("%w: range request answered with %s", ErrVerifyFailed, resp.Status)
+	}
+	total, ok := contentRangeTotal(resp.Header.Get("Content-Range"))
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid Content-Range %q", ErrVerifyFailed, resp.Header.Get("Content-Range"))
+	}
+	if total != size {
+		return nil, fmt.Errorf("%w: remote file is %d bytes, local file is %d", ErrVerifyFailed, total, size)
+	}
+	return ioutil.ReadAll(io.LimitReader(resp.Body, length))
+}
+
+// contentRangeTotal returns the complete length from a "bytes start-end/total" Content-Range header
+func contentRangeTotal(header string) (int64, bool) {
+	i := strings.LastIndexByte(header, '/')
+	if !strings.HasPrefix(header, "bytes ") || i < 0 {
+		return 0, false
+	}
+	total, err := strconv.ParseInt(header[i+1:], 10, 64)
+	return total, err == nil
+}
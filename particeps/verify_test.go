@@ -0,0 +1,86 @@
+package particeps
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// rangeServer accepts uploads and serves back what serve returns for the uploaded data, honouring range
+// requests unless noRanges is set
+func rangeServer(t *testing.T, serve func([]byte) []byte, noRanges bool) *httptest.Server {
+	var stored []byte
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			data, _ := ioutil.ReadAll(r.Body)
+			stored = serve(data)
+			w.Write([]byte(srv.URL + "/file"))
+			return
+		}
+		if noRanges {
+			w.Write(stored)
+			return
+		}
+		http.ServeContent(w, r, "file", time.Time{}, bytes.NewReader(stored))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSpotCheckVerify(t *testing.T) {
+	data := make([]byte, 3*spotCheckSample+1000)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	path := writeTestFile(t, "data.bin", data)
+	tests := []struct {
+		name     string
+		serve    func([]byte) []byte
+		noRanges bool
+		ok       bool
+	}{
+		{"intact", func(b []byte) []byte { return b }, false, true},
+		{"corrupted", func(b []byte) []byte {
+			corrupted := append([]byte(nil), b...)
+			corrupted[len(b)/2] ^= 0xFF
+			return corrupted
+		}, false, false},
+		{"truncated", func(b []byte) []byte { return b[:len(b)-1] }, false, false},
+		{"no ranges", func(b []byte) []byte { return b }, true, false},
+	}
+	for _, tc := range tests {
+		srv := rangeServer(t, tc.serve, tc.noRanges)
+		c := &Client{Endpoints: map[int]string{TtmSh: srv.URL}, SpotCheckVerify: true}
+		_, err := c.UploadContext(context.Background(), TtmSh, path)
+		if tc.ok && err != nil {
+			t.Errorf("%s: err = %v", tc.name, err)
+		}
+		if !tc.ok && !errors.Is(err, ErrVerifyFailed) {
+			t.Errorf("%s: err = %v, want ErrVerifyFailed", tc.name, err)
+		}
+	}
+}
+
+func TestContentRangeTotal(t *testing.T) {
+	tests := []struct {
+		header string
+		total  int64
+		ok     bool
+	}{
+		{"bytes 0-99/1000", 1000, true},
+		{"bytes 0-99/*", 0, false},
+		{"0-99/1000", 0, false},
+		{"", 0, false},
+	}
+	for _, tc := range tests {
+		if total, ok := contentRangeTotal(tc.header); total != tc.total || ok != tc.ok {
+			t.Errorf("contentRangeTotal(%q) = %d, %v, want %d, %v", tc.header, total, ok, tc.total, tc.ok)
+		}
+	}
+}
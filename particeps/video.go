@@ -0,0 +1,107 @@
+package particeps
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// checkVideoDuration fails with ErrVideoTooLong if r holds a video longer than the provider's MaxVideoDuration.
+// Only MP4 and QuickTime videos that can be read at random, such as files, are measured; anything else passes.
+func checkVideoDuration(r io.Reader, filename string, def *providerDef) error {
+	max := def.caps.MaxVideoDuration
+	if max <= 0 {
+		return nil
+	}
+	video, ok := remainder(r)
+	if !ok {
+		return nil
+	}
+	if d, ok := mp4Duration(video); ok && d > max {
+		return fmt.Errorf("%w: %s lasts %s, %s takes videos up to %s", ErrVideoTooLong, filename, d, def.name, max)
+	}
+	return nil
+}
+
+// remainder returns what is left to read in r for random access, if r allows it, without consuming r
+func remainder(r io.Reader) (*io.SectionReader, bool) {
+	ra, ok := r.(io.ReaderAt)
+	seeker, ok2 := r.(io.Seeker)
+	size := readerSize(r)
+	if !ok || !ok2 || size < 0 {
+		return nil, false
+	}
+	offset, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, false
+	}
+	return io.NewSectionReader(ra, offset, size), true
+}
+
+// mp4Duration reads a video's duration from the movie header of an MP4 or QuickTime file.
+// The header may come after the media data, so the whole file is walked box by box.
+func mp4Duration(r *io.SectionReader) (time.Duration, bool) {
+	start, end, ok := findBox(r, 0, r.Size(), "moov")
+	if ok {
+		start, end, ok = findBox(r, start, end, "mvhd")
+	}
+	if !ok {
+		return 0, false
+	}
+	header := make([]byte, 32)
+	if end-start < int64(len(header)) {
+		header = header[:end-start]
+	}
+	if _, err := r.ReadAt(header, start); err != nil {
+		return 0, false
+	}
+	var timescale, duration uint64
+	switch {
+	case len(header) >= 20 && header[0] == 0:
+		timescale, duration = uint64(binary.BigEndian.Uint32(header[12:16])), uint64(binary.BigEndian.Uint32(header[16:20]))
+		if duration == 1<<32-1 {
+			return 0, false // unknown
+		}
+	case len(header) >= 32 && header[0] == 1:
+		timescale, duration = uint64(binary.BigEndian.Uint32(header[20:24])), binary.BigEndian.Uint64(header[24:32])
+		if duration == 1<<64-1 {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	if timescale == 0 {
+		return 0, false
+	}
+	return time.Duration(float64(duration) / float64(timescale) * float64(time.Second)), true
+}
+
+// findBox looks for the box of the given type among those between start and end,
+// returning where its contents start and end
+func findBox(r io.ReaderAt, start, end int64, boxType string) (int64, int64, bool) {
+	header := make([]byte, 16)
+	for offset := start; offset+8 <= end; {
+		if _, err := r.ReadAt(header[:8], offset); err != nil {
+			return 0, 0, false
+		}
+		size, headerSize := int64(binary.BigEndian.Uint32(header[:4])), int64(8)
+		switch size {
+		case 0: // extends to the end
+			size = end - offset
+		case 1: // 64-bit size
+			if _, err := r.ReadAt(header[8:], offset+8); err != nil {
+				return 0, 0, false
+			}
+			size, headerSize = int64(binary.BigEndian.Uint64(header[8:])), 16
+		}
+		if size < headerSize || size > end-offset {
+			return 0, 0, false
+		}
+		if string(header[4:8]) == boxType {
+			return offset + headerSize, offset + size, true
+		}
+		offset += size
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,91 @@
+package particeps
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mp4Box encodes a box of the given type around the contents
+func mp4Box(boxType string, contents ...[]byte) []byte {
+	body := bytes.Join(contents, nil)
+	box := make([]byte, 8, 8+len(body))
+	binary.BigEndian.PutUint32(box, uint32(8+len(body)))
+	copy(box[4:], boxType)
+	return append(box, body...)
+}
+
+// testMP4 builds an MP4 file lasting duration/timescale seconds, with its movie header after the media data
+func testMP4(version byte, timescale uint32, duration uint64) []byte {
+	var mvhd []byte
+	if version == 0 {
+		mvhd = make([]byte, 100)
+		binary.BigEndian.PutUint32(mvhd[12:], timescale)
+		binary.BigEndian.PutUint32(mvhd[16:], uint32(duration))
+	} else {
+		mvhd = make([]byte, 112)
+		mvhd[0] = 1
+		binary.BigEndian.PutUint32(mvhd[20:], timescale)
+		binary.BigEndian.PutUint64(mvhd[24:], duration)
+	}
+	return bytes.Join([][]byte{
+		mp4Box("ftyp", []byte("mp42\x00\x00\x00\x00mp42isom")),
+		mp4Box("mdat", make([]byte, 1000)),
+		mp4Box("moov", mp4Box("mvhd", mvhd), mp4Box("trak", make([]byte, 16))),
+	}, nil)
+}
+
+func TestMP4Duration(t *testing.T) {
+	// A media data box with a 64-bit size
+	large := []byte("\x00\x00\x00\x01mdat\x00\x00\x00\x00\x00\x00\x00\x18\x00\x00\x00\x00\x00\x00\x00\x00")
+	tests := []struct {
+		name string
+		data []byte
+		want time.Duration
+		ok   bool
+	}{
+		{"version 0", testMP4(0, 1000, 90000), 90 * time.Second, true},
+		{"version 1", testMP4(1, 600, 600*30+300), 30*time.Second + 500*time.Millisecond, true},
+		{"64-bit box size", append(large, testMP4(0, 1, 42)...), 42 * time.Second, true},
+		{"unknown duration", testMP4(0, 1000, 1<<32-1), 0, false},
+		{"no movie header", smallMP4, 0, false},
+		{"truncated", testMP4(0, 1000, 90000)[:500], 0, false},
+		{"not a video", []byte("\x89PNG\r\n\x1a\n" + strings.Repeat("x", 100)), 0, false},
+	}
+	for _, tc := range tests {
+		got, ok := mp4Duration(io.NewSectionReader(bytes.NewReader(tc.data), 0, int64(len(tc.data))))
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("%s: got %s, %v, want %s, %v", tc.name, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestImgurVideoDurationLimit(t *testing.T) {
+	var sent int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent++
+		w.Write([]byte(`{"data": {"id": "vid1234", "link": "https://i.imgur.com/vid1234.mp4", "type": "video/mp4"},
+			"success": true, "status": 200}`))
+	}))
+	defer srv.Close()
+	c := imgurClient(srv)
+
+	long := writeTestFile(t, "long.mp4", testMP4(0, 1000, 90000))
+	if _, err := c.UploadContext(context.Background(), Imgur, long); !errors.Is(err, ErrVideoTooLong) {
+		t.Fatalf("90s video: err = %v, want ErrVideoTooLong", err)
+	}
+	if sent != 0 {
+		t.Fatal("the video over the limit was sent")
+	}
+	short := writeTestFile(t, "short.mp4", testMP4(0, 1000, 30000))
+	if _, err := c.UploadContext(context.Background(), Imgur, short); err != nil || sent != 1 {
+		t.Fatalf("30s video: err = %v, %d requests sent", err, sent)
+	}
+}
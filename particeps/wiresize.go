@@ -0,0 +1,78 @@
+package particeps
+
+import (
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+)
+
+// WireSize returns how many bytes the body of a request uploading the given file to provider would be, with the
+// given options: the file's size, after StripMetadata and WithGzip if they apply, plus the multipart encoding
+// around it for providers that send the file as a form. It accounts for the file part only, not for form fields
+// such as a password, nor for the several requests of chunked uploads.
+func WireSize(provider int, filename string, opts ...Option) (int64, error) {
+	return DefaultClient.WireSize(provider, filename, opts...)
+}
+
+// WireSize returns the size of the request body uploading the given file to provider would send
+func (c *Client) WireSize(provider int, filename string, opts ...Option) (int64, error) {
+	def, err := lookupProvider(provider)
+	if err != nil {
+		return 0, err
+	}
+	f, filename, err := c.openFile(filename)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	o := newUploadOptions(opts)
+	if o.remoteName != "" {
+		filename = o.remoteName
+	}
+	if tmpl := c.filenameTemplate(provider, o); tmpl != "" {
+		if filename, err = expandFilenameTemplate(tmpl, filename, f, o.sha256, c.clock().Now()); err != nil {
+			return 0, err
+		}
+	}
+	u := &uploadRequest{provider: provider, r: f, filename: c.sanitizeFilename(remoteFilename(filename)), opts: o}
+	if err := c.stripMetadata(u); err != nil {
+		return 0, err
+	}
+	if o.gzip {
+		compressed, err := gzipUpload(u)
+		if err != nil {
+			return 0, err
+		}
+		if compressed != nil {
+			defer compressed.Close()
+		}
+	}
+	size := readerSize(u.r)
+	if size < 0 {
+		if size, err = io.Copy(ioutil.Discard, u.r); err != nil {
+			return 0, err
+		}
+	}
+	if def.shape.raw {
+		return size, nil
+	}
+	overhead, err := multipartOverhead(def.shape.field, u.filename)
+	return size + overhead, err
+}
+
+// multipartOverhead returns the bytes a multipart form holding a single file part adds to the file's contents
+func multipartOverhead(field, filename string) (int64, error) {
+	if field == "" {
+		field = "file"
+	}
+	counted := &countingWriter{}
+	mw := multipart.NewWriter(counted)
+	if _, err := createFormFile(mw, field, filename); err != nil {
+		return 0, err
+	}
+	if err := mw.Close(); err != nil {
+		return 0, err
+	}
+	return counted.n, nil
+}
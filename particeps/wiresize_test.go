@@ -0,0 +1,39 @@
+package particeps
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestWireSize(t *testing.T) {
+	srv := newTextServer(t, `{"success": true, "files": [{"url": "https://a.uguu.se/abc.txt"}]}`)
+	c := &Client{Endpoints: map[int]string{Uguu: srv.URL, TransferSh: srv.URL}}
+	path := writeTestFile(t, `my "notes"; final.txt`, []byte(strings.Repeat("particeps ", 5000)))
+
+	tests := []struct {
+		provider int
+		opts     []Option
+	}{
+		{Uguu, nil},
+		{Uguu, []Option{WithRemoteName("renamed.log")}},
+		{Uguu, []Option{WithGzip()}},
+		{TransferSh, nil},
+		{TransferSh, []Option{WithGzip()}},
+	}
+	for i, tc := range tests {
+		size, err := c.WireSize(tc.provider, path, tc.opts...)
+		if err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		before := len(srv.received())
+		c.UploadContext(context.Background(), tc.provider, path, tc.opts...)
+		got := srv.received()
+		if len(got) != before+1 {
+			t.Fatalf("case %d: the upload wasn't sent", i)
+		}
+		if sent := int64(len(got[before].Body)); size != sent {
+			t.Errorf("case %d: WireSize = %d, but %d bytes were sent", i, size, sent)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package particeps
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrOutsideBaseDir is returned when a file to be zipped isn't under the directory given to WithBaseDir
+var ErrOutsideBaseDir = errors.New("particeps: file is outside the base directory")
+
+// UploadZip zips every regular file under dir, recursively, and uploads the archive as a single file
+// named after dir, e.g. "photos.zip". Entries are stored under their base names unless WithBaseDir is given.
+// The archive is built while it is uploaded, so it is never held in memory or written to disk.
+func UploadZip(provider int, dir string, opts ...Option) (UniversalResponse, error) {
+	return DefaultClient.UploadZipContext(context.Background(), provider, dir, opts...)
+}
+
+// UploadZipContext zips every regular file under dir and uploads the archive to the given provider
+func (c *Client) UploadZipContext(ctx context.Context, provider int, dir string, opts ...Option) (UniversalResponse, error) {
+	var filenames []string
+	err := c.walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			filenames = append(filenames, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return UniversalResponse{}, err
+	}
+	o := newUploadOptions(opts)
+	entries, err := zipEntryNames(filenames, o.baseDir, o.renameCollisions)
+	if err != nil {
+		return UniversalResponse{}, err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(c.writeZip(pw, filenames, entries))
+	}()
+	defer pr.Close()
+	name := remoteFilename(filepath.Clean(dir)) + ".zip"
+	return c.UploadReaderContext(ctx, provider, pr, name, opts...)
+}
+
+// zipEntryNames returns the name each file is stored under in the archive: its path relative to baseDir,
+// with forward slashes, or, if baseDir is empty, its base name, with clashes handled as in UploadDir
+func zipEntryNames(filenames []string, baseDir string, rename bool) (map[string]string, error) {
+	entries := make(map[string]string, len(filenames))
+	if baseDir == "" {
+		renames, err := resolveCollisions(filenames, rename)
+		if err != nil {
+			return nil, err
+		}
+		for _, filename := range filenames {
+			entries[filename] = remoteFilename(filename)
+			if renamed, ok := renames[filename]; ok {
+				entries[filename] = renamed
+			}
+		}
+		return entries, nil
+	}
+	base, err := filepath.Abs(baseDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, filename := range filenames {
+		path, err := filepath.Abs(filename)
+		if err != nil {
+			return nil, err
+		}
+		rel, err := filepath.Rel(base, path)
+		// A relative path climbing out of the base directory would let the archive write outside
+		// the directory it is extracted to
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("%w: %s is not under %s", ErrOutsideBaseDir, filename, baseDir)
+		}
+		entries[filename] = filepath.ToSlash(rel)
+	}
+	return entries, nil
+}
+
+// writeZip writes an archive of filenames, each stored under its name in entries, to w
+func (c *Client) writeZip(w io.Writer, filenames []string, entries map[string]string) error {
+	zw := zip.NewWriter(w)
+	for _, filename := range filenames {
+		if err := c.addToZip(zw, filename, entries[filename]); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func (c *Client) addToZip(zw *zip.Writer, filename, name string) error {
+	f, _, err := c.openFile(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+	entry, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, f)
+	return err
+}
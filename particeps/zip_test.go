@@ -0,0 +1,92 @@
+package particeps
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestUploadZipWithBaseDir(t *testing.T) {
+	srv := newTextServer(t, "https://transfer.sh/abc/photos.zip")
+	c := &Client{Endpoints: map[int]string{TransferSh: srv.URL}}
+	root := filepath.Join(tempDir(t), "photos")
+	files := map[string]string{"a.txt": "first", "sub/b.txt": "second", "sub/deeper/c.txt": "third"}
+	for name, data := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		baseDir string
+		want    []string
+	}{
+		{root, []string{"a.txt", "sub/b.txt", "sub/deeper/c.txt"}},
+		{filepath.Dir(root), []string{"photos/a.txt", "photos/sub/b.txt", "photos/sub/deeper/c.txt"}},
+		{"", []string{"a.txt", "b.txt", "c.txt"}},
+	}
+	for _, tc := range tests {
+		before := len(srv.received())
+		if _, err := c.UploadZipContext(context.Background(), TransferSh, root, WithBaseDir(tc.baseDir)); err != nil {
+			t.Fatalf("base %q: %v", tc.baseDir, err)
+		}
+		got := srv.received()[before]
+		if got.Path != "/photos.zip" {
+			t.Errorf("base %q: archive sent as %s", tc.baseDir, got.Path)
+		}
+		zr, err := zip.NewReader(bytes.NewReader(got.Body), int64(len(got.Body)))
+		if err != nil {
+			t.Fatalf("base %q: %v", tc.baseDir, err)
+		}
+		var names []string
+		for _, f := range zr.File {
+			names = append(names, f.Name)
+			rc, _ := f.Open()
+			data, _ := ioutil.ReadAll(rc)
+			rc.Close()
+			if name := strings.TrimPrefix(f.Name, "photos/"); tc.baseDir != "" && string(data) != files[name] {
+				t.Errorf("base %q: %s holds %q, want %q", tc.baseDir, f.Name, data, files[name])
+			}
+		}
+		sort.Strings(names)
+		if strings.Join(names, " ") != strings.Join(tc.want, " ") {
+			t.Errorf("base %q: archive holds %v, want %v", tc.baseDir, names, tc.want)
+		}
+	}
+}
+
+func TestUploadZipOutsideBaseDir(t *testing.T) {
+	srv := newTextServer(t, "https://transfer.sh/abc/photos.zip")
+	c := &Client{Endpoints: map[int]string{TransferSh: srv.URL}}
+	root := tempDir(t)
+	writeFile := func(name string) {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("a.txt")
+	writeFile(filepath.Join("sub", "b.txt"))
+
+	_, err := c.UploadZipContext(context.Background(), TransferSh, root, WithBaseDir(filepath.Join(root, "sub")))
+	if !errors.Is(err, ErrOutsideBaseDir) {
+		t.Fatalf("err = %v, want ErrOutsideBaseDir", err)
+	}
+	if n := len(srv.received()); n != 0 {
+		t.Fatalf("%d requests sent", n)
+	}
+}
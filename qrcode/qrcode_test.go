@@ -0,0 +1,275 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"image/color"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// rawDataModules is the number of modules left for data and error correction in a version, per the standard
+func rawDataModules(version int) int {
+	n := (16*version+128)*version + 64
+	if version >= 2 {
+		align := version/7 + 2
+		n -= (25*align-10)*align - 55
+		if version >= 7 {
+			n -= 36
+		}
+	}
+	return n
+}
+
+// readFormat reads a copy of the format information, given as the coordinates of bits 0 to 14
+func readFormat(c *Code, at func(i int) (x, y int)) int {
+	bits := 0
+	for i := 0; i < 15; i++ {
+		if x, y := at(i); c.Dark(x, y) {
+			bits |= 1 << uint(i)
+		}
+	}
+	return bits ^ 0x5412
+}
+
+func masked(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	}
+	return ((x+y)%2+x*y%3)%2 == 0
+}
+
+// decode reads back the text of a code, checking its structure and error correction along the way
+func decode(t *testing.T, c *Code) string {
+	t.Helper()
+	version := (c.Size - 17) / 4
+	if version < 1 || version >= len(versions) || c.Size != 17+4*version {
+		t.Fatalf("size %d matches no version", c.Size)
+	}
+
+	for _, corner := range [][2]int{{0, 0}, {c.Size - 7, 0}, {0, c.Size - 7}} {
+		for dy := 0; dy < 7; dy++ {
+			for dx := 0; dx < 7; dx++ {
+				d := max(abs(dx-3), abs(dy-3))
+				if c.Dark(corner[0]+dx, corner[1]+dy) != (d != 2) {
+					t.Fatalf("broken finder pattern at %v", corner)
+				}
+			}
+		}
+	}
+	for i := 8; i < c.Size-8; i++ {
+		if c.Dark(i, 6) != (i%2 == 0) || c.Dark(6, i) != (i%2 == 0) {
+			t.Fatalf("broken timing pattern at %d", i)
+		}
+	}
+
+	format := readFormat(c, func(i int) (int, int) {
+		switch {
+		case i < 6:
+			return 8, i
+		case i < 8:
+			return 8, i + 1
+		case i == 8:
+			return 7, 8
+		}
+		return 14 - i, 8
+	})
+	second := readFormat(c, func(i int) (int, int) {
+		if i < 8 {
+			return c.Size - 1 - i, 8
+		}
+		return 8, c.Size - 15 + i
+	})
+	if format != second {
+		t.Fatalf("format copies differ: %015b and %015b", format, second)
+	}
+	rem := format >> 10
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	if rem != format&0x3FF || format>>13 != 1 {
+		t.Fatalf("invalid format information %015b, or level other than L", format)
+	}
+	mask := format >> 10 & 7
+
+	data := 0
+	var bits []bool
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < c.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = c.Size - 1 - vert
+				}
+				if !c.function[y][x] {
+					data++
+					bits = append(bits, c.Dark(x, y) != masked(mask, x, y))
+				}
+			}
+		}
+	}
+	if data != rawDataModules(version) {
+		t.Fatalf("%d data modules in version %d, want %d", data, version, rawDataModules(version))
+	}
+	codewords := make([]byte, len(bits)/8)
+	for i := range codewords {
+		for j := 0; j < 8; j++ {
+			if bits[8*i+j] {
+				codewords[i] |= 1 << uint(7-j)
+			}
+		}
+	}
+
+	// Undo the interleaving and check each block's error correction: the codeword polynomial must vanish
+	// at the generator's roots
+	info := versions[version]
+	blocks := make([][]byte, len(info.blocks))
+	next := 0
+	for i := 0; next < info.dataCapacity(); i++ {
+		for b, n := range info.blocks {
+			if i < n {
+				blocks[b] = append(blocks[b], codewords[next])
+				next++
+			}
+		}
+	}
+	var text []byte
+	for _, block := range blocks {
+		text = append(text, block...)
+	}
+	for i := 0; i < info.ecPerBlock; i++ {
+		for b := range blocks {
+			blocks[b] = append(blocks[b], codewords[next])
+			next++
+		}
+	}
+	for b, block := range blocks {
+		root := byte(1)
+		for i := 0; i < info.ecPerBlock; i++ {
+			var sum byte
+			for _, cw := range block {
+				sum = gfMul(sum, root) ^ cw
+			}
+			if sum != 0 {
+				t.Fatalf("block %d fails its error correction check", b)
+			}
+			root = gfMul(root, 2)
+		}
+	}
+
+	if text[0]>>4 != 0x4 {
+		t.Fatalf("mode %x, want byte mode", text[0]>>4)
+	}
+	stream := text
+	count := int(stream[0]&0xF)<<4 | int(stream[1]>>4)
+	shift := 12
+	if version >= 10 {
+		count = count<<8 | int(stream[1]&0xF)<<4 | int(stream[2]>>4)
+		shift = 20
+	}
+	out := make([]byte, count)
+	for i := range out {
+		bit := shift + 8*i
+		out[i] = stream[bit/8]<<uint(bit%8) | stream[bit/8+1]>>uint(8-bit%8)
+	}
+	return string(out)
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	tests := []struct {
+		text    string
+		version int
+	}{
+		{"https://ttm.sh/abc.txt", 2},
+		{"a", 1},
+		{"https://filebin.net/x7k2pq9m/" + strings.Repeat("long-name-", 8) + ".tar.gz", 6},
+		{"https://example.com/" + strings.Repeat("é", 100), 9},
+		{strings.Repeat("x", 271), 10},
+	}
+	for _, tc := range tests {
+		c, err := Encode(tc.text)
+		if err != nil {
+			t.Fatalf("%q: %v", tc.text, err)
+		}
+		if version := (c.Size - 17) / 4; version != tc.version {
+			t.Errorf("%q: version %d, want %d", tc.text, version, tc.version)
+		}
+		if got := decode(t, c); got != tc.text {
+			t.Fatalf("decoded %q, want %q", got, tc.text)
+		}
+	}
+}
+
+func TestEncodeTooLong(t *testing.T) {
+	if _, err := Encode(strings.Repeat("x", 272)); !errors.Is(err, ErrTooLong) {
+		t.Fatalf("err = %v, want ErrTooLong", err)
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	c, err := Encode("https://ttm.sh/abc.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := c.WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	width := c.Size + 2*quietZone
+	if len(lines) != (width+1)/2 {
+		t.Fatalf("%d lines, want %d", len(lines), (width+1)/2)
+	}
+	for i, line := range lines {
+		if utf8.RuneCountInString(line) != width || strings.Trim(line, "█▀▄ ") != "" {
+			t.Fatalf("line %d is %q, want %d half blocks", i, line, width)
+		}
+	}
+	// The quiet zone is light, and the top-left finder pattern dark around a light ring
+	if lines[0] != strings.Repeat("█", width) || !strings.HasPrefix(lines[2], "████ ▄▄▄▄▄ ") {
+		t.Fatalf("unexpected drawing:\n%s", buf.String())
+	}
+}
+
+func TestImage(t *testing.T) {
+	c, err := Encode("https://ttm.sh/abc.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := c.Image(3)
+	if width := (c.Size + 2*quietZone) * 3; img.Bounds().Dx() != width || img.Bounds().Dy() != width {
+		t.Fatalf("image is %v, want %dx%d", img.Bounds(), width, width)
+	}
+	black, white := color.GrayModel.Convert(color.Black), color.GrayModel.Convert(color.White)
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			want := white
+			if c.Dark(x, y) {
+				want = black
+			}
+			if got := color.GrayModel.Convert(img.At((x+quietZone)*3+1, (y+quietZone)*3+1)); got != want {
+				t.Fatalf("module %d,%d is %v, want %v", x, y, got, want)
+			}
+		}
+	}
+	if got := color.GrayModel.Convert(img.At(0, 0)); got != white {
+		t.Fatal("the quiet zone isn't white")
+	}
+}